@@ -0,0 +1,71 @@
+// Command onix-import loads an ONIX 3.0 publisher feed into the catalog.
+//
+// Usage:
+//
+//	go run ./cmd/onix-import -file feed.xml [-dry-run] [-mode atomic|best_effort]
+//
+// The import report is printed as JSON on stdout; the exit status is 1 when
+// any product could not be imported.
+package main
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	file := flag.String("file", "", "path to the ONIX 3.0 XML file")
+	dryRun := flag.Bool("dry-run", false, "validate and resolve authors without writing")
+	mode := flag.String("mode", string(models.ImportModeAtomic), "commit mode: atomic or best_effort")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// The .env file is optional here so the command also runs from CI
+	_ = godotenv.Load()
+	models.DefaultCurrency = services.CurrencyFromEnv()
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal("Cannot open ONIX file:", err)
+	}
+	defer f.Close()
+
+	repositories.InitDB()
+	defer repositories.CloseDB()
+
+	auditService := services.NewAuditService(repositories.NewAuditRepository(repositories.DB))
+	importService := services.NewBookImportService(repositories.NewBookImportRepository(repositories.DB), auditService)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	report, err := importService.ImportBooks(ctx, f, models.ImportOptions{
+		Format: "onix",
+		DryRun: *dryRun,
+		Mode:   models.ImportMode(*mode),
+	})
+	if err != nil {
+		log.Fatal("ONIX import failed:", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+
+	if report.FailedRows > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultAuditLimit caps the number of audit entries returned when no limit is given
+const defaultAuditLimit = 100
+
+type AuditController struct {
+	service *services.AuditService
+}
+
+func NewAuditController(s *services.AuditService) *AuditController {
+	return &AuditController{service: s}
+}
+
+// ListAudit handles GET /api/audit?entity=book&id=42&limit=100
+func (ac *AuditController) ListAudit(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	query := r.URL.Query()
+	filter := models.AuditFilter{Entity: query.Get("entity"), Limit: defaultAuditLimit}
+	if !models.IsAuditEntity(filter.Entity) {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid 'entity', expected one of "+strings.Join(models.AuditEntities, ", "))
+		return
+	}
+	if v := query.Get("id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id <= 0 {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid 'id'")
+			return
+		}
+		filter.EntityID = id
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > 1000 {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid 'limit', expected a number between 1 and 1000")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := ac.service.ListAudit(ctx, filter)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
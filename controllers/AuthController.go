@@ -23,7 +23,7 @@ type RegisterInput struct {
 	Name     string  `json:"name" validate:"required"`
 	Email    string  `json:"email" validate:"required,email"`
 	Password string  `json:"password" validate:"required"`
-	Role     string  `json:"role" validate:"required,oneof=admin customer"`
+	Role     string  `json:"role" validate:"omitempty,oneof=admin customer"` // defaults to customer
 	Address  models.Address `json:"address"` 
 }
 
@@ -46,15 +46,14 @@ func (c *AuthController) Register(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	var input RegisterInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeRequest(w, r, &input) {
 		return
 	}
 
 	// Hash password
 	hashedPassword, err := c.AuthService.HashPassword(input.Password)
 	if err != nil {
-		http.Error(w, "Password does not meet complexity requirements", http.StatusBadRequest)
+		WriteError(w, err)
 		return
 	}
 
@@ -75,11 +74,7 @@ func (c *AuthController) Register(w http.ResponseWriter, r *http.Request) {
 	// Store user in database
 	err = c.AuthService.UserRepo.CreateUser(ctx, &user)
 	if err != nil {
-		if err == services.ErrUserExists {
-			http.Error(w, "Email already registered", http.StatusConflict)
-			return
-		}
-		http.Error(w, "User creation failed", http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -94,19 +89,14 @@ func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	var input LoginInput
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeRequest(w, r, &input) {
 		return
 	}
 
 	// Authenticate user
 	tokens, err := c.AuthService.AuthenticateUser(ctx, input.Email, input.Password)
 	if err != nil {
-		if err == services.ErrInvalidCredentials {
-			http.Error(w, "Invalid credentials", http.StatusUnauthorized)
-			return
-		}
-		http.Error(w, "Authentication failed", http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -125,17 +115,13 @@ func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 func (c *AuthController) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	refreshToken := r.Header.Get("X-Refresh-Token")
 	if refreshToken == "" {
-		http.Error(w, "Refresh token is required", http.StatusBadRequest)
+		WriteJSONError(w, http.StatusBadRequest, "Refresh token is required")
 		return
 	}
 
 	tokens, err := c.AuthService.RefreshTokens(refreshToken)
 	if err != nil {
-		if err == services.ErrInvalidToken {
-			http.Error(w, "Invalid or expired refresh token", http.StatusUnauthorized)
-			return
-		}
-		http.Error(w, "Token refresh failed", http.StatusInternalServerError)
+		WriteError(w, err)
 		return
 	}
 
@@ -25,16 +25,17 @@ func (ac *AuthorController) CreateAuthor(w http.ResponseWriter, r *http.Request)
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	var author models.Author
-	if err := json.NewDecoder(r.Body).Decode(&author); err != nil {
-		WriteJSONError(w, http.StatusBadRequest, err.Error())
+	var req models.AuthorRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
+	author := req.Author()
 	created, err := ac.service.CreateAuthor(ctx, author)
 	if err != nil {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
 		return
 	}
+	setETag(w, created.Version)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(created)
 }
@@ -61,7 +62,15 @@ func (ac *AuthorController) GetAuthor(w http.ResponseWriter, r *http.Request) {
 	// Fetch the author from the database
 	author, getErr := ac.service.GetAuthor(ctx, id)
 	if getErr != nil {
-		WriteJSONError(w, http.StatusNotFound, getErr.Error())
+		WriteError(w, getErr)
+		return
+	}
+
+	// The ID of a merged author resolves to the author it was merged into
+	if author.ID != id {
+		w.Header().Set("Content-Location", fmt.Sprintf("/api/authors/%d", author.ID))
+	}
+	if notModified(w, r, author.Version) {
 		return
 	}
 
@@ -86,24 +95,58 @@ func (ac *AuthorController) UpdateAuthor(w http.ResponseWriter, r *http.Request)
 		WriteJSONError(w, http.StatusBadRequest, "Invalid author ID")
 		return
 	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
 
-	var author models.Author
-	if err := json.NewDecoder(r.Body).Decode(&author); err != nil {
-		WriteJSONError(w, http.StatusBadRequest, err.Error())
+	var req models.AuthorRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
+	author := req.Author()
 
 	author.ID = id
+	author.Version = version
 
 	updatedAuthor, updateErr := ac.service.UpdateAuthor(ctx, id, author)
 	if updateErr != nil {
-		WriteJSONError(w, http.StatusNotFound, updateErr.Error())
+		WriteError(w, updateErr)
 		return
 	}
+	setETag(w, updatedAuthor.Version)
 
 	json.NewEncoder(w).Encode(updatedAuthor)
 }
 
+// PatchAuthor handles PATCH /api/authors/{id} with a JSON Merge Patch or JSON Patch body
+func (ac *AuthorController) PatchAuthor(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid author ID")
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := readPatch(w, r)
+	if !ok {
+		return
+	}
+
+	author, err := ac.service.PatchAuthor(ctx, id, version, r.Header.Get("Content-Type"), patch)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	setETag(w, author.Version)
+	json.NewEncoder(w).Encode(author)
+}
+
 func (ac *AuthorController) DeleteAuthor(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
@@ -120,10 +163,14 @@ func (ac *AuthorController) DeleteAuthor(w http.ResponseWriter, r *http.Request)
 		WriteJSONError(w, http.StatusBadRequest, "Invalid author ID")
 		return
 	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
 
-	err = ac.service.DeleteAuthor(ctx, id)
+	err = ac.service.DeleteAuthor(ctx, id, version)
 	if err != nil {
-		WriteJSONError(w, http.StatusConflict, err.Error())
+		WriteError(w, err)
 		return
 	}
 
@@ -134,13 +181,34 @@ func (ac *AuthorController) DeleteAuthor(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// RestoreAuthor handles POST /api/authors/{id}/restore
+func (ac *AuthorController) RestoreAuthor(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid author ID")
+		return
+	}
+
+	author, err := ac.service.RestoreAuthor(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	setETag(w, author.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(author)
+}
+
 func (ac *AuthorController) ListAuthors(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
 	authors, err := ac.service.ListAuthors(ctx)
 	if err != nil {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
 		return
 	}
 	json.NewEncoder(w).Encode(authors)
@@ -153,17 +221,75 @@ func (ac *AuthorController) SearchAuthors(w http.ResponseWriter, r *http.Request
 	firstName := r.URL.Query().Get("first_name")
 	lastName := r.URL.Query().Get("last_name")
 
+	withDeleted, ok := includeDeleted(w, r)
+	if !ok {
+		return
+	}
+
 	criteria := models.AuthorCriteriaModel{
-		FirstName: firstName,
-		LastName:  lastName,
+		FirstName:      firstName,
+		LastName:       lastName,
+		IncludeDeleted: withDeleted,
 	}
 
 	authors, err := ac.service.SearchAuthors(ctx, criteria)
 	if err != nil {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(authors)
 }
+
+// FindDuplicateAuthors handles GET /api/authors/duplicates?min_score=0.7
+func (ac *AuthorController) FindDuplicateAuthors(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	minScore := 0.7
+	if v := r.URL.Query().Get("min_score"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid 'min_score', expected a number between 0 and 1")
+			return
+		}
+		minScore = parsed
+	}
+
+	duplicates, err := ac.service.FindDuplicateAuthors(ctx, minScore)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(duplicates)
+}
+
+// MergeAuthor handles POST /api/authors/{id}/merge with a body of
+// {"SourceID": n}; author n is folded into author {id}
+func (ac *AuthorController) MergeAuthor(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid author ID")
+		return
+	}
+
+	var body models.MergeRequest
+	if !decodeRequest(w, r, &body) {
+		return
+	}
+
+	merged, err := ac.service.MergeAuthors(ctx, id, body.SourceID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(merged)
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"FinalProject/models"
 	"FinalProject/services"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -15,30 +16,30 @@ import (
 
 type BookController struct {
 	service *services.BookService
+	pricing *services.PricingService
 }
 
-func NewBookController(s *services.BookService) *BookController {
-	return &BookController{service: s}
+func NewBookController(s *services.BookService, pricing *services.PricingService) *BookController {
+	return &BookController{service: s, pricing: pricing}
 }
 
 func (bc *BookController) CreateBook(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	var book models.Book
-	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-		WriteJSONError(w, http.StatusBadRequest, "Invalid JSON")
+	var req models.BookRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
+	book := req.Book()
 
 	created, err := bc.service.CreateBook(ctx, book)
 	if err != nil {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(created)
+	writeBodyTagged(w, http.StatusCreated, created.Version, created)
 }
 
 func (bc *BookController) GetBook(w http.ResponseWriter, r *http.Request) {
@@ -63,12 +64,28 @@ func (bc *BookController) GetBook(w http.ResponseWriter, r *http.Request) {
 	// Fetch the book from the database
 	book, err := bc.service.GetBook(ctx, id)
 	if err != nil {
-		WriteJSONError(w, http.StatusNotFound, err.Error())
+		WriteError(w, err)
+		return
+	}
+	// Prices are shown in ?currency, or that of the user's country
+	localized := []models.Book{book}
+	if err := bc.pricing.LocalizeBooks(ctx, localized, r.URL.Query().Get("currency")); err != nil {
+		WriteError(w, err)
+		return
+	}
+	// The body changes with the currency and the available copies as well
+	// as with the version, so the tag covers all of it
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(localized[0]); err != nil {
+		WriteError(w, err)
+		return
+	}
+	if notModifiedTag(w, r, bodyETag(book.Version, body.Bytes())) {
 		return
 	}
 
 	// Return the book as JSON
-	json.NewEncoder(w).Encode(book)
+	w.Write(body.Bytes())
 }
 
 func (bc *BookController) UpdateBook(w http.ResponseWriter, r *http.Request) {
@@ -88,19 +105,51 @@ func (bc *BookController) UpdateBook(w http.ResponseWriter, r *http.Request) {
 		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID")
 		return
 	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
 
-	var book models.Book
-	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-		WriteJSONError(w, http.StatusBadRequest, err.Error())
+	var req models.BookRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
+	book := req.Book()
+	book.Version = version
 
 	updated, updateErr := bc.service.UpdateBook(ctx, id, book)
 	if updateErr != nil {
-		WriteJSONError(w, http.StatusNotFound, updateErr.Error())
+		WriteError(w, updateErr)
+		return
+	}
+	writeBodyTagged(w, http.StatusOK, updated.Version, updated)
+}
+
+// PatchBook handles PATCH /api/books/{id} with a JSON Merge Patch or JSON Patch body
+func (bc *BookController) PatchBook(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID")
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := readPatch(w, r)
+	if !ok {
+		return
+	}
+
+	book, err := bc.service.PatchBook(ctx, id, version, r.Header.Get("Content-Type"), patch)
+	if err != nil {
+		WriteError(w, err)
 		return
 	}
-	json.NewEncoder(w).Encode(updated)
+	writeBodyTagged(w, http.StatusOK, book.Version, book)
 }
 
 func (bc *BookController) DeleteBook(w http.ResponseWriter, r *http.Request) {
@@ -120,10 +169,14 @@ func (bc *BookController) DeleteBook(w http.ResponseWriter, r *http.Request) {
 		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID")
 		return
 	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
 
-	err = bc.service.DeleteBook(ctx, id)
+	err = bc.service.DeleteBook(ctx, id, version)
 	if err != nil {
-		WriteJSONError(w, http.StatusNotFound, err.Error())
+		WriteError(w, err)
 		return
 	}
 
@@ -141,17 +194,53 @@ func (bc *BookController) SearchBooks(w http.ResponseWriter, r *http.Request) {
 	title := r.URL.Query().Get("title")
 	author := r.URL.Query().Get("author")
 	genre := r.URL.Query().Get("genre")
+	role := r.URL.Query().Get("role")
+
+	if role != "" && !models.IsContributorRole(role) {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid contributor role")
+		return
+	}
+
+	withDeleted, ok := includeDeleted(w, r)
+	if !ok {
+		return
+	}
 
 	criteria := models.SearchCriteria{
-		Title:  title,
-		Author: author,
-		Genre:  genre,
+		Title:          title,
+		Author:         author,
+		Role:           role,
+		Genre:          genre,
+		IncludeDeleted: withDeleted,
 	}
 
 	books, err := bc.service.SearchBooks(ctx, criteria)
 	if err != nil {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
+		return
+	}
+	if err := bc.pricing.LocalizeBooks(ctx, books, r.URL.Query().Get("currency")); err != nil {
+		WriteError(w, err)
 		return
 	}
 	json.NewEncoder(w).Encode(books)
 }
+
+// RestoreBook handles POST /api/books/{id}/restore
+func (bc *BookController) RestoreBook(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID format")
+		return
+	}
+
+	book, err := bc.service.RestoreBook(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	writeBodyTagged(w, http.StatusOK, book.Version, book)
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type BookCoverController struct {
+	service *services.CoverService
+}
+
+func NewBookCoverController(s *services.CoverService) *BookCoverController {
+	return &BookCoverController{service: s}
+}
+
+// UploadCover handles POST /api/books/{id}/cover. The image is sent either
+// as the raw request body or as the "cover" field of a multipart form.
+func (cc *BookCoverController) UploadCover(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID")
+		return
+	}
+
+	// Leave room for multipart headers around the image itself
+	r.Body = http.MaxBytesReader(w, r.Body, models.MaxCoverBytes+64<<10)
+
+	var src io.Reader = r.Body
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("cover")
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Missing 'cover' file field")
+			return
+		}
+		defer file.Close()
+		src = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(src, models.MaxCoverBytes+1))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			WriteJSONError(w, http.StatusRequestEntityTooLarge, "Cover image is too large")
+			return
+		}
+		WriteJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(data) > models.MaxCoverBytes {
+		WriteJSONError(w, http.StatusRequestEntityTooLarge, "Cover image is too large")
+		return
+	}
+
+	book, err := cc.service.SetCover(ctx, id, data)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(book)
+}
+
+// DeleteCover handles DELETE /api/books/{id}/cover
+func (cc *BookCoverController) DeleteCover(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID")
+		return
+	}
+
+	book, err := cc.service.DeleteCover(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(book)
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxImportBodySize caps the size of a single import upload (50 MB)
+const maxImportBodySize = 50 << 20
+
+type BookImportController struct {
+	service *services.BookImportService
+}
+
+func NewBookImportController(s *services.BookImportService) *BookImportController {
+	return &BookImportController{service: s}
+}
+
+// ImportBooks handles POST /api/books/import
+//
+// Query parameters:
+//   - format: "csv", "ndjson" or "onix" (defaults to the request Content-Type)
+//   - dry_run: "true" to validate and resolve authors without writing
+//   - mode: "atomic" (default) or "best_effort"
+func (ic *BookImportController) ImportBooks(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = importFormatFromContentType(r.Header.Get("Content-Type"))
+	}
+	if format != "csv" && format != "ndjson" && format != "onix" {
+		WriteJSONError(w, http.StatusUnsupportedMediaType, "Import format must be csv, ndjson or onix")
+		return
+	}
+
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid 'dry_run' value, expected true or false")
+			return
+		}
+		dryRun = parsed
+	}
+
+	mode := models.ImportMode(r.URL.Query().Get("mode"))
+	if mode == "" {
+		mode = models.ImportModeAtomic
+	}
+	if mode != models.ImportModeAtomic && mode != models.ImportModeBestEffort {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid 'mode' value, expected atomic or best_effort")
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBodySize)
+	report, err := ic.service.ImportBooks(ctx, body, models.ImportOptions{
+		Format: format,
+		DryRun: dryRun,
+		Mode:   mode,
+	})
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if report.Committed {
+		status = http.StatusCreated
+	} else if !report.DryRun && report.FailedRows > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+func importFormatFromContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return "csv"
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/json-lines":
+		return "ndjson"
+	case "application/xml", "text/xml":
+		return "onix"
+	}
+	return ""
+}
+
+// ImportONIX handles POST /api/books/import/onix, a shortcut for format=onix
+func (ic *BookImportController) ImportONIX(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	q.Set("format", "onix")
+	r.URL.RawQuery = q.Encode()
+	ic.ImportBooks(w, r)
+}
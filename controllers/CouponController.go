@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type CouponController struct {
+	service *services.CouponService
+}
+
+func NewCouponController(s *services.CouponService) *CouponController {
+	return &CouponController{service: s}
+}
+
+// CreateCoupon handles POST /api/coupons
+func (cc *CouponController) CreateCoupon(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.CouponRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	created, err := cc.service.CreateCoupon(ctx, req.Coupon())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetCoupon handles GET /api/coupons/{id}
+func (cc *CouponController) GetCoupon(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid coupon ID")
+		return
+	}
+
+	coupon, err := cc.service.GetCoupon(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(coupon)
+}
+
+// UpdateCoupon handles PUT /api/coupons/{id}
+func (cc *CouponController) UpdateCoupon(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid coupon ID")
+		return
+	}
+
+	var req models.CouponRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	updated, err := cc.service.UpdateCoupon(ctx, id, req.Coupon())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteCoupon handles DELETE /api/coupons/{id}
+func (cc *CouponController) DeleteCoupon(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid coupon ID")
+		return
+	}
+
+	if err := cc.service.DeleteCoupon(ctx, id); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Coupon with ID %d successfully deleted", id),
+	})
+}
+
+// ListCoupons handles GET /api/coupons
+func (cc *CouponController) ListCoupons(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	coupons, err := cc.service.ListCoupons(ctx)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(coupons)
+}
+
+// ListRedemptions handles GET /api/coupons/{id}/redemptions
+func (cc *CouponController) ListRedemptions(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid coupon ID")
+		return
+	}
+
+	redemptions, err := cc.service.ListRedemptions(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(redemptions)
+}
+
+// CouponUsage handles GET /api/coupons/usage?from=&to=
+func (cc *CouponController) CouponUsage(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	from, to, ok := reportRange(w, r)
+	if !ok {
+		return
+	}
+	usage, err := cc.service.CouponUsage(ctx, from, to)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
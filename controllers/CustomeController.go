@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -46,7 +45,10 @@ func (cc *CustomerController) GetCustomer(w http.ResponseWriter, r *http.Request
 	// Fetch customer from the service
 	customer, getErr := cc.service.GetCustomer(ctx, id)
 	if getErr != nil {
-		WriteJSONError(w, http.StatusNotFound, getErr.Error())
+		WriteError(w, getErr)
+		return
+	}
+	if notModified(w, r, customer.Version) {
 		return
 	}
 
@@ -59,49 +61,77 @@ func (cc *CustomerController) UpdateCustomer(w http.ResponseWriter, r *http.Requ
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 3 {
-		WriteJSONError(w, http.StatusBadRequest, "missing User ID")
-		return
-	}
-	id, err := strconv.Atoi(parts[2])
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		WriteJSONError(w, http.StatusBadRequest, "invalid User ID")
 		return
 	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
 
-	var User models.User
-	if err := json.NewDecoder(r.Body).Decode(&User); err != nil {
-		WriteJSONError(w, http.StatusBadRequest, err.Error())
+	var req models.CustomerRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
+	User := req.User()
+	User.Version = version
 
 	updated, updateErr := cc.service.UpdateCustomer(ctx, id, User)
 	if updateErr != nil {
-		WriteJSONError(w, http.StatusNotFound, updateErr.Error())
+		WriteError(w, updateErr)
 		return
 	}
+	setETag(w, updated.Version)
 	json.NewEncoder(w).Encode(updated)
 }
 
-func (cc *CustomerController) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
+// PatchCustomer handles PATCH /api/customers/{id} with a JSON Merge Patch or JSON Patch body
+func (cc *CustomerController) PatchCustomer(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 3 {
-		WriteJSONError(w, http.StatusBadRequest, "missing User ID")
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "invalid User ID")
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := readPatch(w, r)
+	if !ok {
+		return
+	}
+
+	customer, err := cc.service.PatchCustomer(ctx, id, version, r.Header.Get("Content-Type"), patch)
+	if err != nil {
+		WriteError(w, err)
 		return
 	}
-	id, err := strconv.Atoi(parts[2])
+	setETag(w, customer.Version)
+	json.NewEncoder(w).Encode(customer)
+}
+
+func (cc *CustomerController) DeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
 		WriteJSONError(w, http.StatusBadRequest, "invalid User ID")
 		return
 	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
 
-	err = cc.service.DeleteCustomer(ctx, id)
+	err = cc.service.DeleteCustomer(ctx, id, version)
 	if err != nil {
-		WriteJSONError(w, http.StatusNotFound, err.Error())
+		WriteError(w, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -115,10 +145,36 @@ func (cc *CustomerController) ListCustomers(w http.ResponseWriter, r *http.Reque
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	customers, err := cc.service.ListCustomers(ctx)
+	withDeleted, ok := includeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	customers, err := cc.service.ListCustomers(ctx, withDeleted)
 	if err != nil {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
 		return
 	}
 	json.NewEncoder(w).Encode(customers)
 }
+
+// RestoreCustomer handles POST /api/customers/{id}/restore
+func (cc *CustomerController) RestoreCustomer(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid User ID format")
+		return
+	}
+
+	customer, err := cc.service.RestoreCustomer(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	setETag(w, customer.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag is the entity tag of a versioned record: its version, quoted
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// bodyETag is the entity tag of a versioned record whose response also
+// depends on more than its version, such as the currency prices are shown
+// in or the copies reservations leave available: the version and a hash of
+// the body. If-Match only compares the version.
+func bodyETag(version int, body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + strconv.Itoa(version) + "-" + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// setETag tags the response with the record's version
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", etag(version))
+}
+
+// writeBodyTagged writes v as JSON with the given status, tagged with
+// bodyETag so that writes carry the same kind of tag as reads of the record
+func writeBodyTagged(w http.ResponseWriter, status, version int, v interface{}) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(v); err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.Header().Set("ETag", bodyETag(version, body.Bytes()))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body.Bytes())
+}
+
+// notModified tags the response and answers 304 when the client already has
+// this version (If-None-Match). It reports whether the response is done.
+func notModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	return notModifiedTag(w, r, etag(version))
+}
+
+// notModifiedTag is notModified for a response tagged current
+func notModifiedTag(w http.ResponseWriter, r *http.Request, current string) bool {
+	w.Header().Set("ETag", current)
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		// If-None-Match uses weak comparison, so W/ tags match too
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion reads the version a PUT or DELETE is based on from If-Match.
+// The header is required; "*" yields 0, which matches any version. On failure
+// the error response has been written and ok is false.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (version int, ok bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		WriteJSONError(w, http.StatusPreconditionRequired, "If-Match header is required; send the ETag from a GET")
+		return 0, false
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	// If-Match uses strong comparison, so weak tags never match. Only the
+	// version of a tag with a body hash counts.
+	tag, _, _ := strings.Cut(strings.Trim(header, `"`), "-")
+	version, err := strconv.Atoi(tag)
+	if err != nil || strings.HasPrefix(header, "W/") || version <= 0 {
+		WriteJSONError(w, http.StatusPreconditionFailed, "If-Match does not match the current version")
+		return 0, false
+	}
+	return version, true
+}
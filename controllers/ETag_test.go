@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func TestWriteBodyTaggedMatchesReads(t *testing.T) {
+	book := map[string]interface{}{"ID": 7, "Title": "Dune", "Version": 3}
+
+	rec := httptest.NewRecorder()
+	writeBodyTagged(rec, http.StatusCreated, 3, book)
+	if rec.Code != http.StatusCreated || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("got %d %q, want 201 application/json", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	written := rec.Header().Get("ETag")
+	if !regexp.MustCompile(`^"3-[0-9a-f]{16}"$`).MatchString(written) {
+		t.Fatalf("write ETag = %s, want the version and a body hash", written)
+	}
+	if want := bodyETag(3, rec.Body.Bytes()); written != want {
+		t.Errorf("write ETag = %s, want %s, the tag a GET of the same body gets", written, want)
+	}
+
+	// A GET of the unchanged body is not modified for the tag a write gave
+	get := httptest.NewRequest(http.MethodGet, "/api/books/7", nil)
+	get.Header.Set("If-None-Match", written)
+	rec = httptest.NewRecorder()
+	if !notModifiedTag(rec, get, bodyETag(3, []byte(`{"ID":7,"Title":"Dune","Version":3}`+"\n"))) || rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match %s: got %d, want 304", written, rec.Code)
+	}
+}
+
+func TestIfMatchVersionTagFormats(t *testing.T) {
+	cases := []struct {
+		header  string
+		version int
+		ok      bool
+	}{
+		{`"3"`, 3, true},
+		{`"3-9f86d081884c7d65"`, 3, true},
+		{`*`, 0, true},
+		{`W/"3"`, 0, false},
+		{`W/"3-9f86d081884c7d65"`, 0, false},
+		{`"abc"`, 0, false},
+		{`"0"`, 0, false},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodPut, "/api/books/7", nil)
+		r.Header.Set("If-Match", c.header)
+		rec := httptest.NewRecorder()
+		version, ok := ifMatchVersion(rec, r)
+		if ok != c.ok || version != c.version {
+			t.Errorf("If-Match %s: got %d, %v, want %d, %v", c.header, version, ok, c.version, c.ok)
+		}
+		if !ok && rec.Code != http.StatusPreconditionFailed {
+			t.Errorf("If-Match %s: status %d, want 412", c.header, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type EditionController struct {
+	service *services.EditionService
+}
+
+func NewEditionController(s *services.EditionService) *EditionController {
+	return &EditionController{service: s}
+}
+
+// CreateEdition handles POST /api/books/{id}/editions
+func (ec *EditionController) CreateEdition(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	bookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID")
+		return
+	}
+
+	var req models.EditionRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	edition := req.Edition()
+
+	created, err := ec.service.CreateEdition(ctx, bookID, edition)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListBookEditions handles GET /api/books/{id}/editions
+func (ec *EditionController) ListBookEditions(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	bookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID")
+		return
+	}
+
+	editions, err := ec.service.ListEditions(ctx, bookID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(editions)
+}
+
+// FindEdition handles GET /api/editions?isbn=, accepting ISBN-10 or ISBN-13
+func (ec *EditionController) FindEdition(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	isbn := r.URL.Query().Get("isbn")
+	if isbn == "" {
+		WriteJSONError(w, http.StatusBadRequest, "Missing 'isbn' parameter")
+		return
+	}
+
+	edition, err := ec.service.GetEditionByISBN(ctx, isbn)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(edition)
+}
+
+func (ec *EditionController) GetEdition(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid edition ID")
+		return
+	}
+
+	edition, err := ec.service.GetEdition(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(edition)
+}
+
+func (ec *EditionController) UpdateEdition(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid edition ID")
+		return
+	}
+
+	var req models.EditionRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	edition := req.Edition()
+
+	updated, err := ec.service.UpdateEdition(ctx, id, edition)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (ec *EditionController) DeleteEdition(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid edition ID")
+		return
+	}
+
+	if err := ec.service.DeleteEdition(ctx, id); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Edition with ID %d successfully deleted", id),
+	})
+}
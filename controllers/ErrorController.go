@@ -1,19 +1,12 @@
 package controllers
 
 import (
-	"encoding/json"
-	"errors"
-	"io"
 	"log"
 	"net/http"
 	"os"
 	"sync"
 )
 
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
-
 var (
 	errorLogger *log.Logger
 	once        sync.Once
@@ -34,30 +27,8 @@ func LogError(err error) {
 	logger.Println(err.Error())
 }
 
+// WriteJSONError answers with a problem of the given status; see WriteError
+// for errors returned by the services
 func WriteJSONError(w http.ResponseWriter, statusCode int, errMsg string) {
-	LogError(errors.New(errMsg))
-	w.WriteHeader(statusCode)
-	resp := ErrorResponse{Error: errMsg}
-	err := jsonNewEncoder(w).Encode(resp)
-	if err != nil {
-		LogError(err)
-	}
-}
-
-func jsonNewEncoder(w http.ResponseWriter) *jsonEncoderWrapper {
-	return &jsonEncoderWrapper{w: w}
-}
-
-type jsonEncoderWrapper struct {
-	w http.ResponseWriter
-}
-
-func (j *jsonEncoderWrapper) Encode(v interface{}) error {
-	j.w.Header().Set("Content-Type", "application/json")
-	return EncodeAsJSON(v, j.w)
-}
-
-func EncodeAsJSON(v interface{}, w io.Writer) error {
-	enc := json.NewEncoder(w)
-	return enc.Encode(v)
+	WriteProblem(w, Problem{Status: statusCode, Code: codeForStatus(statusCode), Detail: errMsg})
 }
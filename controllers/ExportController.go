@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type ExportController struct {
+	service *services.ExportService
+}
+
+func NewExportController(s *services.ExportService) *ExportController {
+	return &ExportController{service: s}
+}
+
+// Export handles GET /api/export/{entity} and streams the rows as a download
+func (ec *ExportController) Export(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Minute)
+	defer cancel()
+
+	entity := mux.Vars(r)["entity"]
+	format := exportFormat(r)
+	contentType, ext, err := services.ExportContentType(format)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	filter, err := parseExportFilter(r)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s.%s", entity, time.Now().UTC().Format("20060102150405"), ext)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Headers are already sent once streaming starts, so later failures can
+	// only be logged and the truncated body left to the client.
+	if _, err := ec.service.Export(ctx, entity, format, filter, w); err != nil {
+		LogError(fmt.Errorf("export of %s failed: %w", entity, err))
+	}
+}
+
+// StartExportJob handles POST /api/export/{entity}/jobs
+func (ec *ExportController) StartExportJob(w http.ResponseWriter, r *http.Request) {
+	entity := mux.Vars(r)["entity"]
+
+	filter, err := parseExportFilter(r)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job, err := ec.service.StartExportJob(entity, exportFormat(r), filter)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/export/jobs/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// ListExportJobs handles GET /api/export/jobs
+func (ec *ExportController) ListExportJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ec.service.ListExportJobs())
+}
+
+// GetExportJob handles GET /api/export/jobs/{id}
+func (ec *ExportController) GetExportJob(w http.ResponseWriter, r *http.Request) {
+	job, err := ec.service.GetExportJob(mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// DownloadExportJob handles GET /api/export/jobs/{id}/download
+func (ec *ExportController) DownloadExportJob(w http.ResponseWriter, r *http.Request) {
+	job, err := ec.service.GetExportJob(mux.Vars(r)["id"])
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	if job.Status != models.ExportJobCompleted {
+		WriteJSONError(w, http.StatusConflict, fmt.Sprintf("export job %s is %s", job.ID, job.Status))
+		return
+	}
+
+	file, err := os.Open(job.FilePath)
+	if err != nil {
+		WriteJSONError(w, http.StatusGone, "export file is no longer available")
+		return
+	}
+	defer file.Close()
+
+	contentType, _, _ := services.ExportContentType(job.Format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(job.FilePath)))
+	http.ServeContent(w, r, filepath.Base(job.FilePath), *job.CompletedAt, file)
+}
+
+func exportFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format
+	}
+	return "csv"
+}
+
+// parseExportFilter reads the same query parameters as the list endpoints
+func parseExportFilter(r *http.Request) (models.ExportFilter, error) {
+	q := r.URL.Query()
+	filter := models.ExportFilter{
+		Title:     q.Get("title"),
+		Author:    q.Get("author"),
+		Genre:     q.Get("genre"),
+		FirstName: q.Get("first_name"),
+		LastName:  q.Get("last_name"),
+	}
+
+	if v := q.Get("customer_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return models.ExportFilter{}, errors.New("Invalid customer ID format")
+		}
+		filter.CustomerID = id
+	}
+
+	fromStr, toStr := q.Get("from"), q.Get("to")
+	if (fromStr == "") != (toStr == "") {
+		return models.ExportFilter{}, errors.New("Both 'from' and 'to' query parameters are required for a date range")
+	}
+	if fromStr != "" {
+		from, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return models.ExportFilter{}, errors.New("Invalid 'from' date format, expected RFC3339 (YYYY-MM-DDTHH:MM:SSZ)")
+		}
+		to, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return models.ExportFilter{}, errors.New("Invalid 'to' date format, expected RFC3339 (YYYY-MM-DDTHH:MM:SSZ)")
+		}
+		filter.From, filter.To = from, to
+	}
+	return filter, nil
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type GenreController struct {
+	service *services.GenreService
+}
+
+func NewGenreController(s *services.GenreService) *GenreController {
+	return &GenreController{service: s}
+}
+
+func (gc *GenreController) CreateGenre(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.GenreRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	genre := req.Genre()
+	created, err := gc.service.CreateGenre(ctx, genre)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (gc *GenreController) GetGenre(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid genre ID")
+		return
+	}
+
+	genre, err := gc.service.GetGenre(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(genre)
+}
+
+func (gc *GenreController) UpdateGenre(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid genre ID")
+		return
+	}
+
+	var req models.GenreRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	genre := req.Genre()
+
+	updated, err := gc.service.UpdateGenre(ctx, id, genre)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (gc *GenreController) DeleteGenre(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid genre ID")
+		return
+	}
+
+	if err := gc.service.DeleteGenre(ctx, id); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Genre with ID %d successfully deleted", id),
+	})
+}
+
+// MergeGenre handles POST /api/genres/{id}/merge with a body of
+// {"SourceID": n}; the source genre is folded into genre {id}
+func (gc *GenreController) MergeGenre(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid genre ID")
+		return
+	}
+
+	var body models.MergeRequest
+	if !decodeRequest(w, r, &body) {
+		return
+	}
+
+	merged, err := gc.service.MergeGenres(ctx, id, body.SourceID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(merged)
+}
+
+// ListGenres handles GET /api/genres; add tree=true to nest sub-genres
+// under their parents
+func (gc *GenreController) ListGenres(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if tree, _ := strconv.ParseBool(r.URL.Query().Get("tree")); tree {
+		nodes, err := gc.service.GenreTree(ctx)
+		if err != nil {
+			WriteError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(nodes)
+		return
+	}
+
+	genres, err := gc.service.ListGenres(ctx)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(genres)
+}
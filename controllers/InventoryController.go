@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type InventoryController struct {
+	service *services.InventoryService
+}
+
+func NewInventoryController(s *services.InventoryService) *InventoryController {
+	return &InventoryController{service: s}
+}
+
+// CreateLocation handles POST /api/locations
+func (ic *InventoryController) CreateLocation(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.LocationRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	created, err := ic.service.CreateLocation(ctx, req.Location())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetLocation handles GET /api/locations/{id}
+func (ic *InventoryController) GetLocation(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid location ID")
+		return
+	}
+
+	location, err := ic.service.GetLocation(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(location)
+}
+
+// UpdateLocation handles PUT /api/locations/{id}
+func (ic *InventoryController) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid location ID")
+		return
+	}
+
+	var req models.LocationRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	updated, err := ic.service.UpdateLocation(ctx, id, req.Location())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteLocation handles DELETE /api/locations/{id}
+func (ic *InventoryController) DeleteLocation(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid location ID")
+		return
+	}
+
+	if err := ic.service.DeleteLocation(ctx, id); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Location with ID %d successfully deleted", id),
+	})
+}
+
+// ListLocations handles GET /api/locations
+func (ic *InventoryController) ListLocations(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	locations, err := ic.service.ListLocations(ctx)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(locations)
+}
+
+// BookStock handles GET /api/books/{id}/stock
+func (ic *InventoryController) BookStock(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID")
+		return
+	}
+
+	levels, err := ic.service.BookStock(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(levels)
+}
+
+// TransferStock handles POST /api/stock-transfers
+func (ic *InventoryController) TransferStock(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.StockTransferRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	transfer, err := ic.service.TransferStock(ctx, req.Transfer())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transfer)
+}
+
+// ListTransfers handles GET /api/stock-transfers?book_id=
+func (ic *InventoryController) ListTransfers(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	bookID := 0
+	if v := r.URL.Query().Get("book_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid 'book_id'")
+			return
+		}
+		bookID = id
+	}
+
+	transfers, err := ic.service.ListTransfers(ctx, bookID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transfers)
+}
+
+// RecordMovement handles POST /api/books/{id}/stock-movements
+func (ic *InventoryController) RecordMovement(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID")
+		return
+	}
+	var req models.StockMovementRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	book, err := ic.service.RecordMovement(ctx, id, req.Movement())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	writeBodyTagged(w, http.StatusCreated, book.Version, book)
+}
+
+// StockHistory handles GET /api/books/{id}/stock-history?limit=
+func (ic *InventoryController) StockHistory(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID")
+		return
+	}
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > 1000 {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid 'limit', expected a number between 1 and 1000")
+			return
+		}
+	}
+
+	movements, err := ic.service.StockHistory(ctx, id, limit)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movements)
+}
+
+// StockDrift handles GET /api/stock/drift
+func (ic *InventoryController) StockDrift(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	drift, err := ic.service.StockDrift(ctx)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(drift)
+}
@@ -9,7 +9,6 @@ import (
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -27,13 +26,11 @@ func (oc *OrderController) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	var order models.Order
-
-	// Decode request JSON
-	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
-		WriteJSONError(w, http.StatusBadRequest, "Invalid JSON format")
+	var req models.OrderRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
+	order := req.Order()
 
 	// Get authenticated user info from JWT token
 	authenticatedUserID, err := strconv.Atoi(r.Header.Get("X-User-ID"))
@@ -63,10 +60,11 @@ func (oc *OrderController) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	// Allow order creation
 	created, err := oc.service.CreateOrder(ctx, order)
 	if err != nil {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
 		return
 	}
 
+	setETag(w, created.Version)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(created)
 }
@@ -92,7 +90,10 @@ func (oc *OrderController) GetOrder(w http.ResponseWriter, r *http.Request) {
 	// Fetch order from database
 	order, err := oc.service.GetOrder(ctx, id)
 	if err != nil {
-		WriteJSONError(w, http.StatusNotFound, "Order not found")
+		WriteError(w, err)
+		return
+	}
+	if notModified(w, r, order.Version) {
 		return
 	}
 
@@ -116,21 +117,55 @@ func (oc *OrderController) UpdateOrder(w http.ResponseWriter, r *http.Request) {
 		WriteJSONError(w, http.StatusBadRequest, "Invalid order ID")
 		return
 	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
 
-	var order models.Order
-	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
-		WriteJSONError(w, http.StatusBadRequest, err.Error())
+	var req models.OrderRequest
+	if !decodeRequest(w, r, &req) {
 		return
 	}
+	order := req.Order()
+	order.Version = version
 
 	updated, updateErr := oc.service.UpdateOrder(ctx, id, order)
 	if updateErr != nil {
-		WriteJSONError(w, http.StatusNotFound, updateErr.Error())
+		WriteError(w, updateErr)
 		return
 	}
+	setETag(w, updated.Version)
 	json.NewEncoder(w).Encode(updated)
 }
 
+// PatchOrder handles PATCH /api/orders/{id} with a JSON Merge Patch or JSON Patch body
+func (oc *OrderController) PatchOrder(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	patch, ok := readPatch(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := oc.service.PatchOrder(ctx, id, version, r.Header.Get("Content-Type"), patch)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	setETag(w, order.Version)
+	json.NewEncoder(w).Encode(order)
+}
+
 func (oc *OrderController) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
@@ -148,10 +183,14 @@ func (oc *OrderController) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 		WriteJSONError(w, http.StatusBadRequest, "Invalid order ID")
 		return
 	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
 
-	delErr := oc.service.DeleteOrder(ctx, id)
+	delErr := oc.service.DeleteOrder(ctx, id, version)
 	if delErr != nil {
-		WriteJSONError(w, http.StatusNotFound, delErr.Error()) // ✅ Return 404 if order not found
+		WriteError(w, delErr)
 		return
 	}
 
@@ -181,7 +220,7 @@ func (oc *OrderController) ListOrders(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
 		return
 	}
 	json.NewEncoder(w).Encode(orders)
@@ -213,7 +252,7 @@ func (oc *OrderController) GetOrdersByDateRange(w http.ResponseWriter, r *http.R
 
 	orders, err := oc.service.GetOrdersInRange(ctx, from, to)
 	if err != nil {
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
 		return
 	}
 
@@ -238,11 +277,7 @@ func (oc *OrderController) SearchOrdersByCustomerID(w http.ResponseWriter, r *ht
 
 	orders, err := oc.service.SearchOrdersByCustomerID(ctx, customerID)
 	if err != nil {
-		if strings.Contains(err.Error(), "customer with ID") {
-			WriteJSONError(w, http.StatusNotFound, err.Error())
-			return
-		}
-		WriteJSONError(w, http.StatusInternalServerError, err.Error())
+		WriteError(w, err)
 		return
 	}
 
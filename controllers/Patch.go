@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+)
+
+// maxPatchSize caps the size of a PATCH body
+const maxPatchSize = 1 << 20
+
+// readPatch reads a PATCH body. On failure the error response has been
+// written and ok is false.
+func readPatch(w http.ResponseWriter, r *http.Request) (patch []byte, ok bool) {
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		WriteJSONError(w, http.StatusRequestEntityTooLarge, "Patch is too large")
+		return nil, false
+	}
+	return patch, true
+}
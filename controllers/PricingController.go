@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxRateImportSize caps the size of an exchange-rate file (1 MB)
+const maxRateImportSize = 1 << 20
+
+type PricingController struct {
+	service *services.PricingService
+}
+
+func NewPricingController(s *services.PricingService) *PricingController {
+	return &PricingController{service: s}
+}
+
+// ListExchangeRates handles GET /api/exchange-rates
+func (pc *PricingController) ListExchangeRates(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rates, err := pc.service.ListExchangeRates(ctx)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"base":  models.DefaultCurrency,
+		"rates": rates,
+	})
+}
+
+// SetExchangeRate handles PUT /api/exchange-rates/{currency}
+func (pc *PricingController) SetExchangeRate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.ExchangeRateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	rate, err := pc.service.SetExchangeRate(ctx, mux.Vars(r)["currency"], req.Rate)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(rate)
+}
+
+// DeleteExchangeRate handles DELETE /api/exchange-rates/{currency}
+func (pc *PricingController) DeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	currency := strings.ToUpper(mux.Vars(r)["currency"])
+	if err := pc.service.DeleteExchangeRate(ctx, currency); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Exchange rate for %s successfully deleted", currency),
+	})
+}
+
+// ImportExchangeRates handles POST /api/exchange-rates/import. The format
+// ("csv" or "json") is taken from ?format or the request Content-Type.
+func (pc *PricingController) ImportExchangeRates(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil {
+			switch mediaType {
+			case "text/csv", "application/csv":
+				format = "csv"
+			case "application/json":
+				format = "json"
+			}
+		}
+	}
+	if format != "csv" && format != "json" {
+		WriteJSONError(w, http.StatusUnsupportedMediaType, "Import format must be csv or json")
+		return
+	}
+
+	rates, err := pc.service.ImportExchangeRates(ctx, http.MaxBytesReader(w, r.Body, maxRateImportSize), format)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rates)
+}
+
+// CreatePriceList handles POST /api/price-lists
+func (pc *PricingController) CreatePriceList(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.PriceListRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	created, err := pc.service.CreatePriceList(ctx, req.PriceList())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetPriceList handles GET /api/price-lists/{id}
+func (pc *PricingController) GetPriceList(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid price list ID")
+		return
+	}
+
+	list, err := pc.service.GetPriceList(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// UpdatePriceList handles PUT /api/price-lists/{id}
+func (pc *PricingController) UpdatePriceList(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid price list ID")
+		return
+	}
+
+	var req models.PriceListRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	updated, err := pc.service.UpdatePriceList(ctx, id, req.PriceList())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeletePriceList handles DELETE /api/price-lists/{id}
+func (pc *PricingController) DeletePriceList(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid price list ID")
+		return
+	}
+
+	if err := pc.service.DeletePriceList(ctx, id); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Price list with ID %d successfully deleted", id),
+	})
+}
+
+// ListPriceLists handles GET /api/price-lists
+func (pc *PricingController) ListPriceLists(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	lists, err := pc.service.ListPriceLists(ctx)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lists)
+}
+
+// ListBookPrices handles GET /api/price-lists/{id}/prices
+func (pc *PricingController) ListBookPrices(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid price list ID")
+		return
+	}
+
+	prices, err := pc.service.ListBookPrices(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prices)
+}
+
+// SetBookPrice handles PUT /api/price-lists/{id}/prices/{book_id}
+func (pc *PricingController) SetBookPrice(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid price list ID")
+		return
+	}
+	bookID, err := strconv.Atoi(mux.Vars(r)["book_id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID")
+		return
+	}
+
+	var req models.BookPriceRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	price, err := pc.service.SetBookPrice(ctx, id, bookID, req.Amount)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(price)
+}
+
+// DeleteBookPrice handles DELETE /api/price-lists/{id}/prices/{book_id}
+func (pc *PricingController) DeleteBookPrice(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid price list ID")
+		return
+	}
+	bookID, err := strconv.Atoi(mux.Vars(r)["book_id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID")
+		return
+	}
+
+	if err := pc.service.DeleteBookPrice(ctx, id, bookID); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Price of book ID %d removed from price list %d", bookID, id),
+	})
+}
+
+// BookPrices handles GET /api/books/{id}/prices
+func (pc *PricingController) BookPrices(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid book ID")
+		return
+	}
+
+	prices, err := pc.service.BookPrices(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prices)
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"FinalProject/services"
+	"FinalProject/validation"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ProblemContentType is the media type of every error response (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// Error codes of a Problem. Clients branch on these rather than on the
+// wording of Detail, so they never change meaning.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodeGone                 = "gone"
+	CodeVersionConflict      = "version_conflict"
+	CodePreconditionRequired = "precondition_required"
+	CodeValidationFailed     = "validation_failed"
+	CodeInvalidPatch         = "invalid_patch"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeRateLimited          = "rate_limited"
+	CodeTimeout              = "timeout"
+	CodeInternal             = "internal_error"
+)
+
+// Problem is the body of an error response, an RFC 7807 problem detail with
+// a stable error code and, for validation failures, the failed rules
+type Problem struct {
+	Type   string                  `json:"type"`
+	Title  string                  `json:"title"`
+	Status int                     `json:"status"`
+	Detail string                  `json:"detail,omitempty"`
+	Code   string                  `json:"code"`
+	Errors []validation.FieldError `json:"errors,omitempty"`
+}
+
+// WriteProblem writes p, filling in its type and title. Server errors are
+// logged; client errors are the client's to handle and are not.
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	if p.Status >= 500 {
+		LogError(errors.New(p.Code + ": " + p.Detail))
+	}
+	writeProblem(w, p)
+}
+
+func writeProblem(w http.ResponseWriter, p Problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		LogError(err)
+	}
+}
+
+// WriteError answers with the status and code that match err. Errors of an
+// unknown kind are reported as 500 without their message, which may leak
+// internals; server errors log err itself, once.
+func WriteError(w http.ResponseWriter, err error) {
+	p := problemFor(err)
+	if p.Status >= 500 {
+		LogError(err)
+	}
+	if p.Status == http.StatusUnsupportedMediaType {
+		w.Header().Set("Accept-Patch", services.MergePatchType+", "+services.JSONPatchType)
+	}
+	writeProblem(w, p)
+}
+
+func problemFor(err error) Problem {
+	var fields validation.Errors
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &fields):
+		return Problem{Status: http.StatusUnprocessableEntity, Code: CodeValidationFailed, Detail: "validation failed", Errors: fields}
+	case errors.Is(err, services.ErrVersionConflict):
+		return Problem{Status: http.StatusPreconditionFailed, Code: CodeVersionConflict, Detail: err.Error()}
+	case errors.Is(err, services.ErrUnsupportedPatch):
+		return Problem{Status: http.StatusUnsupportedMediaType, Code: CodeUnsupportedMediaType, Detail: err.Error()}
+	case errors.Is(err, services.ErrInvalidPatch):
+		return Problem{Status: http.StatusBadRequest, Code: CodeInvalidPatch, Detail: err.Error()}
+	case errors.Is(err, services.ErrInvalidPatched), errors.Is(err, services.ErrValidation):
+		return Problem{Status: http.StatusUnprocessableEntity, Code: CodeValidationFailed, Detail: err.Error()}
+	case errors.Is(err, services.ErrNotFound):
+		return Problem{Status: http.StatusNotFound, Code: CodeNotFound, Detail: err.Error()}
+	case errors.Is(err, services.ErrConflict):
+		return Problem{Status: http.StatusConflict, Code: CodeConflict, Detail: err.Error()}
+	case errors.Is(err, services.ErrForbidden):
+		return Problem{Status: http.StatusForbidden, Code: CodeForbidden, Detail: err.Error()}
+	case errors.Is(err, services.ErrUnauthorized):
+		return Problem{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Detail: err.Error()}
+	case errors.As(err, &tooLarge):
+		return Problem{Status: http.StatusRequestEntityTooLarge, Code: CodePayloadTooLarge, Detail: "request body is too large"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return Problem{Status: http.StatusGatewayTimeout, Code: CodeTimeout, Detail: "the request took too long"}
+	}
+	return Problem{Status: http.StatusInternalServerError, Code: CodeInternal, Detail: "an unexpected error occurred"}
+}
+
+// codeForStatus is the error code of a response written with a bare status
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusGone:
+		return CodeGone
+	case http.StatusPreconditionFailed:
+		return CodeVersionConflict
+	case http.StatusPreconditionRequired:
+		return CodePreconditionRequired
+	case http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
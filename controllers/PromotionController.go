@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type PromotionController struct {
+	service *services.PromotionService
+}
+
+func NewPromotionController(s *services.PromotionService) *PromotionController {
+	return &PromotionController{service: s}
+}
+
+// CreatePromotion handles POST /api/promotions
+func (pc *PromotionController) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.PromotionRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	created, err := pc.service.CreatePromotion(ctx, req.Promotion())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetPromotion handles GET /api/promotions/{id}
+func (pc *PromotionController) GetPromotion(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid promotion ID")
+		return
+	}
+
+	promotion, err := pc.service.GetPromotion(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(promotion)
+}
+
+// UpdatePromotion handles PUT /api/promotions/{id}
+func (pc *PromotionController) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid promotion ID")
+		return
+	}
+
+	var req models.PromotionRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	updated, err := pc.service.UpdatePromotion(ctx, id, req.Promotion())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeletePromotion handles DELETE /api/promotions/{id}
+func (pc *PromotionController) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid promotion ID")
+		return
+	}
+
+	if err := pc.service.DeletePromotion(ctx, id); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Promotion with ID %d successfully deleted", id),
+	})
+}
+
+// ListPromotions handles GET /api/promotions?active=true
+func (pc *PromotionController) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	active, _ := strconv.ParseBool(r.URL.Query().Get("active"))
+	promotions, err := pc.service.ListPromotions(ctx, active)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promotions)
+}
+
+// PromotionUsage handles GET /api/promotions/usage?from=&to=
+func (pc *PromotionController) PromotionUsage(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	from, to, ok := reportRange(w, r)
+	if !ok {
+		return
+	}
+	usage, err := pc.service.PromotionUsage(ctx, from, to)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
+
+// reportRange reads the from and to dates (YYYY-MM-DD, both included) of a
+// report. They default to the last 30 days.
+func reportRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from, to := today.AddDate(0, 0, -30), today
+
+	if v := r.URL.Query().Get("from"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "invalid 'from' date format (use YYYY-MM-DD)")
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "invalid 'to' date format (use YYYY-MM-DD)")
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+	return from, to.Add(24*time.Hour - time.Second), true
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type PublisherController struct {
+	service *services.PublisherService
+}
+
+func NewPublisherController(s *services.PublisherService) *PublisherController {
+	return &PublisherController{service: s}
+}
+
+func (pc *PublisherController) CreatePublisher(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.PublisherRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	publisher := req.Publisher()
+	created, err := pc.service.CreatePublisher(ctx, publisher)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (pc *PublisherController) GetPublisher(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid publisher ID")
+		return
+	}
+
+	publisher, err := pc.service.GetPublisher(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(publisher)
+}
+
+func (pc *PublisherController) UpdatePublisher(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid publisher ID")
+		return
+	}
+
+	var req models.PublisherRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	publisher := req.Publisher()
+
+	updated, err := pc.service.UpdatePublisher(ctx, id, publisher)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (pc *PublisherController) DeletePublisher(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid publisher ID")
+		return
+	}
+
+	if err := pc.service.DeletePublisher(ctx, id); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Publisher with ID %d successfully deleted", id),
+	})
+}
+
+// ListPublishers handles GET /api/publishers?name=
+func (pc *PublisherController) ListPublishers(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	publishers, err := pc.service.ListPublishers(ctx, r.URL.Query().Get("name"))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(publishers)
+}
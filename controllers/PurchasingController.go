@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type PurchasingController struct {
+	service *services.PurchasingService
+}
+
+func NewPurchasingController(s *services.PurchasingService) *PurchasingController {
+	return &PurchasingController{service: s}
+}
+
+// CreateSupplier handles POST /api/suppliers
+func (pc *PurchasingController) CreateSupplier(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.SupplierRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	created, err := pc.service.CreateSupplier(ctx, req.Supplier())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetSupplier handles GET /api/suppliers/{id}
+func (pc *PurchasingController) GetSupplier(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid supplier ID")
+		return
+	}
+
+	supplier, err := pc.service.GetSupplier(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(supplier)
+}
+
+// UpdateSupplier handles PUT /api/suppliers/{id}
+func (pc *PurchasingController) UpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid supplier ID")
+		return
+	}
+
+	var req models.SupplierRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	updated, err := pc.service.UpdateSupplier(ctx, id, req.Supplier())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteSupplier handles DELETE /api/suppliers/{id}
+func (pc *PurchasingController) DeleteSupplier(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid supplier ID")
+		return
+	}
+
+	if err := pc.service.DeleteSupplier(ctx, id); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Supplier with ID %d successfully deleted", id),
+	})
+}
+
+// ListSuppliers handles GET /api/suppliers?name=
+func (pc *PurchasingController) ListSuppliers(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	suppliers, err := pc.service.ListSuppliers(ctx, r.URL.Query().Get("name"))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suppliers)
+}
+
+// CreatePurchaseOrder handles POST /api/purchase-orders
+func (pc *PurchasingController) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.PurchaseOrderRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	created, err := pc.service.CreatePurchaseOrder(ctx, req.PurchaseOrder())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetPurchaseOrder handles GET /api/purchase-orders/{id}
+func (pc *PurchasingController) GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid purchase order ID")
+		return
+	}
+
+	po, err := pc.service.GetPurchaseOrder(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(po)
+}
+
+// UpdatePurchaseOrder handles PUT /api/purchase-orders/{id}
+func (pc *PurchasingController) UpdatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid purchase order ID")
+		return
+	}
+
+	var req models.PurchaseOrderRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	updated, err := pc.service.UpdatePurchaseOrder(ctx, id, req.PurchaseOrder())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeletePurchaseOrder handles DELETE /api/purchase-orders/{id}
+func (pc *PurchasingController) DeletePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid purchase order ID")
+		return
+	}
+
+	if err := pc.service.DeletePurchaseOrder(ctx, id); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Purchase order with ID %d successfully deleted", id),
+	})
+}
+
+// ListPurchaseOrders handles GET /api/purchase-orders?status=&supplier_id=
+func (pc *PurchasingController) ListPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	query := r.URL.Query()
+	supplierID := 0
+	if v := query.Get("supplier_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			WriteJSONError(w, http.StatusBadRequest, "Invalid 'supplier_id'")
+			return
+		}
+		supplierID = id
+	}
+
+	pos, err := pc.service.ListPurchaseOrders(ctx, query.Get("status"), supplierID)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pos)
+}
+
+// SendPurchaseOrder handles POST /api/purchase-orders/{id}/send
+func (pc *PurchasingController) SendPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid purchase order ID")
+		return
+	}
+
+	po, err := pc.service.SendPurchaseOrder(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(po)
+}
+
+// ReceiveGoods handles POST /api/purchase-orders/{id}/receipts
+func (pc *PurchasingController) ReceiveGoods(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid purchase order ID")
+		return
+	}
+
+	var req models.GoodsReceiptRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	po, err := pc.service.ReceiveGoods(ctx, id, req.Receipt())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(po)
+}
+
+// ListReceipts handles GET /api/purchase-orders/{id}/receipts
+func (pc *PurchasingController) ListReceipts(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid purchase order ID")
+		return
+	}
+
+	receipts, err := pc.service.ListReceipts(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(receipts)
+}
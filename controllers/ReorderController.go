@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type ReorderController struct {
+	service *services.ReorderService
+}
+
+func NewReorderController(s *services.ReorderService) *ReorderController {
+	return &ReorderController{service: s}
+}
+
+// LowStock handles GET /api/stock/low
+func (rc *ReorderController) LowStock(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	alerts, err := rc.service.LowStock(ctx)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alerts)
+}
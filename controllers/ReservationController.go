@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type ReservationController struct {
+	service *services.ReservationService
+}
+
+func NewReservationController(s *services.ReservationService) *ReservationController {
+	return &ReservationController{service: s}
+}
+
+// ownerFilter is the user whose reservations a request may see: the
+// authenticated customer, or 0 (everyone) for admins
+func ownerFilter(r *http.Request) (int, bool) {
+	if r.Header.Get("X-User-Role") == "admin" {
+		return 0, true
+	}
+	userID, err := strconv.Atoi(r.Header.Get("X-User-ID"))
+	return userID, err == nil
+}
+
+// CreateReservation handles POST /api/reservations
+func (rc *ReservationController) CreateReservation(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.ReservationRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	reservation := req.Reservation()
+
+	owner, ok := ownerFilter(r)
+	if !ok {
+		WriteJSONError(w, http.StatusUnauthorized, "Invalid authentication")
+		return
+	}
+	if owner > 0 {
+		if reservation.UserID > 0 && reservation.UserID != owner {
+			WriteJSONError(w, http.StatusForbidden, "Customers can only reserve for themselves")
+			return
+		}
+		reservation.UserID = owner
+	} else if reservation.UserID == 0 {
+		WriteJSONError(w, http.StatusBadRequest, "Admin must provide a valid user_id")
+		return
+	}
+
+	created, err := rc.service.Reserve(ctx, reservation)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListReservations handles GET /api/reservations
+func (rc *ReservationController) ListReservations(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	owner, ok := ownerFilter(r)
+	if !ok {
+		WriteJSONError(w, http.StatusUnauthorized, "Invalid authentication")
+		return
+	}
+
+	reservations, err := rc.service.ListReservations(ctx, owner)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservations)
+}
+
+// GetReservation handles GET /api/reservations/{id}
+func (rc *ReservationController) GetReservation(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid reservation ID")
+		return
+	}
+	owner, ok := ownerFilter(r)
+	if !ok {
+		WriteJSONError(w, http.StatusUnauthorized, "Invalid authentication")
+		return
+	}
+
+	reservation, err := rc.service.GetReservation(ctx, id, owner)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// DeleteReservation handles DELETE /api/reservations/{id}
+func (rc *ReservationController) DeleteReservation(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid reservation ID")
+		return
+	}
+	owner, ok := ownerFilter(r)
+	if !ok {
+		WriteJSONError(w, http.StatusUnauthorized, "Invalid authentication")
+		return
+	}
+
+	if err := rc.service.Release(ctx, id, owner); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Reservation with ID %d released", id),
+	})
+}
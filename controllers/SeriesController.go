@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type SeriesController struct {
+	service *services.SeriesService
+}
+
+func NewSeriesController(s *services.SeriesService) *SeriesController {
+	return &SeriesController{service: s}
+}
+
+func (sc *SeriesController) CreateSeries(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.SeriesRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	series := req.Series()
+	created, err := sc.service.CreateSeries(ctx, series)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (sc *SeriesController) GetSeries(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid series ID")
+		return
+	}
+
+	series, err := sc.service.GetSeries(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(series)
+}
+
+func (sc *SeriesController) UpdateSeries(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid series ID")
+		return
+	}
+
+	var req models.SeriesRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	series := req.Series()
+
+	updated, err := sc.service.UpdateSeries(ctx, id, series)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (sc *SeriesController) DeleteSeries(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid series ID")
+		return
+	}
+
+	if err := sc.service.DeleteSeries(ctx, id); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Series with ID %d successfully deleted", id),
+	})
+}
+
+// ListSeries handles GET /api/series?name=
+func (sc *SeriesController) ListSeries(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	series, err := sc.service.ListSeries(ctx, r.URL.Query().Get("name"))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+// ListSeriesBooks handles GET /api/series/{id}/books, in reading order
+func (sc *SeriesController) ListSeriesBooks(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid series ID")
+		return
+	}
+
+	books, err := sc.service.ListSeriesBooks(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(books)
+}
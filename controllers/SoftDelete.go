@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// includeDeleted reads the include_deleted query parameter. Only admins may
+// see deleted records; on a bad value or a non-admin caller it writes the
+// error response and returns ok == false.
+func includeDeleted(w http.ResponseWriter, r *http.Request) (include bool, ok bool) {
+	v := r.URL.Query().Get("include_deleted")
+	if v == "" {
+		return false, true
+	}
+	include, err := strconv.ParseBool(v)
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid 'include_deleted', expected true or false")
+		return false, false
+	}
+	if include && r.Header.Get("X-User-Role") != "admin" {
+		WriteJSONError(w, http.StatusForbidden, "Only admins can list deleted records")
+		return false, false
+	}
+	return include, true
+}
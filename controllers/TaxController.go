@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"FinalProject/models"
+	"FinalProject/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type TaxController struct {
+	service *services.TaxService
+}
+
+func NewTaxController(s *services.TaxService) *TaxController {
+	return &TaxController{service: s}
+}
+
+// CreateTaxRate handles POST /api/tax-rates
+func (tc *TaxController) CreateTaxRate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	var req models.TaxRateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	created, err := tc.service.CreateTaxRate(ctx, req.TaxRate())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetTaxRate handles GET /api/tax-rates/{id}
+func (tc *TaxController) GetTaxRate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid tax rate ID")
+		return
+	}
+
+	rate, err := tc.service.GetTaxRate(ctx, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(rate)
+}
+
+// UpdateTaxRate handles PUT /api/tax-rates/{id}
+func (tc *TaxController) UpdateTaxRate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid tax rate ID")
+		return
+	}
+
+	var req models.TaxRateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	updated, err := tc.service.UpdateTaxRate(ctx, id, req.TaxRate())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteTaxRate handles DELETE /api/tax-rates/{id}
+func (tc *TaxController) DeleteTaxRate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid tax rate ID")
+		return
+	}
+
+	if err := tc.service.DeleteTaxRate(ctx, id); err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": fmt.Sprintf("Tax rate with ID %d successfully deleted", id),
+	})
+}
+
+// ListTaxRates handles GET /api/tax-rates, optionally ?country=
+func (tc *TaxController) ListTaxRates(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	rates, err := tc.service.ListTaxRates(ctx, r.URL.Query().Get("country"))
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rates)
+}
@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"FinalProject/validation"
+	"encoding/json"
+	"net/http"
+)
+
+// decodeRequest decodes a JSON request body into dst, a request type from
+// models, and checks its validation rules. On failure the error response has
+// been written and ok is false.
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst any) (ok bool) {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		WriteJSONError(w, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return false
+	}
+	if err := validation.Struct(dst); err != nil {
+		WriteError(w, err)
+		return false
+	}
+	return true
+}
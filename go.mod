@@ -3,16 +3,15 @@ module FinalProject
 go 1.23.4
 
 require (
+	github.com/golang-jwt/jwt/v5 v5.2.1
+	github.com/gorilla/mux v1.8.1
+	github.com/joho/godotenv v1.5.1
 	github.com/uptrace/bun v1.2.9
 	github.com/uptrace/bun/driver/pgdriver v1.2.9
+	golang.org/x/image v0.24.0
 )
 
-require (
-	github.com/golang-jwt/jwt/v4 v4.5.1 // indirect
-	github.com/golang-jwt/jwt/v5 v5.2.1 // indirect
-	github.com/gorilla/mux v1.8.1 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
-)
+require github.com/golang-jwt/jwt/v4 v4.5.1 // indirect
 
 require (
 	github.com/jinzhu/inflection v1.0.0 // indirect
@@ -23,7 +22,7 @@ require (
 	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	go.opentelemetry.io/otel v1.34.0 // indirect
 	go.opentelemetry.io/otel/trace v1.34.0 // indirect
-	golang.org/x/crypto v0.35.0 // indirect
+	golang.org/x/crypto v0.35.0
 	golang.org/x/sys v0.30.0 // indirect
 	mellium.im/sasl v0.3.2 // indirect
 )
@@ -3,6 +3,7 @@ package main
 import (
 	"FinalProject/controllers"
 	"FinalProject/middleware"
+	"FinalProject/models"
 	"FinalProject/repositories"
 	"FinalProject/services"
 	"FinalProject/task"
@@ -21,6 +22,9 @@ func main() {
 		log.Fatal("Error loading .env file")
 	}
 
+	// Prices and orders are in the store currency
+	models.DefaultCurrency = services.CurrencyFromEnv()
+
 	// Initialize database connection
 	repositories.InitDB()
 	defer repositories.CloseDB()
@@ -32,28 +36,86 @@ func main() {
 	orderRepo := repositories.NewOrderRepository(repositories.DB)
 	reportRepo := repositories.NewReportStore(repositories.DB)
 	userRepo := repositories.NewUserRepository(repositories.DB)
+	bookImportRepo := repositories.NewBookImportRepository(repositories.DB)
+	exportRepo := repositories.NewExportRepository(repositories.DB)
+	publisherRepo := repositories.NewPublisherRepository(repositories.DB)
+	seriesRepo := repositories.NewSeriesRepository(repositories.DB)
+	editionRepo := repositories.NewEditionRepository(repositories.DB)
+	genreRepo := repositories.NewGenreRepository(repositories.DB)
+	blobStore := repositories.NewBlobStoreFromEnv()
+	auditRepo := repositories.NewAuditRepository(repositories.DB)
+	inventoryRepo := repositories.NewInventoryRepository(repositories.DB)
+	purchasingRepo := repositories.NewPurchasingRepository(repositories.DB)
+	reservationRepo := repositories.NewReservationRepository(repositories.DB)
+	promotionRepo := repositories.NewPromotionRepository(repositories.DB)
+	couponRepo := repositories.NewCouponRepository(repositories.DB)
+	pricingRepo := repositories.NewPricingRepository(repositories.DB)
+	taxRepo := repositories.NewTaxRepository(repositories.DB)
 
 	// Initialize services
-	authorService := services.NewAuthorService(authorRepo)
-	bookService := services.NewBookService(bookRepo, authorRepo)
-	customerService := services.NewCustomerService(customerRepo)
-	orderService := services.NewOrderService(orderRepo, bookRepo, customerRepo)
+	auditService := services.NewAuditService(auditRepo)
+	inventoryService := services.NewInventoryService(inventoryRepo, bookRepo, services.FulfillmentStrategyFromEnv(), auditService)
+	authorService := services.NewAuthorService(authorRepo, auditService)
+	bookService := services.NewBookService(bookRepo, authorRepo, publisherRepo, seriesRepo, genreRepo, inventoryService, auditService)
+	customerService := services.NewCustomerService(customerRepo, auditService)
+	reservationService := services.NewReservationService(reservationRepo, customerRepo,
+		services.DurationFromEnv("RESERVATION_TTL", services.DefaultReservationTTL))
+	promotionService := services.NewPromotionService(promotionRepo, bookRepo, authorRepo, genreRepo, auditService)
+	couponService := services.NewCouponService(couponRepo, auditService)
+	pricingService := services.NewPricingService(pricingRepo, bookRepo, customerRepo, auditService)
+	taxService := services.NewTaxService(taxRepo, services.TaxModeFromEnv(), auditService)
+	orderService := services.NewOrderService(orderRepo, bookRepo, customerRepo, editionRepo, inventoryService, reservationService, promotionService, couponService, pricingService, taxService, auditService)
 	reportService := services.NewReportService(orderRepo, reportRepo)
 	authService := services.NewAuthService(userRepo)
+	bookImportService := services.NewBookImportService(bookImportRepo, auditService)
+	exportService := services.NewExportService(exportRepo, "output-exports",
+		services.DurationFromEnv("EXPORT_RETENTION", services.DefaultExportRetention))
+	publisherService := services.NewPublisherService(publisherRepo, auditService)
+	seriesService := services.NewSeriesService(seriesRepo, publisherRepo, auditService)
+	editionService := services.NewEditionService(editionRepo, bookRepo, inventoryService, auditService)
+	genreService := services.NewGenreService(genreRepo, auditService)
+	coverService := services.NewCoverService(bookRepo, blobStore, auditService)
+	purgeService := services.NewPurgeService(bookRepo, authorRepo, customerRepo, services.RetentionFromEnv())
+	purchasingService := services.NewPurchasingService(purchasingRepo, bookRepo, inventoryService, auditService)
+	reorderService := services.NewReorderService(inventoryRepo, services.NotifierFromEnv(),
+		services.DaysFromEnv("REORDER_SALES_WINDOW_DAYS", services.DefaultSalesWindowDays),
+		services.DaysFromEnv("REORDER_COVER_DAYS", services.DefaultReorderCoverDays))
 
 	// Initialize controllers
 	authorController := controllers.NewAuthorController(authorService)
-	bookController := controllers.NewBookController(bookService)
+	bookController := controllers.NewBookController(bookService, pricingService)
 	customerController := controllers.NewCustomerController(customerService)
 	orderController := controllers.NewOrderController(orderService)
 	reportController := controllers.NewReportController(reportService)
 	authController := controllers.NewAuthController(authService)
+	bookImportController := controllers.NewBookImportController(bookImportService)
+	exportController := controllers.NewExportController(exportService)
+	publisherController := controllers.NewPublisherController(publisherService)
+	seriesController := controllers.NewSeriesController(seriesService)
+	editionController := controllers.NewEditionController(editionService)
+	genreController := controllers.NewGenreController(genreService)
+	bookCoverController := controllers.NewBookCoverController(coverService)
+	auditController := controllers.NewAuditController(auditService)
+	inventoryController := controllers.NewInventoryController(inventoryService)
+	reorderController := controllers.NewReorderController(reorderService)
+	purchasingController := controllers.NewPurchasingController(purchasingService)
+	reservationController := controllers.NewReservationController(reservationService)
+	promotionController := controllers.NewPromotionController(promotionService)
+	couponController := controllers.NewCouponController(couponService)
+	pricingController := controllers.NewPricingController(pricingService)
+	taxController := controllers.NewTaxController(taxService)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(authService, orderService)
 
 	// Start background tasks
 	task.StartDailyReportJob(reportService)
+	task.StartLowStockJob(reorderService)
+	task.StartPurgeJob(purgeService)
+	task.StartStockReconcileJob(inventoryService)
+	task.StartBackorderJob(orderService)
+	task.StartReservationSweeper(reservationService)
+	task.StartExportSweeper(exportService)
 
 	// Setup router
 	router := mux.NewRouter()
@@ -62,6 +124,11 @@ func main() {
 	router.HandleFunc("/register", authController.Register).Methods("POST")
 	router.HandleFunc("/login", authController.Login).Methods("POST")
 
+	// Files of the local blob store (cover images) are public
+	if local, ok := blobStore.(*repositories.LocalBlobStore); ok {
+		router.PathPrefix(local.ServePath()).Handler(local).Methods("GET", "HEAD")
+	}
+
 	// Protected API routes (JWT required)
 	api := router.PathPrefix("/api").Subrouter()
 	api.Use(authMiddleware.JWTAuthMiddleware)
@@ -69,32 +136,160 @@ func main() {
 	// 📚 Book routes
 	api.HandleFunc("/books", bookController.CreateBook).Methods("POST")
 	api.HandleFunc("/books", bookController.SearchBooks).Methods("GET")
+	api.HandleFunc("/books/import", bookImportController.ImportBooks).Methods("POST")
+	api.HandleFunc("/books/import/onix", bookImportController.ImportONIX).Methods("POST")
 	api.HandleFunc("/books/{id:[0-9]+}", bookController.GetBook).Methods("GET")
 	api.HandleFunc("/books/{id}", bookController.UpdateBook).Methods("PUT")
+	api.HandleFunc("/books/{id:[0-9]+}", bookController.PatchBook).Methods("PATCH")
 	api.HandleFunc("/books/{id}", bookController.DeleteBook).Methods("DELETE")
+	api.HandleFunc("/books/{id:[0-9]+}/restore", bookController.RestoreBook).Methods("POST")
+	api.HandleFunc("/books/{id:[0-9]+}/editions", editionController.ListBookEditions).Methods("GET")
+	api.HandleFunc("/books/{id:[0-9]+}/editions", editionController.CreateEdition).Methods("POST")
+	api.HandleFunc("/books/{id:[0-9]+}/cover", bookCoverController.UploadCover).Methods("POST")
+	api.HandleFunc("/books/{id:[0-9]+}/cover", bookCoverController.DeleteCover).Methods("DELETE")
+	api.HandleFunc("/books/{id:[0-9]+}/prices", pricingController.BookPrices).Methods("GET")
+
+	// 💿 Edition routes
+	api.HandleFunc("/editions", editionController.FindEdition).Methods("GET")
+	api.HandleFunc("/editions/{id:[0-9]+}", editionController.GetEdition).Methods("GET")
+	api.HandleFunc("/editions/{id:[0-9]+}", editionController.UpdateEdition).Methods("PUT")
+	api.HandleFunc("/editions/{id:[0-9]+}", editionController.DeleteEdition).Methods("DELETE")
+
+	// 🏬 Inventory routes
+	api.HandleFunc("/books/{id:[0-9]+}/stock", inventoryController.BookStock).Methods("GET")
+	api.HandleFunc("/books/{id:[0-9]+}/stock-history", inventoryController.StockHistory).Methods("GET")
+	api.HandleFunc("/books/{id:[0-9]+}/stock-movements", inventoryController.RecordMovement).Methods("POST")
+	api.HandleFunc("/stock/drift", inventoryController.StockDrift).Methods("GET")
+	api.HandleFunc("/stock/low", reorderController.LowStock).Methods("GET")
+	api.HandleFunc("/locations", inventoryController.CreateLocation).Methods("POST")
+	api.HandleFunc("/locations", inventoryController.ListLocations).Methods("GET")
+	api.HandleFunc("/locations/{id:[0-9]+}", inventoryController.GetLocation).Methods("GET")
+	api.HandleFunc("/locations/{id:[0-9]+}", inventoryController.UpdateLocation).Methods("PUT")
+	api.HandleFunc("/locations/{id:[0-9]+}", inventoryController.DeleteLocation).Methods("DELETE")
+	api.HandleFunc("/stock-transfers", inventoryController.TransferStock).Methods("POST")
+	api.HandleFunc("/stock-transfers", inventoryController.ListTransfers).Methods("GET")
+
+	// 🚚 Purchasing routes
+	api.HandleFunc("/suppliers", purchasingController.CreateSupplier).Methods("POST")
+	api.HandleFunc("/suppliers", purchasingController.ListSuppliers).Methods("GET")
+	api.HandleFunc("/suppliers/{id:[0-9]+}", purchasingController.GetSupplier).Methods("GET")
+	api.HandleFunc("/suppliers/{id:[0-9]+}", purchasingController.UpdateSupplier).Methods("PUT")
+	api.HandleFunc("/suppliers/{id:[0-9]+}", purchasingController.DeleteSupplier).Methods("DELETE")
+	api.HandleFunc("/purchase-orders", purchasingController.CreatePurchaseOrder).Methods("POST")
+	api.HandleFunc("/purchase-orders", purchasingController.ListPurchaseOrders).Methods("GET")
+	api.HandleFunc("/purchase-orders/{id:[0-9]+}", purchasingController.GetPurchaseOrder).Methods("GET")
+	api.HandleFunc("/purchase-orders/{id:[0-9]+}", purchasingController.UpdatePurchaseOrder).Methods("PUT")
+	api.HandleFunc("/purchase-orders/{id:[0-9]+}", purchasingController.DeletePurchaseOrder).Methods("DELETE")
+	api.HandleFunc("/purchase-orders/{id:[0-9]+}/send", purchasingController.SendPurchaseOrder).Methods("POST")
+	api.HandleFunc("/purchase-orders/{id:[0-9]+}/receipts", purchasingController.ReceiveGoods).Methods("POST")
+	api.HandleFunc("/purchase-orders/{id:[0-9]+}/receipts", purchasingController.ListReceipts).Methods("GET")
+
+	// 🏢 Publisher routes
+	api.HandleFunc("/publishers", publisherController.CreatePublisher).Methods("POST")
+	api.HandleFunc("/publishers", publisherController.ListPublishers).Methods("GET")
+	api.HandleFunc("/publishers/{id:[0-9]+}", publisherController.GetPublisher).Methods("GET")
+	api.HandleFunc("/publishers/{id:[0-9]+}", publisherController.UpdatePublisher).Methods("PUT")
+	api.HandleFunc("/publishers/{id:[0-9]+}", publisherController.DeletePublisher).Methods("DELETE")
+
+	// 📖 Series routes
+	api.HandleFunc("/series", seriesController.CreateSeries).Methods("POST")
+	api.HandleFunc("/series", seriesController.ListSeries).Methods("GET")
+	api.HandleFunc("/series/{id:[0-9]+}", seriesController.GetSeries).Methods("GET")
+	api.HandleFunc("/series/{id:[0-9]+}", seriesController.UpdateSeries).Methods("PUT")
+	api.HandleFunc("/series/{id:[0-9]+}", seriesController.DeleteSeries).Methods("DELETE")
+	api.HandleFunc("/series/{id:[0-9]+}/books", seriesController.ListSeriesBooks).Methods("GET")
 
 	// ✍️ Author routes
 	api.HandleFunc("/authors", authorController.CreateAuthor).Methods("POST")
 	api.HandleFunc("/authors", authorController.SearchAuthors).Methods("GET")
+	api.HandleFunc("/authors/duplicates", authorController.FindDuplicateAuthors).Methods("GET")
+	api.HandleFunc("/authors/{id:[0-9]+}/merge", authorController.MergeAuthor).Methods("POST")
+	api.HandleFunc("/authors/{id:[0-9]+}/restore", authorController.RestoreAuthor).Methods("POST")
 	api.HandleFunc("/authors/{id}", authorController.GetAuthor).Methods("GET")
 	api.HandleFunc("/authors/{id}", authorController.UpdateAuthor).Methods("PUT")
+	api.HandleFunc("/authors/{id:[0-9]+}", authorController.PatchAuthor).Methods("PATCH")
 	api.HandleFunc("/authors/{id}", authorController.DeleteAuthor).Methods("DELETE")
 
+	// 🏷️ Genre routes
+	api.HandleFunc("/genres", genreController.CreateGenre).Methods("POST")
+	api.HandleFunc("/genres", genreController.ListGenres).Methods("GET")
+	api.HandleFunc("/genres/{id:[0-9]+}", genreController.GetGenre).Methods("GET")
+	api.HandleFunc("/genres/{id:[0-9]+}", genreController.UpdateGenre).Methods("PUT")
+	api.HandleFunc("/genres/{id:[0-9]+}", genreController.DeleteGenre).Methods("DELETE")
+	api.HandleFunc("/genres/{id:[0-9]+}/merge", genreController.MergeGenre).Methods("POST")
+
 	// 👥 Customer routes
 	api.HandleFunc("/customers", customerController.ListCustomers).Methods("GET")
 	api.HandleFunc("/customers/{id}", customerController.GetCustomer).Methods("GET")
 	api.HandleFunc("/customers/{id}", customerController.UpdateCustomer).Methods("PUT")
+	api.HandleFunc("/customers/{id:[0-9]+}", customerController.PatchCustomer).Methods("PATCH")
 	api.HandleFunc("/customers/{id}", customerController.DeleteCustomer).Methods("DELETE")
+	api.HandleFunc("/customers/{id:[0-9]+}/restore", customerController.RestoreCustomer).Methods("POST")
 
 	// 📦 Order routes
 	api.HandleFunc("/orders", orderController.CreateOrder).Methods("POST")
 	api.HandleFunc("/orders", orderController.ListOrders).Methods("GET")
 	api.HandleFunc("/orders/{id}", orderController.GetOrder).Methods("GET")
 	api.HandleFunc("/orders/{id}", orderController.UpdateOrder).Methods("PUT")
+	api.HandleFunc("/orders/{id:[0-9]+}", orderController.PatchOrder).Methods("PATCH")
 	api.HandleFunc("/orders/{id}", orderController.DeleteOrder).Methods("DELETE")
 	api.HandleFunc("/orders/date-range", orderController.GetOrdersByDateRange).Methods("GET")
 	api.HandleFunc("/orders/search-by-customer", orderController.SearchOrdersByCustomerID).Methods("GET")
 
+	// 🏷️ Promotion routes
+	api.HandleFunc("/promotions", promotionController.CreatePromotion).Methods("POST")
+	api.HandleFunc("/promotions", promotionController.ListPromotions).Methods("GET")
+	api.HandleFunc("/promotions/usage", promotionController.PromotionUsage).Methods("GET")
+	api.HandleFunc("/promotions/{id:[0-9]+}", promotionController.GetPromotion).Methods("GET")
+	api.HandleFunc("/promotions/{id:[0-9]+}", promotionController.UpdatePromotion).Methods("PUT")
+	api.HandleFunc("/promotions/{id:[0-9]+}", promotionController.DeletePromotion).Methods("DELETE")
+
+	// 🎟️ Coupon routes
+	api.HandleFunc("/coupons", couponController.CreateCoupon).Methods("POST")
+	api.HandleFunc("/coupons", couponController.ListCoupons).Methods("GET")
+	api.HandleFunc("/coupons/usage", couponController.CouponUsage).Methods("GET")
+	api.HandleFunc("/coupons/{id:[0-9]+}", couponController.GetCoupon).Methods("GET")
+	api.HandleFunc("/coupons/{id:[0-9]+}", couponController.UpdateCoupon).Methods("PUT")
+	api.HandleFunc("/coupons/{id:[0-9]+}", couponController.DeleteCoupon).Methods("DELETE")
+	api.HandleFunc("/coupons/{id:[0-9]+}/redemptions", couponController.ListRedemptions).Methods("GET")
+
+	// 💱 Exchange rate and price list routes
+	api.HandleFunc("/exchange-rates", pricingController.ListExchangeRates).Methods("GET")
+	api.HandleFunc("/exchange-rates/import", pricingController.ImportExchangeRates).Methods("POST")
+	api.HandleFunc("/exchange-rates/{currency:[A-Za-z]{3}}", pricingController.SetExchangeRate).Methods("PUT")
+	api.HandleFunc("/exchange-rates/{currency:[A-Za-z]{3}}", pricingController.DeleteExchangeRate).Methods("DELETE")
+	api.HandleFunc("/price-lists", pricingController.CreatePriceList).Methods("POST")
+	api.HandleFunc("/price-lists", pricingController.ListPriceLists).Methods("GET")
+	api.HandleFunc("/price-lists/{id:[0-9]+}", pricingController.GetPriceList).Methods("GET")
+	api.HandleFunc("/price-lists/{id:[0-9]+}", pricingController.UpdatePriceList).Methods("PUT")
+	api.HandleFunc("/price-lists/{id:[0-9]+}", pricingController.DeletePriceList).Methods("DELETE")
+	api.HandleFunc("/price-lists/{id:[0-9]+}/prices", pricingController.ListBookPrices).Methods("GET")
+	api.HandleFunc("/price-lists/{id:[0-9]+}/prices/{book_id:[0-9]+}", pricingController.SetBookPrice).Methods("PUT")
+	api.HandleFunc("/price-lists/{id:[0-9]+}/prices/{book_id:[0-9]+}", pricingController.DeleteBookPrice).Methods("DELETE")
+
+	// 🧾 Tax rate routes
+	api.HandleFunc("/tax-rates", taxController.CreateTaxRate).Methods("POST")
+	api.HandleFunc("/tax-rates", taxController.ListTaxRates).Methods("GET")
+	api.HandleFunc("/tax-rates/{id:[0-9]+}", taxController.GetTaxRate).Methods("GET")
+	api.HandleFunc("/tax-rates/{id:[0-9]+}", taxController.UpdateTaxRate).Methods("PUT")
+	api.HandleFunc("/tax-rates/{id:[0-9]+}", taxController.DeleteTaxRate).Methods("DELETE")
+
+	// 🛒 Reservation routes
+	api.HandleFunc("/reservations", reservationController.CreateReservation).Methods("POST")
+	api.HandleFunc("/reservations", reservationController.ListReservations).Methods("GET")
+	api.HandleFunc("/reservations/{id:[0-9]+}", reservationController.GetReservation).Methods("GET")
+	api.HandleFunc("/reservations/{id:[0-9]+}", reservationController.DeleteReservation).Methods("DELETE")
+
+	// 📤 Export routes
+	api.HandleFunc("/export/jobs", exportController.ListExportJobs).Methods("GET")
+	api.HandleFunc("/export/jobs/{id}", exportController.GetExportJob).Methods("GET")
+	api.HandleFunc("/export/jobs/{id}/download", exportController.DownloadExportJob).Methods("GET")
+	api.HandleFunc("/export/{entity:books|authors|customers|orders}", exportController.Export).Methods("GET")
+	api.HandleFunc("/export/{entity:books|authors|customers|orders}/jobs", exportController.StartExportJob).Methods("POST")
+
+	// 🕵️ Audit routes
+	api.HandleFunc("/audit", auditController.ListAudit).Methods("GET")
+
 	// 📊 Report routes
 	api.HandleFunc("/report", reportController.ListReports).Methods("GET")
 
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"FinalProject/controllers"
 	"FinalProject/services"
 	"context"
 	"log"
@@ -74,26 +75,26 @@ func (m *AuthMiddleware) JWTAuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		clientIP := r.RemoteAddr
 		if !m.rateLimiter.Allow(clientIP) {
-			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			controllers.WriteJSONError(w, http.StatusTooManyRequests, "Rate limit exceeded")
 			return
 		}
 
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Missing authorization token", http.StatusUnauthorized)
+			controllers.WriteJSONError(w, http.StatusUnauthorized, "Missing authorization token")
 			return
 		}
 
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
+			controllers.WriteJSONError(w, http.StatusUnauthorized, "Invalid authorization format")
 			return
 		}
 
 		tokenString := tokenParts[1]
 		claims, err := m.AuthService.ValidateToken(tokenString)
 		if err != nil {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			controllers.WriteJSONError(w, http.StatusUnauthorized, "Invalid or expired token")
 			return
 		}
 
@@ -107,11 +108,12 @@ func (m *AuthMiddleware) JWTAuthMiddleware(next http.Handler) http.Handler {
 
 		// Check role-based access
 		if !m.checkRoleAccess(r, userID, role) {
-			http.Error(w, "Insufficient permissions", http.StatusForbidden)
+			controllers.WriteJSONError(w, http.StatusForbidden, "Insufficient permissions")
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		// Services read the acting user from the context for the audit log
+		next.ServeHTTP(w, r.WithContext(services.WithActor(r.Context(), userID)))
 	})
 }
 
@@ -120,6 +122,16 @@ func (m *AuthMiddleware) checkRoleAccess(r *http.Request, userID int, role strin
 	path := r.URL.Path
 	method := r.Method
 
+	// Duplicate detection is an admin tool
+	if strings.HasPrefix(path, "/api/authors/duplicates") {
+		return role == "admin"
+	}
+
+	// The stock ledger names who moved stock and why
+	if strings.HasPrefix(path, "/api/books/") && strings.HasSuffix(path, "/stock-history") {
+		return role == "admin"
+	}
+
 	// Public access (both customers and admins)
 	if (strings.HasPrefix(path, "/api/books") && method == http.MethodGet) ||
 		(strings.HasPrefix(path, "/api/authors") && method == http.MethodGet) {
@@ -128,10 +140,17 @@ func (m *AuthMiddleware) checkRoleAccess(r *http.Request, userID int, role strin
 
 	// Admin-only actions
 	if (strings.HasPrefix(path, "/api/books") || strings.HasPrefix(path, "/api/authors")) &&
-		(method == http.MethodPost || method == http.MethodPut || method == http.MethodDelete) {
+		(method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete) {
 		return role == "admin"
 	}
 
+	// Publishers, series, editions and genres are catalog data: readable by
+	// everyone, managed by admins
+	if strings.HasPrefix(path, "/api/publishers") || strings.HasPrefix(path, "/api/series") ||
+		strings.HasPrefix(path, "/api/editions") || strings.HasPrefix(path, "/api/genres") {
+		return method == http.MethodGet || role == "admin"
+	}
+
 	if strings.HasPrefix(path, "/api/customers") && !strings.HasPrefix(path, "/api/customers/"){
 		return role == "admin"
 	}
@@ -156,6 +175,49 @@ func (m *AuthMiddleware) checkRoleAccess(r *http.Request, userID int, role strin
 		return userID == customerID
 	}
 
+	// Locations are readable by everyone, managed by admins; stock transfers
+	// and the drift check are admin tools
+	if strings.HasPrefix(path, "/api/locations") {
+		return method == http.MethodGet || role == "admin"
+	}
+	if strings.HasPrefix(path, "/api/stock-transfers") || strings.HasPrefix(path, "/api/stock/") {
+		return role == "admin"
+	}
+
+	// Suppliers, purchase orders and their costs are for staff only
+	if strings.HasPrefix(path, "/api/suppliers") || strings.HasPrefix(path, "/api/purchase-orders") {
+		return role == "admin"
+	}
+
+	// Promotions, including those not yet announced, and coupon codes are
+	// managed by admins; customers only give a code with an order
+	if strings.HasPrefix(path, "/api/promotions") || strings.HasPrefix(path, "/api/coupons") {
+		return role == "admin"
+	}
+
+	// Exchange rates, price lists and tax rates can be read by everyone, set
+	// by admins
+	if strings.HasPrefix(path, "/api/exchange-rates") || strings.HasPrefix(path, "/api/price-lists") ||
+		strings.HasPrefix(path, "/api/tax-rates") {
+		return method == http.MethodGet || role == "admin"
+	}
+
+	// Exports expose every customer and order, so they are admin-only
+	if strings.HasPrefix(path, "/api/export") {
+		return role == "admin"
+	}
+
+	// The audit log shows every change by every user
+	if strings.HasPrefix(path, "/api/audit") {
+		return role == "admin"
+	}
+
+	// Customers hold copies for their own checkout; the reservation
+	// endpoints only show them their own reservations
+	if strings.HasPrefix(path, "/api/reservations") {
+		return true
+	}
+
 	// ✅ Fix: Allow customers to access only their own orders
 	if strings.HasPrefix(path, "/api/orders") {
 		if role == "admin" {
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Audited entity types
+const (
+	AuditBook          = "book"
+	AuditAuthor        = "author"
+	AuditCustomer      = "customer"
+	AuditOrder         = "order"
+	AuditPublisher     = "publisher"
+	AuditSeries        = "series"
+	AuditEdition       = "edition"
+	AuditGenre         = "genre"
+	AuditLocation      = "location"
+	AuditSupplier      = "supplier"
+	AuditPurchaseOrder = "purchase_order"
+	AuditPromotion     = "promotion"
+	AuditCoupon        = "coupon"
+	AuditPriceList     = "price_list"
+	AuditTaxRate       = "tax_rate"
+)
+
+// AuditEntities lists every entity type that can be queried in the audit log
+var AuditEntities = []string{AuditBook, AuditAuthor, AuditCustomer, AuditOrder, AuditPublisher, AuditSeries, AuditEdition, AuditGenre, AuditLocation, AuditSupplier, AuditPurchaseOrder, AuditPromotion, AuditCoupon, AuditPriceList, AuditTaxRate}
+
+// Audit actions
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditMerge   = "merge"
+)
+
+// AuditEntry records one mutation of an entity: who made it, when, and the
+// before/after value of every field that changed
+type AuditEntry struct {
+	bun.BaseModel `bun:"table:audit_log"`
+	ID            int64                  `bun:",pk,autoincrement"`
+	Entity        string                 `bun:",notnull"`
+	EntityID      int                    `bun:",notnull"`
+	Action        string                 `bun:",notnull"`
+	ActorID       int                    `bun:",nullzero"` // 0 for background jobs and command-line tools
+	Changes       map[string]FieldChange `bun:"type:jsonb,notnull"`
+	CreatedAt     time.Time              `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// FieldChange holds the JSON value of a field before and after a mutation;
+// Before is null on create and After is null on delete
+type FieldChange struct {
+	Before json.RawMessage
+	After  json.RawMessage
+}
+
+// AuditFilter selects audit entries; EntityID 0 matches every entity of the type
+type AuditFilter struct {
+	Entity   string
+	EntityID int
+	Limit    int
+}
+
+// IsAuditEntity reports whether entity is a known audited entity type
+func IsAuditEntity(entity string) bool {
+	for _, e := range AuditEntities {
+		if e == entity {
+			return true
+		}
+	}
+	return false
+}
@@ -3,4 +3,6 @@ package models
 type AuthorCriteriaModel struct {
 	FirstName string
 	LastName  string
+
+	IncludeDeleted bool // also return soft-deleted authors
 }
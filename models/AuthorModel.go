@@ -1,12 +1,17 @@
 package models
 
+import (
+	"time"
 
-import "github.com/uptrace/bun"
+	"github.com/uptrace/bun"
+)
 
 type Author struct {
 	bun.BaseModel `bun:"table:authors"`
-	ID        int     `bun:",pk,autoincrement"`
+	ID            int    `bun:",pk,autoincrement"`
 	FirstName     string `bun:",notnull"`
 	LastName      string `bun:",notnull"`
 	Bio           string
+	Version       int       `bun:",notnull,default:1"` // bumped on every write
+	DeletedAt     time.Time `bun:",soft_delete,nullzero"`
 }
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// AuthorRedirect keeps the ID of an author that was merged away pointing at
+// the author it was merged into, so old IDs still resolve
+type AuthorRedirect struct {
+	bun.BaseModel `bun:"table:author_redirects"`
+	OldID         int       `bun:",pk"`
+	AuthorID      int       `bun:",notnull"`
+	MergedAt      time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// AuthorDuplicate is a pair of authors that likely are the same person.
+// Score runs from 0 to 1; Reasons explains how the names matched.
+type AuthorDuplicate struct {
+	Author    Author
+	Duplicate Author
+	Score     float64
+	Reasons   []string
+}
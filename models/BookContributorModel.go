@@ -0,0 +1,36 @@
+package models
+
+import "github.com/uptrace/bun"
+
+// Contributor roles a person can have on a book
+const (
+	RoleAuthor      = "author"
+	RoleEditor      = "editor"
+	RoleTranslator  = "translator"
+	RoleIllustrator = "illustrator"
+	RoleForeword    = "foreword"
+	RoleNarrator    = "narrator"
+)
+
+// ContributorRoles lists every accepted contributor role
+var ContributorRoles = []string{RoleAuthor, RoleEditor, RoleTranslator, RoleIllustrator, RoleForeword, RoleNarrator}
+
+// BookContributor links an author to a book with a role and display order
+type BookContributor struct {
+	bun.BaseModel `bun:"table:book_contributors"`
+	BookID        int     `bun:",pk"`
+	AuthorID      int     `bun:",pk"`
+	Author        *Author `bun:"rel:belongs-to,join:author_id=id"`
+	Role          string  `bun:",pk"`
+	Position      int     `bun:",notnull"`
+}
+
+// IsContributorRole reports whether role is a known contributor role
+func IsContributorRole(role string) bool {
+	for _, r := range ContributorRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
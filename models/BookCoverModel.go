@@ -0,0 +1,25 @@
+package models
+
+// Cover image size limits
+const (
+	MaxCoverBytes  = 10 << 20 // largest accepted upload
+	MaxCoverPixels = 40e6     // largest accepted width*height, guards against decompression bombs
+)
+
+// Cover renditions, by longest side in pixels
+const (
+	CoverMediumSize    = 600
+	CoverThumbnailSize = 200
+)
+
+// CoverContentTypes lists the accepted upload formats
+var CoverContentTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+// BookCover holds the blob key prefix of a book's cover and the public URLs
+// of its renditions. It is stored in the cover_* columns of books.
+type BookCover struct {
+	Key          string `bun:"key,nullzero"`
+	URL          string `bun:"url,nullzero"`
+	MediumURL    string `bun:"medium_url,nullzero"`
+	ThumbnailURL string `bun:"thumbnail_url,nullzero"`
+}
@@ -0,0 +1,41 @@
+package models
+
+// ImportMode controls how rows are committed during a bulk import
+type ImportMode string
+
+const (
+	ImportModeAtomic     ImportMode = "atomic"      // all rows or nothing
+	ImportModeBestEffort ImportMode = "best_effort" // valid rows are kept
+)
+
+// ImportOptions carries the settings of one import run
+type ImportOptions struct {
+	Format string // "csv", "ndjson" or "onix"
+	DryRun bool
+	Mode   ImportMode
+}
+
+// ImportRowError describes why a single input row was rejected
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport summarizes the outcome of a bulk import
+type ImportReport struct {
+	Format         string           `json:"format"`
+	Mode           ImportMode       `json:"mode"`
+	DryRun         bool             `json:"dry_run"`
+	Committed      bool             `json:"committed"`
+	TotalRows      int              `json:"total_rows"`
+	ImportedRows   int              `json:"imported_rows"`
+	FailedRows     int              `json:"failed_rows"`
+	AuthorsCreated int              `json:"authors_created"`
+	AuthorsMatched int              `json:"authors_matched"`
+	GenresCreated  int              `json:"genres_created"`
+	Errors         []ImportRowError `json:"errors"`
+	// UnmappedFields counts source fields that have no place in the catalog
+	// model, keyed by element path (only filled for ONIX feeds)
+	UnmappedFields map[string]int `json:"unmapped_fields,omitempty"`
+}
@@ -6,6 +6,19 @@ import (
 	"github.com/uptrace/bun"
 )
 
+// Backorder policies of a book, from strictest to most lenient
+const (
+	// BackorderNone only sells copies in stock, and only once released
+	BackorderNone = "none"
+	// BackorderAllowed also takes orders beyond the stock once released
+	BackorderAllowed = "backorder"
+	// BackorderPreorder also takes orders before the release date
+	BackorderPreorder = "preorder"
+)
+
+// BackorderPolicies lists every backorder policy
+var BackorderPolicies = []string{BackorderNone, BackorderAllowed, BackorderPreorder}
+
 type Book struct {
 	bun.BaseModel `bun:"table:books"`
 	ID            int       `bun:",pk,autoincrement"`
@@ -14,6 +27,54 @@ type Book struct {
 	Author        *Author   `bun:"rel:belongs-to,join:author_id=id"`
 	Genres        []string  `bun:",array"`
 	PublishedAt   time.Time `bun:",notnull"`
-	Price         float64   `bun:",notnull"`
+	Price         Money     `bun:"embed:price_"`
 	Stock         int       `bun:",notnull"`
+
+	// Available is Stock less the copies held by active reservations; it is
+	// computed when a book is read and never stored
+	Available int `bun:",scanonly"`
+
+	// ReorderThreshold raises a low-stock alert once Stock falls to it; 0
+	// turns alerts off for the book
+	ReorderThreshold int `bun:",notnull,default:0"`
+
+	// BackorderPolicy says whether orders may go beyond the stock (see
+	// BackorderPolicies). RestockExpectedAt is when more stock is due, shown
+	// on backordered items; zero when unknown.
+	BackorderPolicy   string    `bun:",notnull,default:'none'"`
+	RestockExpectedAt time.Time `bun:",nullzero"`
+
+	// CostPrice is the average cost of a copy in stock, kept up to date by
+	// goods receipts. It is never shown to or set by API clients; sales
+	// reports use it for their margin.
+	CostPrice Money `bun:"embed:cost_price_" json:"-"`
+
+	// Contributors lists everyone credited on the book, ordered by Position.
+	// AuthorID always mirrors the first contributor with the author role.
+	Contributors []BookContributor `bun:"rel:has-many,join:id=book_id"`
+
+	PublisherID    int        `bun:",nullzero"` // Optional foreign key to Publisher
+	Publisher      *Publisher `bun:"rel:belongs-to,join:publisher_id=id"`
+	SeriesID       int        `bun:",nullzero"` // Optional foreign key to Series
+	Series         *Series    `bun:"rel:belongs-to,join:series_id=id"`
+	SeriesPosition int        `bun:",nullzero"`
+
+	// Editions are the sellable formats of this work. Price and Stock on the
+	// book itself still apply to orders that do not name an edition.
+	Editions []Edition `bun:"rel:has-many,join:id=book_id"`
+
+	// StockLevels break Stock down by location. Once a location exists,
+	// Stock is the sum of these and orders take stock from them.
+	StockLevels []StockLevel `bun:"rel:has-many,join:id=book_id"`
+
+	// Cover is only changed through POST/DELETE /api/books/{id}/cover
+	Cover BookCover `bun:"embed:cover_"`
+
+	// Version is bumped on every write; updates must name the version they
+	// read (see repositories.ErrVersionConflict)
+	Version int `bun:",notnull,default:1"`
+
+	// DeletedAt is set when the book is deleted; deleted books are hidden
+	// from normal queries but stay visible in past orders
+	DeletedAt time.Time `bun:",soft_delete,nullzero"`
 }
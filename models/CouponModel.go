@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Kinds of coupon
+const (
+	// CouponPercentage takes Value percent off the order
+	CouponPercentage = "percentage"
+	// CouponFixed takes Amount off the order
+	CouponFixed = "fixed"
+)
+
+// CouponKinds lists every kind of coupon
+var CouponKinds = []string{CouponPercentage, CouponFixed}
+
+// Coupon is a code a customer gives when placing an order to get money off
+// the order after promotions. MaxRedemptions caps how many orders may use
+// it in all (1 for a single-use code) and PerCustomerLimit how many orders
+// of one customer; 0 means no limit. A coupon only applies to orders worth
+// at least MinOrderValue, until ExpiresAt when set.
+type Coupon struct {
+	bun.BaseModel    `bun:"table:coupons"`
+	ID               int       `bun:",pk,autoincrement"`
+	Code             string    `bun:",unique,notnull"`
+	Kind             string    `bun:",notnull"`
+	Value            float64   `bun:",notnull,default:0"` // percentage
+	Amount           Money     `bun:"embed:amount_"`
+	MinOrderValue    Money     `bun:"embed:min_order_value_"`
+	MaxRedemptions   int       `bun:",notnull,default:0"`
+	PerCustomerLimit int       `bun:",notnull,default:0"`
+	ExpiresAt        time.Time `bun:",nullzero"`
+	CreatedAt        time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+	Description      string
+}
+
+// CouponRedemption is one use of a coupon. It is taken before its order is
+// saved, so that the limits hold under concurrent orders, and then points at
+// the order; deleting the order gives the redemption back.
+type CouponRedemption struct {
+	bun.BaseModel `bun:"table:coupon_redemptions"`
+	ID            int       `bun:",pk,autoincrement"`
+	CouponID      int       `bun:",notnull"`
+	UserID        int       `bun:",notnull"`
+	OrderID       int       `bun:",nullzero"`
+	CreatedAt     time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// CouponUsage sums up the redemptions of a coupon over a period
+type CouponUsage struct {
+	CouponID    int
+	Code        string
+	Redemptions int
+	Customers   int
+	Amount      Money `bun:"embed:amount_"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ExchangeRate is how many units of Currency one unit of the store currency
+// (DefaultCurrency) buys
+type ExchangeRate struct {
+	bun.BaseModel `bun:"table:exchange_rates"`
+	Currency      string    `bun:",pk"`
+	Rate          float64   `bun:",notnull"`
+	UpdatedAt     time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// PriceList sells the catalog in another currency. A book costs its store
+// price converted at the exchange rate of Currency, unless the list has a
+// BookPrice for it. Customers whose address is in one of Countries are shown
+// and charged in Currency unless they ask for another.
+type PriceList struct {
+	bun.BaseModel `bun:"table:price_lists"`
+	ID            int       `bun:",pk,autoincrement"`
+	Currency      string    `bun:",unique,notnull"`
+	Name          string    `bun:",notnull"`
+	Countries     []string  `bun:",array"`
+	CreatedAt     time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// BookPrice overrides the price of a book in a price list; Price is in the
+// currency of the list
+type BookPrice struct {
+	bun.BaseModel `bun:"table:book_prices"`
+	PriceListID   int       `bun:",pk"`
+	BookID        int       `bun:",pk"`
+	Price         Money     `bun:"embed:price_"`
+	UpdatedAt     time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Edition formats
+const (
+	FormatHardcover = "hardcover"
+	FormatPaperback = "paperback"
+	FormatEbook     = "ebook"
+	FormatAudiobook = "audiobook"
+)
+
+// EditionFormats lists every accepted edition format
+var EditionFormats = []string{FormatHardcover, FormatPaperback, FormatEbook, FormatAudiobook}
+
+// Edition is one sellable format of a book (the work), with its own ISBN,
+// price and stock
+type Edition struct {
+	bun.BaseModel `bun:"table:editions"`
+	ID            int       `bun:",pk,autoincrement"`
+	BookID        int       `bun:",notnull"` // Foreign key to Book (the work)
+	Format        string    `bun:",notnull"`
+	ISBN          string    `bun:"isbn,unique,nullzero"`
+	Price         Money     `bun:"embed:price_"`
+	Stock         int       `bun:",notnull"`
+	PublishedAt   time.Time `bun:",nullzero"`
+}
+
+// IsEditionFormat reports whether format is a known edition format
+func IsEditionFormat(format string) bool {
+	for _, f := range EditionFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
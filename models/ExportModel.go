@@ -0,0 +1,129 @@
+package models
+
+import (
+	"time"
+)
+
+// ExportFilter holds the list-endpoint filters accepted by exports.
+// Only the fields relevant to the exported entity are used.
+type ExportFilter struct {
+	Title      string
+	Author     string
+	Genre      string
+	FirstName  string
+	LastName   string
+	CustomerID int
+	From       time.Time
+	To         time.Time
+}
+
+// ExportRecord is implemented by every row type that can be exported
+type ExportRecord interface {
+	ExportHeader() []string
+	ExportValues() []interface{}
+}
+
+// ExportJob tracks an export running in the background
+type ExportJob struct {
+	ID          string       `json:"id"`
+	Entity      string       `json:"entity"`
+	Format      string       `json:"format"`
+	Filter      ExportFilter `json:"-"`
+	Status      string       `json:"status"`
+	Rows        int          `json:"rows"`
+	Error       string       `json:"error,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time   `json:"expires_at,omitempty"` // when a finished job and its file are removed
+	FilePath    string       `json:"-"`
+}
+
+const (
+	ExportJobQueued    = "queued"
+	ExportJobRunning   = "running"
+	ExportJobCompleted = "completed"
+	ExportJobFailed    = "failed"
+)
+
+// BookExportRow is one exported book with its author flattened in
+type BookExportRow struct {
+	ID         int    `bun:"id"`
+	Title      string `bun:"title"`
+	AuthorID   int    `bun:"author_id"`
+	AuthorName string `bun:"author_name"`
+	// Contributors is "Name (role)" for every credit, in credit order
+	Contributors string    `bun:"contributors"`
+	Genres       []string  `bun:"genres,array"`
+	PublishedAt  time.Time `bun:"published_at"`
+	Price        Money     `bun:"embed:price_"`
+	Stock        int       `bun:"stock"`
+}
+
+func (BookExportRow) ExportHeader() []string {
+	return []string{"id", "title", "author_id", "author_name", "contributors", "genres", "published_at", "price", "currency", "stock"}
+}
+
+func (b BookExportRow) ExportValues() []interface{} {
+	return []interface{}{b.ID, b.Title, b.AuthorID, b.AuthorName, b.Contributors, b.Genres, b.PublishedAt, b.Price, b.Price.Currency, b.Stock}
+}
+
+// AuthorExportRow is one exported author with their book count
+type AuthorExportRow struct {
+	ID        int    `bun:"id"`
+	FirstName string `bun:"first_name"`
+	LastName  string `bun:"last_name"`
+	Bio       string `bun:"bio"`
+	BookCount int    `bun:"book_count"`
+}
+
+func (AuthorExportRow) ExportHeader() []string {
+	return []string{"id", "first_name", "last_name", "bio", "book_count"}
+}
+
+func (a AuthorExportRow) ExportValues() []interface{} {
+	return []interface{}{a.ID, a.FirstName, a.LastName, a.Bio, a.BookCount}
+}
+
+// CustomerExportRow is one exported customer, without credentials
+type CustomerExportRow struct {
+	ID         int       `bun:"id"`
+	Name       string    `bun:"name"`
+	Email      string    `bun:"email"`
+	Role       string    `bun:"role"`
+	Street     string    `bun:"street"`
+	City       string    `bun:"city"`
+	State      string    `bun:"state"`
+	PostalCode string    `bun:"postal_code"`
+	Country    string    `bun:"country"`
+	CreatedAt  time.Time `bun:"created_at"`
+}
+
+func (CustomerExportRow) ExportHeader() []string {
+	return []string{"id", "name", "email", "role", "street", "city", "state", "postal_code", "country", "created_at"}
+}
+
+func (c CustomerExportRow) ExportValues() []interface{} {
+	return []interface{}{c.ID, c.Name, c.Email, c.Role, c.Street, c.City, c.State, c.PostalCode, c.Country, c.CreatedAt}
+}
+
+// OrderExportRow is one exported order with its items aggregated
+type OrderExportRow struct {
+	ID            int       `bun:"id"`
+	UserID        int       `bun:"user_id"`
+	CustomerName  string    `bun:"customer_name"`
+	CustomerEmail string    `bun:"customer_email"`
+	Status        string    `bun:"status"`
+	CreatedAt     time.Time `bun:"created_at"`
+	TotalPrice    Money     `bun:"embed:total_price_"`
+	Tax           Money     `bun:"embed:tax_"`
+	ItemCount     int       `bun:"item_count"`
+	TotalQuantity int       `bun:"total_quantity"`
+}
+
+func (OrderExportRow) ExportHeader() []string {
+	return []string{"id", "user_id", "customer_name", "customer_email", "status", "created_at", "total_price", "tax", "currency", "item_count", "total_quantity"}
+}
+
+func (o OrderExportRow) ExportValues() []interface{} {
+	return []interface{}{o.ID, o.UserID, o.CustomerName, o.CustomerEmail, o.Status, o.CreatedAt, o.TotalPrice, o.Tax, o.TotalPrice.Currency, o.ItemCount, o.TotalQuantity}
+}
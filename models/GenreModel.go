@@ -0,0 +1,45 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/uptrace/bun"
+)
+
+// Genre is one node of the genre taxonomy. Books store genre slugs, and a
+// genre also matches any of its aliases (stored as slugs too).
+type Genre struct {
+	bun.BaseModel `bun:"table:genres"`
+	ID            int      `bun:",pk,autoincrement"`
+	Name          string   `bun:",notnull"`
+	Slug          string   `bun:",unique,notnull"`
+	ParentID      int      `bun:",nullzero"` // Optional foreign key to the parent Genre
+	Parent        *Genre   `bun:"rel:belongs-to,join:parent_id=id"`
+	Aliases       []string `bun:",array"`
+}
+
+// GenreNode is a genre with its sub-genres, used to return the whole tree
+type GenreNode struct {
+	Genre
+	Children []GenreNode
+}
+
+// Slugify turns a genre name or alias into its slug: lowercase letters and
+// digits separated by single hyphens ("Science Fiction" -> "science-fiction")
+func Slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
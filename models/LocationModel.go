@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Location kinds
+const (
+	LocationShop      = "shop"
+	LocationWarehouse = "warehouse"
+)
+
+// LocationKinds lists every accepted location kind
+var LocationKinds = []string{LocationShop, LocationWarehouse}
+
+// Location is a place that holds stock, e.g. the shop or a warehouse.
+// Fulfillment considers locations by Priority, lowest first, and new stock
+// is received at the location with the lowest Priority.
+type Location struct {
+	bun.BaseModel `bun:"table:locations"`
+	ID            int    `bun:",pk,autoincrement"`
+	Code          string `bun:",unique,notnull"`
+	Name          string `bun:",notnull"`
+	Kind          string `bun:",notnull"`
+	Priority      int    `bun:",notnull"`
+}
+
+// StockLevel is the stock of one book at one location
+type StockLevel struct {
+	bun.BaseModel `bun:"table:stock_levels"`
+	BookID        int       `bun:",pk"`
+	LocationID    int       `bun:",pk"`
+	Location      *Location `bun:"rel:belongs-to,join:location_id=id"`
+	Quantity      int       `bun:",notnull"`
+}
+
+// StockTransfer records stock moved from one location to another
+type StockTransfer struct {
+	bun.BaseModel  `bun:"table:stock_transfers"`
+	ID             int       `bun:",pk,autoincrement"`
+	BookID         int       `bun:",notnull"`
+	FromLocationID int       `bun:",notnull"`
+	ToLocationID   int       `bun:",notnull"`
+	Quantity       int       `bun:",notnull"`
+	ActorID        int       `bun:",nullzero"`
+	CreatedAt      time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// IsLocationKind reports whether kind is a known location kind
+func IsLocationKind(kind string) bool {
+	for _, k := range LocationKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
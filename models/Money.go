@@ -0,0 +1,341 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the ISO 4217 code of the currency the store prices and
+// sells in. Amounts given without a currency are in it. It is set from
+// STORE_CURRENCY at startup.
+var DefaultCurrency = "USD"
+
+// currencyExponents lists the ISO 4217 currencies that do not have two
+// minor digits
+var currencyExponents = map[string]int{
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// CurrencyExponent is the number of minor digits of a currency, e.g. 2 for
+// USD (cents) and 0 for JPY
+func CurrencyExponent(currency string) int {
+	if e, ok := currencyExponents[currency]; ok {
+		return e
+	}
+	return 2
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 currency code
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Money is an amount in the minor units of a currency, e.g. 1050 USD is
+// 10.50 dollars. Amounts are whole numbers so that sums are exact. A result
+// that falls between two minor units (a decimal with more places than the
+// currency has, a percentage, an average) is rounded half to even, so that
+// rounding does not drift one way over many amounts.
+//
+// In JSON a Money is {"Amount": "10.50", "Currency": "USD"}; a request may
+// also give a bare number or decimal string in DefaultCurrency. In the
+// database it takes two columns, <name>_minor and <name>_currency.
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+// NewMoney is an amount in the minor units of currency
+func NewMoney(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d{1,3})?$`)
+
+// ParseMoney reads a decimal amount, e.g. "10.5", in currency
+func ParseMoney(amount, currency string) (Money, error) {
+	amount = strings.TrimSpace(amount)
+	if !decimalPattern.MatchString(amount) {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(CurrencyExponent(currency))), nil)
+	r.Mul(r, new(big.Rat).SetInt(scale))
+	minor, err := roundHalfEven(r)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", amount, err)
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// roundHalfEven rounds r to a whole number, halves to the even neighbour
+func roundHalfEven(r *big.Rat) (int64, error) {
+	q, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	if c := twice.Cmp(r.Denom()); c > 0 || (c == 0 && q.Bit(0) == 1) {
+		q.Add(q, big.NewInt(int64(r.Sign())))
+	}
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("amount out of range")
+	}
+	return q.Int64(), nil
+}
+
+// ErrCurrencyMismatch is the error of amounts in different currencies that
+// were to be combined
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+// InCurrency checks that every amount is in currency, or has none, and so
+// can be added to, compared with or subtracted from amounts in currency.
+// Amounts that were not worked out in the request, such as those read back
+// from the database, are checked with it before any arithmetic.
+func InCurrency(currency string, amounts ...Money) error {
+	for _, amount := range amounts {
+		if amount.Currency != "" && currency != "" && amount.Currency != currency {
+			return fmt.Errorf("%w: an amount in %s cannot be combined with %s", ErrCurrencyMismatch, amount.Currency, currency)
+		}
+	}
+	return nil
+}
+
+// currencyWith is the currency of m and o together. An amount without a
+// currency, such as a zero total, takes the other's. Combining two
+// different currencies is a programming error, not a bad request: callers
+// check amounts they did not work out themselves with InCurrency, which
+// returns an error instead.
+func (m Money) currencyWith(o Money) string {
+	switch {
+	case m.Currency == "":
+		return o.Currency
+	case o.Currency == "" || o.Currency == m.Currency:
+		return m.Currency
+	}
+	panic(fmt.Sprintf("money: cannot combine %s and %s", m.Currency, o.Currency))
+}
+
+// Add is m + o
+func (m Money) Add(o Money) Money {
+	return Money{Minor: m.Minor + o.Minor, Currency: m.currencyWith(o)}
+}
+
+// Sub is m - o
+func (m Money) Sub(o Money) Money {
+	return Money{Minor: m.Minor - o.Minor, Currency: m.currencyWith(o)}
+}
+
+// Times is m multiplied by a whole number, e.g. a quantity
+func (m Money) Times(n int) Money {
+	return Money{Minor: m.Minor * int64(n), Currency: m.Currency}
+}
+
+// Percent is p percent of m
+func (m Money) Percent(p float64) Money {
+	rate, ok := new(big.Rat).SetString(strconv.FormatFloat(p, 'f', -1, 64))
+	if !ok {
+		return Money{Currency: m.Currency}
+	}
+	r := new(big.Rat).SetInt64(m.Minor)
+	r.Mul(r, rate)
+	r.Quo(r, big.NewRat(100, 1))
+	minor, _ := roundHalfEven(r)
+	return Money{Minor: minor, Currency: m.Currency}
+}
+
+// PercentWithin is the part of m that p percent added on top of an amount
+// make up, e.g. the tax a price includes: 19 of 119 at 19 percent
+func (m Money) PercentWithin(p float64) Money {
+	rate, ok := new(big.Rat).SetString(strconv.FormatFloat(p, 'f', -1, 64))
+	if !ok || rate.Sign() <= 0 {
+		return Money{Currency: m.Currency}
+	}
+	r := new(big.Rat).SetInt64(m.Minor)
+	r.Mul(r, rate)
+	r.Quo(r, new(big.Rat).Add(rate, big.NewRat(100, 1)))
+	minor, _ := roundHalfEven(r)
+	return Money{Minor: minor, Currency: m.Currency}
+}
+
+// Divide is m split n ways, e.g. the average of n copies
+func (m Money) Divide(n int) Money {
+	if n == 0 {
+		return Money{Currency: m.Currency}
+	}
+	minor, _ := roundHalfEven(big.NewRat(m.Minor, int64(n)))
+	return Money{Minor: minor, Currency: m.Currency}
+}
+
+// Allocate splits m in proportion to weights. Every share but the last is
+// rounded and the last takes what is left, so the shares add up to m.
+func (m Money) Allocate(weights []int) []Money {
+	shares := make([]Money, len(weights))
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	left := m
+	for i, w := range weights {
+		if i == len(weights)-1 {
+			shares[i] = left
+			break
+		}
+		if total > 0 {
+			minor, _ := roundHalfEven(big.NewRat(m.Minor*int64(w), int64(total)))
+			shares[i] = Money{Minor: minor, Currency: m.Currency}
+		} else {
+			shares[i] = Money{Currency: m.Currency}
+		}
+		left = left.Sub(shares[i])
+	}
+	return shares
+}
+
+// Convert is m in currency to at rate, the units of to one unit of m's
+// currency buys, rounded to the minor units of to
+func (m Money) Convert(to string, rate float64) Money {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	if !ok {
+		return Money{Currency: to}
+	}
+	return m.exchange(to, r)
+}
+
+// Revert undoes Convert: m, converted from currency to at rate, back in to
+func (m Money) Revert(to string, rate float64) Money {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'f', -1, 64))
+	if !ok || r.Sign() == 0 {
+		return Money{Currency: to}
+	}
+	return m.exchange(to, r.Inv(r))
+}
+
+func (m Money) exchange(to string, rate *big.Rat) Money {
+	r := new(big.Rat).SetInt64(m.Minor)
+	r.Mul(r, rate)
+	// Minor units of the two currencies may differ in size
+	shift := CurrencyExponent(to) - CurrencyExponent(m.Currency)
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(shift, -shift))), nil))
+	if shift >= 0 {
+		r.Mul(r, scale)
+	} else {
+		r.Quo(r, scale)
+	}
+	minor, _ := roundHalfEven(r)
+	return Money{Minor: minor, Currency: to}
+}
+
+// Less tells whether m is below o
+func (m Money) Less(o Money) bool {
+	m.currencyWith(o)
+	return m.Minor < o.Minor
+}
+
+// Min is the smaller of m and o
+func (m Money) Min(o Money) Money {
+	if o.Less(m) {
+		return Money{Minor: o.Minor, Currency: m.currencyWith(o)}
+	}
+	return Money{Minor: m.Minor, Currency: m.currencyWith(o)}
+}
+
+// Sign is -1, 0 or 1 as m is negative, zero or positive
+func (m Money) Sign() int {
+	switch {
+	case m.Minor < 0:
+		return -1
+	case m.Minor > 0:
+		return 1
+	}
+	return 0
+}
+
+// Float64 is m in major units. It is only meant for display and bounds
+// checks; arithmetic stays in minor units.
+func (m Money) Float64() float64 {
+	f, _ := strconv.ParseFloat(m.Decimal(), 64)
+	return f
+}
+
+// Decimal is m in major units with all its minor digits, e.g. "10.50"
+func (m Money) Decimal() string {
+	exp := CurrencyExponent(m.Currency)
+	digits := strconv.FormatInt(m.Minor, 10)
+	sign := ""
+	if m.Minor < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+func (m Money) String() string {
+	return strings.TrimSpace(m.Decimal() + " " + m.Currency)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string
+		Currency string
+	}{m.Decimal(), m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	amount, currency := data, ""
+	if len(data) > 0 && data[0] == '{' {
+		var obj struct {
+			Amount   json.RawMessage
+			Currency string
+		}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		amount, currency = bytes.TrimSpace(obj.Amount), strings.ToUpper(strings.TrimSpace(obj.Currency))
+	}
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	if !ValidCurrency(currency) {
+		return fmt.Errorf("invalid currency %q", currency)
+	}
+	if len(amount) == 0 || string(amount) == "null" {
+		*m = Money{Currency: currency}
+		return nil
+	}
+
+	text := string(amount)
+	if amount[0] == '"' {
+		if err := json.Unmarshal(amount, &text); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseMoney(text, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
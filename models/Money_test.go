@@ -0,0 +1,228 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoneyRoundsHalfToEven(t *testing.T) {
+	cases := []struct {
+		amount, currency string
+		want             int64
+	}{
+		{"10.50", "USD", 1050},
+		{"0.125", "USD", 12},
+		{"0.135", "USD", 14},
+		{"0.1251", "USD", 13},
+		{"-0.125", "USD", -12},
+		{"-0.135", "USD", -14},
+		{"2.5", "JPY", 2},
+		{"3.5", "JPY", 4},
+		{"-2.5", "JPY", -2},
+		{"-3.5", "JPY", -4},
+		{"1.0005", "KWD", 1000},
+		{"1.0015", "KWD", 1002},
+		{".5", "USD", 50},
+		{"1e2", "USD", 10000},
+	}
+	for _, c := range cases {
+		got, err := ParseMoney(c.amount, c.currency)
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %s): %v", c.amount, c.currency, err)
+			continue
+		}
+		if want := NewMoney(c.want, c.currency); got != want {
+			t.Errorf("ParseMoney(%q, %s) = %v, want %v", c.amount, c.currency, got, want)
+		}
+	}
+}
+
+func TestParseMoneyRejects(t *testing.T) {
+	cases := []struct {
+		name, amount string
+	}{
+		{"empty", ""},
+		{"text", "ten"},
+		{"comma", "1,50"},
+		{"two signs", "--1"},
+		{"above int64", "92233720368547758.08"},
+		{"below int64", "-92233720368547758.09"},
+		{"huge exponent", "1e30"},
+	}
+	for _, c := range cases {
+		if got, err := ParseMoney(c.amount, "USD"); err == nil {
+			t.Errorf("%s: ParseMoney(%q) = %v, want an error", c.name, c.amount, got)
+		}
+	}
+	if _, err := ParseMoney("92233720368547758.07", "USD"); err != nil {
+		t.Errorf("largest amount: %v", err)
+	}
+}
+
+func TestMoneyPercentAndDivideRoundHalfToEven(t *testing.T) {
+	cases := []struct {
+		name string
+		got  Money
+		want int64
+	}{
+		{"percent tie down", NewMoney(1250, "USD").Percent(1), 12},
+		{"percent tie up", NewMoney(1350, "USD").Percent(1), 14},
+		{"negative percent tie", NewMoney(-1250, "USD").Percent(1), -12},
+		{"fractional percent", NewMoney(1000, "USD").Percent(12.5), 125},
+		{"within", NewMoney(11900, "USD").PercentWithin(19), 1900},
+		{"divide tie down", NewMoney(5, "USD").Divide(2), 2},
+		{"divide tie up", NewMoney(7, "USD").Divide(2), 4},
+		{"negative divide tie", NewMoney(-5, "USD").Divide(2), -2},
+		{"divide by zero", NewMoney(5, "USD").Divide(0), 0},
+	}
+	for _, c := range cases {
+		if c.got.Minor != c.want || c.got.Currency != "USD" {
+			t.Errorf("%s: got %v, want %d minor USD", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestMoneyAllocateSumsToTotal(t *testing.T) {
+	cases := []struct {
+		name    string
+		amount  Money
+		weights []int
+		want    []int64
+	}{
+		{"even thirds", NewMoney(100, "USD"), []int{1, 1, 1}, []int64{33, 33, 34}},
+		{"by weight", NewMoney(1000, "USD"), []int{1, 2, 2}, []int64{200, 400, 400}},
+		{"tie shares", NewMoney(5, "USD"), []int{1, 1, 2}, []int64{1, 1, 3}},
+		{"negative", NewMoney(-101, "USD"), []int{1, 1}, []int64{-50, -51}},
+		{"zero weights", NewMoney(999, "USD"), []int{0, 0}, []int64{0, 999}},
+		{"single", NewMoney(7, "JPY"), []int{3}, []int64{7}},
+	}
+	for _, c := range cases {
+		shares := c.amount.Allocate(c.weights)
+		if len(shares) != len(c.want) {
+			t.Errorf("%s: got %d shares, want %d", c.name, len(shares), len(c.want))
+			continue
+		}
+		sum := Money{Currency: c.amount.Currency}
+		for i, share := range shares {
+			if share.Minor != c.want[i] || share.Currency != c.amount.Currency {
+				t.Errorf("%s: share %d = %v, want %d minor %s", c.name, i, share, c.want[i], c.amount.Currency)
+			}
+			sum = sum.Add(share)
+		}
+		if sum != c.amount {
+			t.Errorf("%s: shares add up to %v, want %v", c.name, sum, c.amount)
+		}
+	}
+}
+
+func TestMoneyConvertAndRevert(t *testing.T) {
+	cases := []struct {
+		name string
+		got  Money
+		want Money
+	}{
+		// 2 -> 0 minor digits: 10.00 USD at 150 is 1500 JPY
+		{"USD to JPY", NewMoney(1000, "USD").Convert("JPY", 150), NewMoney(1500, "JPY")},
+		{"JPY back to USD", NewMoney(1500, "JPY").Revert("USD", 150), NewMoney(1000, "USD")},
+		// 0 -> 2: 1 JPY at 0.0067 is 0.0067 USD, rounded to 0.01
+		{"JPY to USD", NewMoney(1, "JPY").Convert("USD", 0.0067), NewMoney(1, "USD")},
+		// 2 -> 3: 10.00 USD at 0.30705 is 3.0705 KWD, a tie rounded to even
+		{"USD to KWD tie", NewMoney(1000, "USD").Convert("KWD", 0.30705), NewMoney(3070, "KWD")},
+		{"USD to KWD", NewMoney(1000, "USD").Convert("KWD", 0.30715), NewMoney(3072, "KWD")},
+		// 3 -> 2: 3.070 KWD back at 0.30705 is 9.9984 USD
+		{"KWD back to USD", NewMoney(3070, "KWD").Revert("USD", 0.30705), NewMoney(1000, "USD")},
+		// 3 -> 0: 3.070 KWD at 488.5 is 1499.695 JPY
+		{"KWD to JPY", NewMoney(3070, "KWD").Convert("JPY", 488.5), NewMoney(1500, "JPY")},
+		// 0 -> 3: 1500 JPY at 488.5 is 3.07062 KWD
+		{"JPY back to KWD", NewMoney(1500, "JPY").Revert("KWD", 488.5), NewMoney(3071, "KWD")},
+		{"negative", NewMoney(-1000, "USD").Convert("EUR", 0.92), NewMoney(-920, "EUR")},
+		{"zero rate revert", NewMoney(1000, "EUR").Revert("USD", 0), NewMoney(0, "USD")},
+	}
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		name, input string
+		want        Money
+	}{
+		{"bare number", `10.5`, NewMoney(1050, DefaultCurrency)},
+		{"bare string", `"10.50"`, NewMoney(1050, DefaultCurrency)},
+		{"bare tie", `0.125`, NewMoney(12, DefaultCurrency)},
+		{"object", `{"Amount": "10.5", "Currency": "EUR"}`, NewMoney(1050, "EUR")},
+		{"object number", `{"Amount": 1500, "Currency": "JPY"}`, NewMoney(1500, "JPY")},
+		{"lower-case currency", `{"Amount": "1.5", "Currency": " kwd "}`, NewMoney(1500, "KWD")},
+		{"object without currency", `{"Amount": "2"}`, NewMoney(200, DefaultCurrency)},
+		{"object without amount", `{"Currency": "EUR"}`, NewMoney(0, "EUR")},
+		{"null", `null`, NewMoney(0, DefaultCurrency)},
+	}
+	for _, c := range cases {
+		var got Money
+		if err := got.UnmarshalJSON([]byte(c.input)); err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	for _, input := range []string{
+		`"ten"`,
+		`true`,
+		`{"Amount": "1", "Currency": "EURO"}`,
+		`{"Amount": "1", "Currency": "E1R"}`,
+		`{"Amount": "92233720368547758.08"}`,
+		`1e30`,
+		`{"Amount": [1]}`,
+	} {
+		var got Money
+		if err := got.UnmarshalJSON([]byte(input)); err == nil {
+			t.Errorf("UnmarshalJSON(%s) = %v, want an error", input, got)
+		}
+	}
+}
+
+func TestMoneyMarshalJSON(t *testing.T) {
+	cases := []struct {
+		amount Money
+		want   string
+	}{
+		{NewMoney(1050, "USD"), `{"Amount":"10.50","Currency":"USD"}`},
+		{NewMoney(-5, "USD"), `{"Amount":"-0.05","Currency":"USD"}`},
+		{NewMoney(1500, "JPY"), `{"Amount":"1500","Currency":"JPY"}`},
+		{NewMoney(1, "KWD"), `{"Amount":"0.001","Currency":"KWD"}`},
+	}
+	for _, c := range cases {
+		data, err := json.Marshal(c.amount)
+		if err != nil {
+			t.Errorf("Marshal(%v): %v", c.amount, err)
+			continue
+		}
+		if string(data) != c.want {
+			t.Errorf("Marshal(%v) = %s, want %s", c.amount, data, c.want)
+		}
+		var back Money
+		if err := json.Unmarshal(data, &back); err != nil || back != c.amount {
+			t.Errorf("round trip of %v gave %v, %v", c.amount, back, err)
+		}
+	}
+}
+
+func TestInCurrency(t *testing.T) {
+	if err := InCurrency("USD", NewMoney(1, "USD"), Money{Minor: 0}); err != nil {
+		t.Errorf("matching amounts: %v", err)
+	}
+	if err := InCurrency("USD", NewMoney(1, "USD"), NewMoney(1, "EUR")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("mixed amounts: got %v, want ErrCurrencyMismatch", err)
+	}
+	// An amount without a currency takes the other's
+	if got := (Money{Minor: 0}).Add(NewMoney(250, "EUR")); got != NewMoney(250, "EUR") {
+		t.Errorf("zero plus EUR = %v", got)
+	}
+}
@@ -1,12 +1,35 @@
 package models
 
-import "github.com/uptrace/bun"
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
 
 type OrderItem struct {
 	bun.BaseModel `bun:"table:order_items"`
-	ID            int  `bun:",pk,autoincrement"`
-	OrderID       int  `bun:",notnull"` // Foreign key to Order
-	BookID        int  `bun:",notnull"` // Foreign key to Book
-	Book          *Book `bun:"rel:belongs-to,join:book_id=id"`
-	Quantity      int  `bun:",notnull"`
+	ID            int       `bun:",pk,autoincrement"`
+	OrderID       int       `bun:",notnull"` // Foreign key to Order
+	BookID        int       `bun:",notnull"` // Foreign key to Book
+	Book          *Book     `bun:"rel:belongs-to,join:book_id=id"`
+	EditionID     int       `bun:",nullzero"` // Optional foreign key to Edition
+	Edition       *Edition  `bun:"rel:belongs-to,join:edition_id=id"`
+	LocationID    int       `bun:",nullzero"` // Location the item ships from; 0 for editions and untracked stock
+	Location      *Location `bun:"rel:belongs-to,join:location_id=id"`
+	Quantity      int       `bun:",notnull"`
+	UnitCost      Money     `bun:"embed:unit_cost_" json:"-"` // cost price of the book when sold; 0 for editions
+
+	// Backordered items wait for stock or for the book's release; their
+	// copies are taken once available. ExpectedAt is when that should be,
+	// zero when unknown.
+	Backordered bool      `bun:",notnull,default:false"`
+	ExpectedAt  time.Time `bun:",nullzero"`
+
+	// UnitPrice is what one copy cost in the currency of the order, before
+	// discounts; zero with no currency when unknown, as on most items of
+	// orders placed before unit prices were kept
+	UnitPrice Money `bun:"embed:unit_price_"`
+
+	// Discount is what promotions took off the price of the item's copies
+	Discount Money `bun:"embed:discount_"`
 }
@@ -7,12 +7,22 @@ import (
 )
 
 type Order struct {
-	bun.BaseModel `bun:"table:orders"`
-	ID            int         `bun:",pk,autoincrement"`
-	UserID        int         `bun:",notnull"` // Foreign key to User (replaces CustomerID)
-	User          *User       `bun:"rel:belongs-to,join:user_id=id"` // Relationship to User
-	Items         []OrderItem `bun:"rel:has-many,join:id=order_id"`  // Relationship to OrderItem
-	TotalPrice    float64     `bun:",notnull"`
-	CreatedAt     time.Time   `bun:",nullzero,notnull,default:current_timestamp"`
-	Status        string      `bun:",notnull"`
+	bun.BaseModel  `bun:"table:orders"`
+	ID             int             `bun:",pk,autoincrement"`
+	UserID         int             `bun:",notnull"`                       // Foreign key to User (replaces CustomerID)
+	User           *User           `bun:"rel:belongs-to,join:user_id=id"` // Relationship to User
+	Items          []OrderItem     `bun:"rel:has-many,join:id=order_id"`  // Relationship to OrderItem
+	Currency       string          `bun:",notnull"`                       // every amount of the order is in it
+	ExchangeRate   float64         `bun:",notnull,default:1"`             // units of Currency per unit of the store currency when priced
+	TotalPrice     Money           `bun:"embed:total_price_"`             // after discounts, with tax
+	Discount       Money           `bun:"embed:discount_"`                // sum of Discounts
+	Discounts      []OrderDiscount `bun:"rel:has-many,join:id=order_id"`  // promotions applied to the items
+	CouponCode     string          `bun:",nullzero"`                      // coupon given when the order was placed
+	CouponDiscount Money           `bun:"embed:coupon_discount_"`         // taken off by the coupon, after promotions
+	TaxMode        string          `bun:",notnull"`                       // TaxExclusive or TaxInclusive when priced
+	Tax            Money           `bun:"embed:tax_"`                     // sum of Taxes; part of TotalPrice either way
+	Taxes          []OrderTax      `bun:"rel:has-many,join:id=order_id"`  // tax lines by rate
+	CreatedAt      time.Time       `bun:",nullzero,notnull,default:current_timestamp"`
+	Status         string          `bun:",notnull"`
+	Version        int             `bun:",notnull,default:1"` // bumped on every write
 }
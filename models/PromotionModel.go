@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Kinds of promotion
+const (
+	// PromotionPercentage takes Value percent off the price
+	PromotionPercentage = "percentage"
+	// PromotionFixed takes Amount off the price of each copy
+	PromotionFixed = "fixed"
+	// PromotionBuyXGetY gives GetQuantity copies free for every BuyQuantity
+	// copies bought of the same book or edition
+	PromotionBuyXGetY = "buy_x_get_y"
+)
+
+// PromotionKinds lists every kind of promotion
+var PromotionKinds = []string{PromotionPercentage, PromotionFixed, PromotionBuyXGetY}
+
+// Promotion is a discount rule applied when an order is priced. It covers
+// the books of a Genre (and its sub-genres) and/or by an AuthorID, or every
+// book when neither is set, between StartsAt and EndsAt (either may be zero
+// for no bound).
+//
+// An order line gets either the single best promotion that is not
+// Stackable or the sum of all Stackable ones, whichever saves more.
+type Promotion struct {
+	bun.BaseModel `bun:"table:promotions"`
+	ID            int       `bun:",pk,autoincrement"`
+	Name          string    `bun:",notnull"`
+	Kind          string    `bun:",notnull"`
+	Value         float64   `bun:",notnull,default:0"` // percentage
+	Amount        Money     `bun:"embed:amount_"`
+	BuyQuantity   int       `bun:",nullzero"`
+	GetQuantity   int       `bun:",nullzero"`
+	Genre         string    `bun:",nullzero"` // genre slug
+	AuthorID      int       `bun:",nullzero"`
+	StartsAt      time.Time `bun:",nullzero"`
+	EndsAt        time.Time `bun:",nullzero"`
+	Stackable     bool      `bun:",notnull,default:false"`
+	CreatedAt     time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// ActiveAt tells whether the promotion runs at t
+func (p Promotion) ActiveAt(t time.Time) bool {
+	return (p.StartsAt.IsZero() || !t.Before(p.StartsAt)) && (p.EndsAt.IsZero() || t.Before(p.EndsAt))
+}
+
+// OrderDiscount is one promotion applied to one line of an order: the
+// copies of a book, or of one of its editions. Name is kept so the discount
+// still reads right once the promotion is changed or deleted.
+type OrderDiscount struct {
+	bun.BaseModel `bun:"table:order_discounts"`
+	ID            int    `bun:",pk,autoincrement"`
+	OrderID       int    `bun:",notnull"`
+	PromotionID   int    `bun:",nullzero"`
+	Name          string `bun:",notnull"`
+	BookID        int    `bun:",notnull"`
+	EditionID     int    `bun:",nullzero"`
+	Amount        Money  `bun:"embed:amount_"`
+}
+
+// PromotionUsage sums up what a promotion gave away over a period
+type PromotionUsage struct {
+	PromotionID int
+	Name        string
+	Orders      int
+	Amount      Money `bun:"embed:amount_"`
+}
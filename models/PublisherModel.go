@@ -0,0 +1,11 @@
+package models
+
+import "github.com/uptrace/bun"
+
+type Publisher struct {
+	bun.BaseModel `bun:"table:publishers"`
+	ID            int    `bun:",pk,autoincrement"`
+	Name          string `bun:",unique,notnull"`
+	Website       string
+	Country       string
+}
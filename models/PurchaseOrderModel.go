@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Purchase order statuses. A draft can still be changed; once sent, goods
+// receipts move it to partially received and then received.
+const (
+	PurchaseOrderDraft             = "draft"
+	PurchaseOrderSent              = "sent"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+)
+
+// PurchaseOrderStatuses lists every purchase order status in lifecycle order
+var PurchaseOrderStatuses = []string{PurchaseOrderDraft, PurchaseOrderSent, PurchaseOrderPartiallyReceived, PurchaseOrderReceived}
+
+// PurchaseOrder is an order of books placed with a supplier
+type PurchaseOrder struct {
+	bun.BaseModel `bun:"table:purchase_orders"`
+	ID            int                 `bun:",pk,autoincrement"`
+	SupplierID    int                 `bun:",notnull"`
+	Supplier      *Supplier           `bun:"rel:belongs-to,join:supplier_id=id"`
+	Status        string              `bun:",notnull"`
+	Lines         []PurchaseOrderLine `bun:"rel:has-many,join:id=purchase_order_id"`
+	CreatedAt     time.Time           `bun:",nullzero,notnull,default:current_timestamp"`
+	SentAt        time.Time           `bun:",nullzero"`
+	ReceivedAt    time.Time           `bun:",nullzero"` // set once every line is received in full
+}
+
+// PurchaseOrderLine orders a quantity of one book at a unit cost
+type PurchaseOrderLine struct {
+	bun.BaseModel    `bun:"table:purchase_order_lines"`
+	ID               int   `bun:",pk,autoincrement"`
+	PurchaseOrderID  int   `bun:",notnull"`
+	BookID           int   `bun:",notnull"`
+	Book             *Book `bun:"rel:belongs-to,join:book_id=id"`
+	Quantity         int   `bun:",notnull"`
+	ReceivedQuantity int   `bun:",notnull,default:0"`
+	UnitCost         Money `bun:"embed:unit_cost_"`
+}
+
+// Outstanding is the quantity of a line still to be received
+func (l PurchaseOrderLine) Outstanding() int {
+	return max(l.Quantity-l.ReceivedQuantity, 0)
+}
+
+// GoodsReceipt records a delivery against a purchase order
+type GoodsReceipt struct {
+	bun.BaseModel   `bun:"table:goods_receipts"`
+	ID              int                `bun:",pk,autoincrement"`
+	PurchaseOrderID int                `bun:",notnull"`
+	LocationID      int                `bun:",nullzero"` // 0 receives at the first location
+	ActorID         int                `bun:",nullzero"`
+	Lines           []GoodsReceiptLine `bun:"rel:has-many,join:id=goods_receipt_id"`
+	CreatedAt       time.Time          `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// GoodsReceiptLine is the quantity of a purchase order line that arrived and
+// what each copy cost
+type GoodsReceiptLine struct {
+	bun.BaseModel       `bun:"table:goods_receipt_lines"`
+	ID                  int   `bun:",pk,autoincrement"`
+	GoodsReceiptID      int   `bun:",notnull"`
+	PurchaseOrderLineID int   `bun:",notnull"`
+	BookID              int   `bun:",notnull"`
+	Quantity            int   `bun:",notnull"`
+	UnitCost            Money `bun:"embed:unit_cost_"`
+}
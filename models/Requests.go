@@ -0,0 +1,370 @@
+package models
+
+import "time"
+
+// Request bodies accepted by the API. They declare the rules a request must
+// meet in `validate` tags (see the validation package) and use the same field
+// names as the models they become, so the JSON accepted is unchanged.
+
+// BookRequest is the body of POST and PUT /api/books. The author is given by
+// AuthorID, Author or Contributors.
+type BookRequest struct {
+	Title             string `validate:"required,max=255"`
+	AuthorID          int    `validate:"gte=0"`
+	Author            *AuthorRequest
+	Contributors      []ContributorRequest `validate:"dive"`
+	Genres            []string             `validate:"dive,required"`
+	PublishedAt       time.Time            `validate:"required"`
+	Price             Money                `validate:"gte=0"`
+	Stock             int                  `validate:"gte=0"`
+	ReorderThreshold  int                  `validate:"gte=0"`
+	BackorderPolicy   string               `validate:"omitempty,oneof=none backorder preorder"`
+	PublisherID       int                  `validate:"gte=0"`
+	SeriesID          int                  `validate:"gte=0"`
+	SeriesPosition    int                  `validate:"gte=0"`
+	RestockExpectedAt time.Time
+}
+
+// ContributorRequest credits an existing author (AuthorID) or a new one
+// (Author) on a book
+type ContributorRequest struct {
+	AuthorID int `validate:"gte=0"`
+	Author   *AuthorRequest
+	Role     string
+	Position int `validate:"gte=0"`
+}
+
+// AuthorRequest is the body of POST and PUT /api/authors
+type AuthorRequest struct {
+	FirstName string `validate:"required,max=100"`
+	LastName  string `validate:"required,max=100"`
+	Bio       string `validate:"max=5000"`
+}
+
+// CustomerRequest is the body of PUT /api/customers/{id}
+type CustomerRequest struct {
+	Name    string  `json:"name" validate:"required,max=255"`
+	Email   string  `json:"email" validate:"required,email"`
+	Address Address `json:"address"`
+}
+
+// OrderRequest is the body of POST and PUT /api/orders. A coupon code and a
+// currency are only taken when the order is placed; without a currency the
+// order is in the currency of the customer's country.
+type OrderRequest struct {
+	UserID     int                `validate:"gte=0"`
+	Items      []OrderItemRequest `validate:"required,dive"`
+	CouponCode string             `json:"coupon_code" validate:"max=50"`
+	Currency   string             `validate:"max=3"`
+}
+
+// OrderItemRequest orders a quantity of a book, or of one of its editions
+type OrderItemRequest struct {
+	BookID    int `validate:"gte=0"`
+	EditionID int `validate:"gte=0"`
+	Quantity  int `validate:"gt=0"`
+}
+
+// ReservationRequest is the body of POST /api/reservations. Customers
+// reserve for themselves; admins name the customer in UserID.
+type ReservationRequest struct {
+	UserID   int `validate:"gte=0"`
+	BookID   int `validate:"required,gt=0"`
+	Quantity int `validate:"gt=0"`
+}
+
+// EditionRequest is the body of POST /api/books/{id}/editions and PUT /api/editions/{id}
+type EditionRequest struct {
+	Format      string `validate:"required"`
+	ISBN        string `validate:"max=17"`
+	Price       Money  `validate:"gte=0"`
+	Stock       int    `validate:"gte=0"`
+	PublishedAt time.Time
+}
+
+// PublisherRequest is the body of POST and PUT /api/publishers
+type PublisherRequest struct {
+	Name    string `validate:"required,max=255"`
+	Website string `validate:"max=255"`
+	Country string `validate:"max=100"`
+}
+
+// SeriesRequest is the body of POST and PUT /api/series
+type SeriesRequest struct {
+	Name        string `validate:"required,max=255"`
+	Description string
+	PublisherID int `validate:"gte=0"`
+}
+
+// GenreRequest is the body of POST and PUT /api/genres
+type GenreRequest struct {
+	Name     string   `validate:"required,max=100"`
+	Slug     string   `validate:"max=100"`
+	ParentID int      `validate:"gte=0"`
+	Aliases  []string `validate:"dive,required"`
+}
+
+// LocationRequest is the body of POST and PUT /api/locations
+type LocationRequest struct {
+	Code     string `validate:"required,max=20"`
+	Name     string `validate:"required,max=255"`
+	Kind     string `validate:"required,oneof=shop warehouse"`
+	Priority int    `validate:"gte=0"`
+}
+
+// StockTransferRequest is the body of POST /api/stock-transfers
+type StockTransferRequest struct {
+	BookID         int `validate:"required,gt=0"`
+	FromLocationID int `validate:"required,gt=0"`
+	ToLocationID   int `validate:"required,gt=0"`
+	Quantity       int `validate:"gt=0"`
+}
+
+// StockMovementRequest is the body of POST /api/books/{id}/stock-movements.
+// Without a LocationID the stock is placed or taken the way a book edit would.
+type StockMovementRequest struct {
+	Kind       string `validate:"required,oneof=receipt return adjustment"`
+	Quantity   int    `validate:"required"`
+	LocationID int    `validate:"gte=0"`
+	Reason     string `validate:"required,max=255"`
+	UnitCost   Money  `validate:"gte=0"`
+}
+
+// SupplierRequest is the body of POST and PUT /api/suppliers
+type SupplierRequest struct {
+	Name  string `validate:"required,max=255"`
+	Email string `validate:"omitempty,email"`
+	Phone string `validate:"max=50"`
+}
+
+// PurchaseOrderRequest is the body of POST and PUT /api/purchase-orders
+type PurchaseOrderRequest struct {
+	SupplierID int                        `validate:"required,gt=0"`
+	Lines      []PurchaseOrderLineRequest `validate:"required,dive"`
+}
+
+// PurchaseOrderLineRequest orders a quantity of a book at a unit cost
+type PurchaseOrderLineRequest struct {
+	BookID   int   `validate:"required,gt=0"`
+	Quantity int   `validate:"gt=0"`
+	UnitCost Money `validate:"gte=0"`
+}
+
+// GoodsReceiptRequest is the body of POST /api/purchase-orders/{id}/receipts.
+// Without a LocationID the goods are received at the first location.
+type GoodsReceiptRequest struct {
+	LocationID int                       `validate:"gte=0"`
+	Lines      []GoodsReceiptLineRequest `validate:"required,dive"`
+}
+
+// GoodsReceiptLineRequest receives a quantity of a purchase order line;
+// UnitCost 0 keeps the cost the line was ordered at
+type GoodsReceiptLineRequest struct {
+	LineID   int   `validate:"required,gt=0"`
+	Quantity int   `validate:"gt=0"`
+	UnitCost Money `validate:"gte=0"`
+}
+
+// PromotionRequest is the body of POST and PUT /api/promotions. Value is the
+// percentage and Amount the money per copy taken off; BuyQuantity and
+// GetQuantity define a buy_x_get_y promotion.
+type PromotionRequest struct {
+	Name        string  `validate:"required,max=255"`
+	Kind        string  `validate:"required,oneof=percentage fixed buy_x_get_y"`
+	Value       float64 `validate:"gte=0"`
+	Amount      Money   `validate:"gte=0"`
+	BuyQuantity int     `validate:"gte=0"`
+	GetQuantity int     `validate:"gte=0"`
+	Genre       string  `validate:"max=100"`
+	AuthorID    int     `validate:"gte=0"`
+	Stackable   bool
+	StartsAt    time.Time
+	EndsAt      time.Time
+}
+
+// CouponRequest is the body of POST and PUT /api/coupons. Value is the
+// percentage and Amount the money taken off. Limits of 0 mean no limit; a
+// single-use code has MaxRedemptions 1.
+type CouponRequest struct {
+	Code             string  `validate:"required,max=50"`
+	Kind             string  `validate:"required,oneof=percentage fixed"`
+	Value            float64 `validate:"gte=0"`
+	Amount           Money   `validate:"gte=0"`
+	MinOrderValue    Money   `validate:"gte=0"`
+	MaxRedemptions   int     `validate:"gte=0"`
+	PerCustomerLimit int     `validate:"gte=0"`
+	Description      string  `validate:"max=255"`
+	ExpiresAt        time.Time
+}
+
+// ExchangeRateRequest is the body of PUT /api/exchange-rates/{currency}
+type ExchangeRateRequest struct {
+	Rate float64 `validate:"gt=0"`
+}
+
+// PriceListRequest is the body of POST and PUT /api/price-lists
+type PriceListRequest struct {
+	Currency  string   `validate:"required,max=3"`
+	Name      string   `validate:"required,max=255"`
+	Countries []string `validate:"dive,required,max=100"`
+}
+
+// BookPriceRequest is the body of PUT /api/price-lists/{id}/prices/{book_id}.
+// Amount is a decimal in the currency of the list, e.g. "9.99".
+type BookPriceRequest struct {
+	Amount string `validate:"required,max=32"`
+}
+
+// TaxRateRequest is the body of POST and PUT /api/tax-rates. Rate is a
+// percentage; State and Category are optional.
+type TaxRateRequest struct {
+	Name     string  `validate:"required,max=100"`
+	Country  string  `validate:"required,max=100"`
+	State    string  `validate:"max=100"`
+	Category string  `validate:"max=20"`
+	Rate     float64 `validate:"gte=0,lte=100"`
+}
+
+// MergeRequest is the body of the author and genre merge endpoints
+type MergeRequest struct {
+	SourceID int `validate:"required,gt=0"`
+}
+
+func (r BookRequest) Book() Book {
+	book := Book{
+		Title:             r.Title,
+		AuthorID:          r.AuthorID,
+		Genres:            r.Genres,
+		PublishedAt:       r.PublishedAt,
+		Price:             r.Price,
+		Stock:             r.Stock,
+		ReorderThreshold:  r.ReorderThreshold,
+		BackorderPolicy:   r.BackorderPolicy,
+		RestockExpectedAt: r.RestockExpectedAt,
+		PublisherID:       r.PublisherID,
+		SeriesID:          r.SeriesID,
+		SeriesPosition:    r.SeriesPosition,
+	}
+	if r.Author != nil {
+		author := r.Author.Author()
+		book.Author = &author
+	}
+	// A nil list leaves the contributors of an existing book alone
+	if r.Contributors != nil {
+		book.Contributors = make([]BookContributor, len(r.Contributors))
+		for i, c := range r.Contributors {
+			book.Contributors[i] = BookContributor{AuthorID: c.AuthorID, Role: c.Role, Position: c.Position}
+			if c.Author != nil {
+				author := c.Author.Author()
+				book.Contributors[i].Author = &author
+			}
+		}
+	}
+	return book
+}
+
+func (r AuthorRequest) Author() Author {
+	return Author{FirstName: r.FirstName, LastName: r.LastName, Bio: r.Bio}
+}
+
+func (r CustomerRequest) User() User {
+	return User{Name: r.Name, Email: r.Email, Address: r.Address}
+}
+
+func (r OrderRequest) Order() Order {
+	order := Order{UserID: r.UserID, CouponCode: r.CouponCode, Currency: r.Currency, Items: make([]OrderItem, len(r.Items))}
+	for i, item := range r.Items {
+		order.Items[i] = OrderItem{BookID: item.BookID, EditionID: item.EditionID, Quantity: item.Quantity}
+	}
+	return order
+}
+
+func (r ReservationRequest) Reservation() Reservation {
+	return Reservation{UserID: r.UserID, BookID: r.BookID, Quantity: r.Quantity}
+}
+
+func (r EditionRequest) Edition() Edition {
+	return Edition{Format: r.Format, ISBN: r.ISBN, Price: r.Price, Stock: r.Stock, PublishedAt: r.PublishedAt}
+}
+
+func (r PublisherRequest) Publisher() Publisher {
+	return Publisher{Name: r.Name, Website: r.Website, Country: r.Country}
+}
+
+func (r SeriesRequest) Series() Series {
+	return Series{Name: r.Name, Description: r.Description, PublisherID: r.PublisherID}
+}
+
+func (r GenreRequest) Genre() Genre {
+	return Genre{Name: r.Name, Slug: r.Slug, ParentID: r.ParentID, Aliases: r.Aliases}
+}
+
+func (r LocationRequest) Location() Location {
+	return Location{Code: r.Code, Name: r.Name, Kind: r.Kind, Priority: r.Priority}
+}
+
+func (r StockTransferRequest) Transfer() StockTransfer {
+	return StockTransfer{BookID: r.BookID, FromLocationID: r.FromLocationID, ToLocationID: r.ToLocationID, Quantity: r.Quantity}
+}
+
+func (r StockMovementRequest) Movement() StockMovement {
+	return StockMovement{Kind: r.Kind, Quantity: r.Quantity, LocationID: r.LocationID, Reason: r.Reason, UnitCost: r.UnitCost}
+}
+
+func (r SupplierRequest) Supplier() Supplier {
+	return Supplier{Name: r.Name, Email: r.Email, Phone: r.Phone}
+}
+
+func (r PurchaseOrderRequest) PurchaseOrder() PurchaseOrder {
+	po := PurchaseOrder{SupplierID: r.SupplierID, Lines: make([]PurchaseOrderLine, len(r.Lines))}
+	for i, line := range r.Lines {
+		po.Lines[i] = PurchaseOrderLine{BookID: line.BookID, Quantity: line.Quantity, UnitCost: line.UnitCost}
+	}
+	return po
+}
+
+func (r GoodsReceiptRequest) Receipt() GoodsReceipt {
+	receipt := GoodsReceipt{LocationID: r.LocationID, Lines: make([]GoodsReceiptLine, len(r.Lines))}
+	for i, line := range r.Lines {
+		receipt.Lines[i] = GoodsReceiptLine{PurchaseOrderLineID: line.LineID, Quantity: line.Quantity, UnitCost: line.UnitCost}
+	}
+	return receipt
+}
+
+func (r PromotionRequest) Promotion() Promotion {
+	return Promotion{
+		Name:        r.Name,
+		Kind:        r.Kind,
+		Value:       r.Value,
+		Amount:      r.Amount,
+		BuyQuantity: r.BuyQuantity,
+		GetQuantity: r.GetQuantity,
+		Genre:       r.Genre,
+		AuthorID:    r.AuthorID,
+		Stackable:   r.Stackable,
+		StartsAt:    r.StartsAt,
+		EndsAt:      r.EndsAt,
+	}
+}
+
+func (r CouponRequest) Coupon() Coupon {
+	return Coupon{
+		Code:             r.Code,
+		Kind:             r.Kind,
+		Value:            r.Value,
+		Amount:           r.Amount,
+		MinOrderValue:    r.MinOrderValue,
+		MaxRedemptions:   r.MaxRedemptions,
+		PerCustomerLimit: r.PerCustomerLimit,
+		Description:      r.Description,
+		ExpiresAt:        r.ExpiresAt,
+	}
+}
+
+func (r PriceListRequest) PriceList() PriceList {
+	return PriceList{Currency: r.Currency, Name: r.Name, Countries: r.Countries}
+}
+
+func (r TaxRateRequest) TaxRate() TaxRate {
+	return TaxRate{Name: r.Name, Country: r.Country, State: r.State, Category: r.Category, Rate: r.Rate}
+}
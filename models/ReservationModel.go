@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Reservation holds copies of a book for a customer during checkout. The
+// copies stay in Stock but are no longer Available to anyone else until
+// the reservation turns into an order or expires.
+type Reservation struct {
+	bun.BaseModel `bun:"table:reservations"`
+	ID            int       `bun:",pk,autoincrement"`
+	BookID        int       `bun:",notnull"`
+	UserID        int       `bun:",notnull"`
+	Quantity      int       `bun:",notnull"`
+	ExpiresAt     time.Time `bun:",notnull"`
+	CreatedAt     time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
@@ -10,7 +10,12 @@ type SalesReport struct {
 	bun.BaseModel   `bun:"table:sales_reports"`
 	ID              int         `bun:",pk,autoincrement"` // ✅ Auto-increment primary key
 	Timestamp       time.Time   `bun:",nullzero,notnull,default:current_timestamp"`
-	TotalRevenue    float64     `bun:",notnull"`
+	TotalRevenue    Money       `bun:"embed:total_revenue_"`  // after discounts, with tax
+	TotalDiscount   Money       `bun:"embed:total_discount_"` // taken off by promotions and coupons
 	TotalOrders     int         `bun:",notnull"`
+	TotalTax        Money       `bun:"embed:total_tax_"`    // collected for the tax authorities, part of TotalRevenue
+	TotalCost       Money       `bun:"embed:total_cost_"`   // cost price of the copies sold
+	GrossMargin     Money       `bun:"embed:gross_margin_"` // TotalRevenue - TotalTax - TotalCost
+	TaxTotals       []TaxTotal  `bun:"-"`                   // TotalTax by rate
 	TopSellingBooks []BookSales `bun:"rel:has-many,join:id=book_id"`
 }
@@ -2,6 +2,9 @@ package models
 
 type SearchCriteria struct {
 	Title  string
-	Author string
+	Author string // matches any contributor's name
+	Role   string // restricts the Author match to one contributor role
 	Genre  string
+
+	IncludeDeleted bool // also return soft-deleted books
 }
@@ -0,0 +1,12 @@
+package models
+
+import "github.com/uptrace/bun"
+
+type Series struct {
+	bun.BaseModel `bun:"table:series,alias:series"`
+	ID            int    `bun:",pk,autoincrement"`
+	Name          string `bun:",notnull"`
+	Description   string
+	PublisherID   int        `bun:",nullzero"` // Optional foreign key to Publisher
+	Publisher     *Publisher `bun:"rel:belongs-to,join:publisher_id=id"`
+}
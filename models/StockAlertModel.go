@@ -0,0 +1,13 @@
+package models
+
+// StockAlert is a book whose stock has fallen to its reorder threshold,
+// with a proposed reorder quantity based on its recent sales
+type StockAlert struct {
+	BookID            int
+	Title             string
+	Stock             int
+	Threshold         int
+	Sold              int     // copies sold over the sales window, net of cancellations
+	DailySales        float64 // Sold per day of the window
+	SuggestedQuantity int
+}
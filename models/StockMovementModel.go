@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Stock movement kinds
+const (
+	MovementReceipt      = "receipt"
+	MovementSale         = "sale"
+	MovementReturn       = "return"
+	MovementAdjustment   = "adjustment"
+	MovementCancellation = "cancellation"
+	MovementTransfer     = "transfer"
+)
+
+// ManualMovementKinds are the movements an admin can record by hand; the
+// others are recorded by orders and transfers
+var ManualMovementKinds = []string{MovementReceipt, MovementReturn, MovementAdjustment}
+
+// StockMovement is one entry of the append-only stock ledger. The stock of a
+// book is the sum of its movements without an edition, the stock of an
+// edition the sum of its movements, and the stock of a book at a location
+// the sum of its movements at that location.
+type StockMovement struct {
+	bun.BaseModel `bun:"table:stock_movements"`
+	ID            int64     `bun:",pk,autoincrement"`
+	BookID        int       `bun:",notnull"`
+	EditionID     int       `bun:",nullzero"` // set for the stock of an edition
+	LocationID    int       `bun:",nullzero"` // 0 for stock not held at a location
+	Kind          string    `bun:",notnull"`
+	Quantity      int       `bun:",notnull"` // positive adds stock, negative removes it
+	Reason        string    `bun:",notnull"`
+	OrderID       int       `bun:",nullzero"`
+	UnitCost      Money     `bun:"embed:unit_cost_"` // cost of each copy of a receipt, when known
+	ActorID       int       `bun:",nullzero"`        // 0 for background jobs and command-line tools
+	CreatedAt     time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// StockDrift is a stock figure that does not match the ledger. EditionID is
+// set for the stock of an edition and LocationID for the stock at a location;
+// both are 0 for the total stock of a book.
+type StockDrift struct {
+	BookID     int
+	EditionID  int
+	LocationID int
+	Recorded   int // sum of the ledger
+	Actual     int // stock as stored
+}
+
+// IsManualMovementKind reports whether kind can be recorded by hand
+func IsManualMovementKind(kind string) bool {
+	for _, k := range ManualMovementKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
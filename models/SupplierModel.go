@@ -0,0 +1,12 @@
+package models
+
+import "github.com/uptrace/bun"
+
+// Supplier is a publisher or wholesaler we buy books from
+type Supplier struct {
+	bun.BaseModel `bun:"table:suppliers"`
+	ID            int    `bun:",pk,autoincrement"`
+	Name          string `bun:",unique,notnull"`
+	Email         string
+	Phone         string
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Tax categories: what the copies of an order line are taxed as
+const (
+	TaxCategoryBook  = "book"
+	TaxCategoryEbook = "ebook"
+)
+
+// TaxCategories lists every tax category a rate can be limited to
+var TaxCategories = []string{TaxCategoryBook, TaxCategoryEbook}
+
+// Tax modes: whether the prices of the store include tax
+const (
+	TaxExclusive = "exclusive" // tax is added on top of the price
+	TaxInclusive = "inclusive" // the price already holds the tax
+)
+
+// TaxRate is the tax, in percent, on sales to an address in Country, or
+// only in State of it when one is given. A rate with a Category applies to
+// that category only. For an address the most specific rate wins: a state's
+// over its country's, and then one for the category over one for all.
+type TaxRate struct {
+	bun.BaseModel `bun:"table:tax_rates"`
+	ID            int       `bun:",pk,autoincrement"`
+	Name          string    `bun:",notnull"` // e.g. "VAT" or "Sales tax", shown on orders
+	Country       string    `bun:",notnull"`
+	State         string    `bun:",nullzero"`
+	Category      string    `bun:",nullzero"`
+	Rate          float64   `bun:",notnull"`
+	CreatedAt     time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// OrderTax is the tax on the lines of an order taxed at one rate. The rate
+// is copied so the tax line still reads right once the rate is changed or
+// deleted.
+type OrderTax struct {
+	bun.BaseModel `bun:"table:order_taxes"`
+	ID            int     `bun:",pk,autoincrement"`
+	OrderID       int     `bun:",notnull"`
+	TaxRateID     int     `bun:",nullzero"`
+	Name          string  `bun:",notnull"`
+	Country       string  `bun:",notnull"`
+	State         string  `bun:",nullzero"`
+	Category      string  `bun:",nullzero"`
+	Rate          float64 `bun:",notnull"`
+	Taxable       Money   `bun:"embed:taxable_"` // what the rate applied to, after discounts
+	Amount        Money   `bun:"embed:amount_"`
+}
+
+// TaxTotal sums up the tax charged at one rate over a period, in the store
+// currency
+type TaxTotal struct {
+	Name     string
+	Country  string
+	State    string
+	Category string
+	Rate     float64
+	Taxable  Money
+	Amount   Money
+}
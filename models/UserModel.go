@@ -10,11 +10,13 @@ import (
 type User struct {
 	bun.BaseModel `bun:"table:users"`
 
-	ID           int       `json:"id" bun:",pk,autoincrement"`
-	Name         string    `json:"name" bun:",notnull"`
-	Email        string    `json:"email" bun:",unique,notnull"`
-	PasswordHash string    `json:"-" bun:",notnull"` // Hide from JSON
-	Role         string    `json:"role" bun:",notnull"`
-	Address      Address   `json:"address" bun:",embed"` 
-	CreatedAt    time.Time `json:"created_at" bun:",default:current_timestamp"`
+	ID           int        `json:"id" bun:",pk,autoincrement"`
+	Name         string     `json:"name" bun:",notnull"`
+	Email        string     `json:"email" bun:",unique,notnull"`
+	PasswordHash string     `json:"-" bun:",notnull"` // Hide from JSON
+	Role         string     `json:"role" bun:",notnull"`
+	Address      Address    `json:"address" bun:",embed"`
+	CreatedAt    time.Time  `json:"created_at" bun:",default:current_timestamp"`
+	Version      int        `json:"version" bun:",notnull,default:1"` // bumped on every write
+	DeletedAt    *time.Time `json:"deleted_at,omitempty" bun:",soft_delete,nullzero"`
 }
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+// AuditStore interface
+type AuditStore interface {
+	CreateAuditEntry(entry models.AuditEntry) error
+	ListAuditEntries(filter models.AuditFilter) ([]models.AuditEntry, error)
+	WithTx(tx bun.IDB) AuditStore
+}
+
+// PostgreSQL-backed implementation of AuditStore
+type AuditRepository struct {
+	db bun.IDB
+}
+
+// NewAuditRepository returns a new instance
+func NewAuditRepository(db *bun.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// WithTx returns an AuditStore that works inside tx
+func (r *AuditRepository) WithTx(tx bun.IDB) AuditStore {
+	return &AuditRepository{db: tx}
+}
+
+// CreateAuditEntry appends an entry to the audit log. The entry is written
+// in a transaction of its own, a savepoint when the repository works inside
+// one, so that a failed entry does not abort the change it describes.
+func (r *AuditRepository) CreateAuditEntry(entry models.AuditEntry) error {
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().Model(&entry).Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("error inserting audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEntries fetches audit entries, newest first
+func (r *AuditRepository) ListAuditEntries(filter models.AuditFilter) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	query := r.db.NewSelect().
+		Model(&entries).
+		Where("entity = ?", filter.Entity).
+		OrderExpr("created_at DESC, id DESC")
+
+	if filter.EntityID > 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if err := query.Scan(context.Background()); err != nil {
+		return nil, fmt.Errorf("error retrieving audit entries: %w", err)
+	}
+	return entries, nil
+}
@@ -4,7 +4,6 @@ import (
 	"FinalProject/models"
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -13,8 +12,8 @@ import (
 )
 
 var (
-	ErrUserNotFound = errors.New("user not found")
-	ErrDuplicateEmail = errors.New("email already exists")
+	ErrUserNotFound = NotFoundf("user not found")
+	ErrDuplicateEmail = Conflictf("email already exists")
 )
 
 // UserRepository handles user database operations
@@ -3,9 +3,12 @@ package repositories
 import (
 	"FinalProject/models"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/uptrace/bun"
 )
@@ -26,8 +29,11 @@ type AuthorStore interface {
 	CreateAuthor(author models.Author) (models.Author, error)
 	GetAuthor(id int) (models.Author, error)
 	UpdateAuthor(id int, author models.Author) (models.Author, error)
-	DeleteAuthor(id int) error
+	DeleteAuthor(id int, version int) error
 	ListAuthors() ([]models.Author, error)
+	MergeAuthors(targetID, sourceID int) (models.Author, error)
+	RestoreAuthor(id int) (models.Author, error)
+	PurgeDeletedAuthors(before time.Time) (int, error)
 }
 
 func (r *AuthorRepository) CreateAuthor(author models.Author) (models.Author, error) {
@@ -49,18 +55,31 @@ func (r *AuthorRepository) GetAuthor(id int) (models.Author, error) {
 		Where("id = ?", id).
 		For("UPDATE"). // Row-Level Locking
 		Scan(context.Background())
+	if errors.Is(err, sql.ErrNoRows) {
+		// The author may have been merged into another one
+		var redirect models.AuthorRedirect
+		if rerr := r.db.NewSelect().Model(&redirect).Where("old_id = ?", id).Scan(context.Background()); rerr == nil {
+			return r.GetAuthor(redirect.AuthorID)
+		}
+	}
 	if err != nil {
-		return models.Author{}, fmt.Errorf("author not found: %w", err)
+		return models.Author{}, lookupError(err, "author with ID %d not found", id)
 	}
 	return author, nil
 }
 
+// UpdateAuthor modifies an existing author; author.Version must be the
+// version the caller read
 func (r *AuthorRepository) UpdateAuthor(id int, author models.Author) (models.Author, error) {
 	author.ID = id
+	expected := author.Version
+	author.Version = expected + 1
 
 	result, err := r.db.NewUpdate().
 		Model(&author).
+		ExcludeColumn("deleted_at").
 		Where("id = ?", id).
+		Where("version = ?", expected).
 		Returning("*").
 		Exec(context.Background())
 
@@ -70,13 +89,13 @@ func (r *AuthorRepository) UpdateAuthor(id int, author models.Author) (models.Au
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return models.Author{}, fmt.Errorf("author with ID %d not found", id)
+		return models.Author{}, versionMismatch(context.Background(), r.db, (*models.Author)(nil), id, NotFoundf("author with ID %d not found", id))
 	}
 
 	return author, nil
 }
 
-func (r *AuthorRepository) DeleteAuthor(id int) error {
+func (r *AuthorRepository) DeleteAuthor(id int, version int) error {
 	ctx := context.Background()
 
 	log.Println("Starting deletion process for Author ID:", id)
@@ -87,6 +106,7 @@ func (r *AuthorRepository) DeleteAuthor(id int) error {
 		Table("authors").
 		ColumnExpr("COUNT(*) > 0").
 		Where("id = ?", id).
+		Where("deleted_at IS NULL").
 		Scan(ctx, &authorExists)
 
 	if err != nil {
@@ -96,17 +116,22 @@ func (r *AuthorRepository) DeleteAuthor(id int) error {
 
 	if !authorExists {
 		log.Println("Author not found:", id)
-		return fmt.Errorf("author with ID %d not found", id)
+		return NotFoundf("author with ID %d not found", id)
 	}
 
 	log.Println("Author exists. Proceeding to check for books.")
 
-	// ✅ Step 2: Check if the author has associated books
+	// ✅ Step 2: Check if the author is credited on any book, in any role.
+	// Deleted books count too, since they can still be restored.
 	var bookCount int
 	err = r.db.NewSelect().
 		Table("books").
 		ColumnExpr("COUNT(*)").
-		Where("author_id = ?", id).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.
+				Where("author_id = ?", id).
+				WhereOr("EXISTS (SELECT 1 FROM book_contributors AS bc WHERE bc.book_id = books.id AND bc.author_id = ?)", id)
+		}).
 		Scan(ctx, &bookCount)
 
 	if err != nil {
@@ -118,15 +143,16 @@ func (r *AuthorRepository) DeleteAuthor(id int) error {
 
 	if bookCount > 0 {
 		log.Println("Cannot delete author:", id, "because they have", bookCount, "associated books")
-		return fmt.Errorf("cannot delete author with ID %d because they have associated books", id)
+		return Conflictf("cannot delete author with ID %d because they have associated books", id)
 	}
 
 	log.Println("No associated books. Proceeding with deletion.")
 
-	// ✅ Step 3: Proceed with deletion if no books exist
+	// ✅ Step 3: Proceed with (soft) deletion if no books exist
 	result, err := r.db.NewDelete().
 		Model((*models.Author)(nil)).
 		Where("id = ?", id).
+		Where("version = ?", version).
 		Exec(ctx)
 
 	if err != nil {
@@ -136,14 +162,176 @@ func (r *AuthorRepository) DeleteAuthor(id int) error {
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		log.Println("Author with ID", id, "not found during deletion.")
-		return fmt.Errorf("author with ID %d not found", id)
+		log.Println("Author with ID", id, "not deleted: not found or modified concurrently.")
+		return versionMismatch(ctx, r.db, (*models.Author)(nil), id, NotFoundf("author with ID %d not found", id))
 	}
 
 	log.Println("Author successfully deleted:", id)
 	return nil
 }
 
+// MergeAuthors folds the source author into the target in one transaction:
+// every book, credit and promotion moves to the target, the bios are
+// combined, the source is deleted and a redirect keeps its ID resolving to
+// the target
+func (r *AuthorRepository) MergeAuthors(targetID, sourceID int) (models.Author, error) {
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		var authors []models.Author
+		err := tx.NewSelect().
+			Model(&authors).
+			Where("id IN (?)", bun.In([]int{targetID, sourceID})).
+			OrderExpr("id ASC").
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return fmt.Errorf("error locking authors: %w", err)
+		}
+
+		var target, source *models.Author
+		for i := range authors {
+			switch authors[i].ID {
+			case targetID:
+				target = &authors[i]
+			case sourceID:
+				source = &authors[i]
+			}
+		}
+		if target == nil {
+			return NotFoundf("author with ID %d not found", targetID)
+		}
+		if source == nil {
+			return NotFoundf("author with ID %d not found", sourceID)
+		}
+
+		// Credits the target already holds in the same role would collide
+		_, err = tx.NewDelete().
+			TableExpr("book_contributors AS s").
+			Where("s.author_id = ?", sourceID).
+			Where("EXISTS (SELECT 1 FROM book_contributors AS t WHERE t.book_id = s.book_id AND t.author_id = ? AND t.role = s.role)", targetID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error removing duplicate credits: %w", err)
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*models.BookContributor)(nil)).
+			Set("author_id = ?", targetID).
+			Where("author_id = ?", sourceID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error moving credits: %w", err)
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*models.Book)(nil)).
+			Set("author_id = ?", targetID).
+			Set("version = version + 1").
+			Where("author_id = ?", sourceID).
+			WhereAllWithDeleted().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error moving books: %w", err)
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*models.Promotion)(nil)).
+			Set("author_id = ?", targetID).
+			Where("author_id = ?", sourceID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error moving promotions: %w", err)
+		}
+
+		target.Bio = mergeBios(target.Bio, source.Bio)
+		_, err = tx.NewUpdate().
+			Model(target).
+			Column("bio", "version").
+			Value("version", "version + 1").
+			WherePK().
+			Returning("version").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error merging bios: %w", err)
+		}
+
+		// Earlier redirects to the source now point straight at the target
+		_, err = tx.NewUpdate().
+			Model((*models.AuthorRedirect)(nil)).
+			Set("author_id = ?", targetID).
+			Where("author_id = ?", sourceID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error updating redirects: %w", err)
+		}
+		redirect := models.AuthorRedirect{OldID: sourceID, AuthorID: targetID}
+		if _, err := tx.NewInsert().Model(&redirect).Exec(ctx); err != nil {
+			return fmt.Errorf("error creating redirect: %w", err)
+		}
+
+		if _, err := tx.NewDelete().Model(source).WherePK().ForceDelete().Exec(ctx); err != nil {
+			return fmt.Errorf("error deleting merged author: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Author{}, err
+	}
+	return r.GetAuthor(targetID)
+}
+
+// mergeBios keeps the target bio and appends the source bio when it adds
+// something new
+func mergeBios(target, source string) string {
+	target, source = strings.TrimSpace(target), strings.TrimSpace(source)
+	switch {
+	case source == "" || strings.Contains(target, source):
+		return target
+	case target == "" || strings.Contains(source, target):
+		return source
+	default:
+		return target + "\n\n" + source
+	}
+}
+
+// RestoreAuthor brings back a soft-deleted author
+func (r *AuthorRepository) RestoreAuthor(id int) (models.Author, error) {
+	result, err := r.db.NewUpdate().
+		Model((*models.Author)(nil)).
+		Set("deleted_at = NULL").
+		Set("version = version + 1").
+		Where("id = ?", id).
+		WhereDeleted().
+		Exec(context.Background())
+	if err != nil {
+		return models.Author{}, fmt.Errorf("error restoring author: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Author{}, NotFoundf("deleted author with ID %d not found", id)
+	}
+	return r.GetAuthor(id)
+}
+
+// PurgeDeletedAuthors permanently removes authors deleted before the given
+// time that are no longer credited on any book or named by a promotion
+func (r *AuthorRepository) PurgeDeletedAuthors(before time.Time) (int, error) {
+	result, err := r.db.NewDelete().
+		Model((*models.Author)(nil)).
+		WhereDeleted().
+		Where("deleted_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM books AS b WHERE b.author_id = author.id)").
+		Where("NOT EXISTS (SELECT 1 FROM book_contributors AS bc WHERE bc.author_id = author.id)").
+		Where("NOT EXISTS (SELECT 1 FROM promotions AS p WHERE p.author_id = author.id)").
+		ForceDelete().
+		Exec(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("error purging deleted authors: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return int(rowsAffected), nil
+}
+
 func (r *AuthorRepository) ListAuthors() ([]models.Author, error) {
 	var authors []models.Author
 	err := r.db.NewSelect().Model(&authors).Scan(context.Background())
@@ -157,6 +345,9 @@ func (r *AuthorRepository) SearchAuthors(criteria models.AuthorCriteriaModel) ([
 	var authors []models.Author
 	query := r.db.NewSelect().Model(&authors)
 
+	if criteria.IncludeDeleted {
+		query = query.WhereAllWithDeleted()
+	}
 	if criteria.FirstName != "" {
 		query = query.Where("LOWER(first_name) LIKE ?", "%"+strings.ToLower(criteria.FirstName)+"%")
 	}
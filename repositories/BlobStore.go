@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// ErrBlobNotFound is returned when no blob is stored under a key
+var ErrBlobNotFound = NotFoundf("blob not found")
+
+// BlobStore keeps binary objects such as cover images under slash-separated
+// keys and knows the public URL each object is served from
+type BlobStore interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// NewBlobStoreFromEnv builds the blob store selected by BLOB_STORE:
+// "local" (default) stores files under BLOB_DIR and serves them from
+// BLOB_BASE_URL; "s3" talks to any S3-compatible endpoint configured with
+// the S3_* variables.
+func NewBlobStoreFromEnv() BlobStore {
+	switch os.Getenv("BLOB_STORE") {
+	case "", "local":
+		dir := envOr("BLOB_DIR", "uploads")
+		baseURL := envOr("BLOB_BASE_URL", "/media")
+		store, err := NewLocalBlobStore(dir, baseURL)
+		if err != nil {
+			log.Fatal("Blob store initialization failed:", err)
+		}
+		return store
+	case "s3":
+		store, err := NewS3BlobStore(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    envOr("S3_REGION", "us-east-1"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+		})
+		if err != nil {
+			log.Fatal("Blob store initialization failed:", err)
+		}
+		return store
+	default:
+		log.Fatal(fmt.Sprintf("Unknown BLOB_STORE %q, expected local or s3", os.Getenv("BLOB_STORE")))
+		return nil
+	}
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
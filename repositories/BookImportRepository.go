@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// ErrAuthorNotFound is returned when an author lookup matches no rows
+var ErrAuthorNotFound = NotFoundf("author not found")
+
+// BookImportStore interface. Every method takes a bun.IDB so the caller
+// decides whether the work runs inside a transaction or directly against the
+// database.
+type BookImportStore interface {
+	DB() bun.IDB
+	RunInTx(ctx context.Context, fn func(ctx context.Context, idb bun.IDB) error) error
+	FindAuthorByName(ctx context.Context, idb bun.IDB, firstName, lastName string) (models.Author, error)
+	ResolveAuthorID(ctx context.Context, idb bun.IDB, id int) (int, error)
+	InsertAuthor(ctx context.Context, idb bun.IDB, author *models.Author) error
+	FindGenre(ctx context.Context, idb bun.IDB, slug string) (models.Genre, error)
+	InsertGenre(ctx context.Context, idb bun.IDB, genre *models.Genre) error
+	InsertBook(ctx context.Context, idb bun.IDB, book *models.Book) error
+	RecordMovement(ctx context.Context, idb bun.IDB, movement models.StockMovement) error
+}
+
+// BookImportRepository is the PostgreSQL-backed implementation of
+// BookImportStore, persisting rows coming from a bulk catalog import
+type BookImportRepository struct {
+	db *bun.DB
+}
+
+// NewBookImportRepository returns a new instance
+func NewBookImportRepository(db *bun.DB) *BookImportRepository {
+	return &BookImportRepository{db: db}
+}
+
+// DB exposes the underlying connection for non-transactional work
+func (r *BookImportRepository) DB() bun.IDB {
+	return r.db
+}
+
+// RunInTx executes fn inside a transaction, rolling back if fn fails
+func (r *BookImportRepository) RunInTx(ctx context.Context, fn func(ctx context.Context, idb bun.IDB) error) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return fn(ctx, tx)
+	})
+}
+
+// FindAuthorByName matches an author on case- and whitespace-insensitive names
+func (r *BookImportRepository) FindAuthorByName(ctx context.Context, idb bun.IDB, firstName, lastName string) (models.Author, error) {
+	var author models.Author
+	err := idb.NewSelect().
+		Model(&author).
+		Where("REGEXP_REPLACE(LOWER(TRIM(first_name)), '\\s+', ' ', 'g') = ?", NormalizeName(firstName)).
+		Where("REGEXP_REPLACE(LOWER(TRIM(last_name)), '\\s+', ' ', 'g') = ?", NormalizeName(lastName)).
+		OrderExpr("id ASC").
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Author{}, ErrAuthorNotFound
+		}
+		return models.Author{}, fmt.Errorf("error looking up author: %w", err)
+	}
+	return author, nil
+}
+
+// ResolveAuthorID returns the ID of the author with the given ID, following
+// the redirect left by a merge, or 0 when there is no such (live) author
+func (r *BookImportRepository) ResolveAuthorID(ctx context.Context, idb bun.IDB, id int) (int, error) {
+	var resolved int
+	err := idb.NewRaw(`SELECT id FROM authors WHERE id = ? AND deleted_at IS NULL
+		UNION ALL SELECT ar.author_id FROM author_redirects AS ar JOIN authors AS a ON a.id = ar.author_id
+		WHERE ar.old_id = ? AND a.deleted_at IS NULL
+		LIMIT 1`, id, id).
+		Scan(ctx, &resolved)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error checking author existence: %w", err)
+	}
+	return resolved, nil
+}
+
+// InsertAuthor creates an author and fills in its generated ID
+func (r *BookImportRepository) InsertAuthor(ctx context.Context, idb bun.IDB, author *models.Author) error {
+	if _, err := idb.NewInsert().Model(author).Returning("*").Exec(ctx); err != nil {
+		return fmt.Errorf("failed to insert author: %w", err)
+	}
+	return nil
+}
+
+// FindGenre matches a genre on its slug or one of its aliases
+func (r *BookImportRepository) FindGenre(ctx context.Context, idb bun.IDB, slug string) (models.Genre, error) {
+	return findGenre(ctx, idb, slug)
+}
+
+// InsertGenre creates a genre and fills in its generated ID
+func (r *BookImportRepository) InsertGenre(ctx context.Context, idb bun.IDB, genre *models.Genre) error {
+	if _, err := idb.NewInsert().Model(genre).Returning("*").Exec(ctx); err != nil {
+		return fmt.Errorf("failed to insert genre: %w", err)
+	}
+	return nil
+}
+
+// InsertBook creates a book with its contributors and fills in its generated ID
+func (r *BookImportRepository) InsertBook(ctx context.Context, idb bun.IDB, book *models.Book) error {
+	if _, err := idb.NewInsert().Model(book).Returning("*").Exec(ctx); err != nil {
+		return fmt.Errorf("error inserting book: %w", err)
+	}
+	return replaceContributors(ctx, idb, book)
+}
+
+// RecordMovement appends a movement to the stock ledger
+func (r *BookImportRepository) RecordMovement(ctx context.Context, idb bun.IDB, movement models.StockMovement) error {
+	return insertMovements(ctx, idb, []models.StockMovement{movement})
+}
+
+// NormalizeName lowercases a name and collapses runs of whitespace
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
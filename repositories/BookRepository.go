@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/uptrace/bun"
 )
@@ -13,15 +14,21 @@ import (
 type BookStore interface {
 	CreateBook(book models.Book) (models.Book, error)
 	GetBook(id int) (models.Book, error)
+	GetBookForUpdate(id int) (models.Book, error)
 	UpdateBook(id int, book models.Book) (models.Book, error)
-	DeleteBook(id int) error
+	DeleteBook(id int, version int) error
 	SearchBooks(criteria models.SearchCriteria) ([]models.Book, error)
 	ListBooks() ([]models.Book, error)
+	SetBookCover(id int, cover models.BookCover) (models.Book, error)
+	RestoreBook(id int) (models.Book, error)
+	PurgeDeletedBooks(before time.Time) (int, error)
+	RunInTx(ctx context.Context, fn func(ctx context.Context, tx bun.IDB) error) error
+	WithTx(tx bun.IDB) BookStore
 }
 
 // PostgreSQL-backed implementation of BookStore
 type BookRepository struct {
-	db *bun.DB
+	db bun.IDB
 }
 
 // NewBookRepository returns a new instance
@@ -29,48 +36,94 @@ func NewBookRepository(db *bun.DB) *BookRepository {
 	return &BookRepository{db: db}
 }
 
-// CreateBook inserts a new book
+// WithTx returns a BookStore that works inside tx
+func (r *BookRepository) WithTx(tx bun.IDB) BookStore {
+	return &BookRepository{db: tx}
+}
+
+// RunInTx executes fn inside a transaction, rolling back if fn fails
+func (r *BookRepository) RunInTx(ctx context.Context, fn func(ctx context.Context, tx bun.IDB) error) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return fn(ctx, tx)
+	})
+}
+
+// CreateBook inserts a new book together with its contributors
 func (r *BookRepository) CreateBook(book models.Book) (models.Book, error) {
-	_, err := r.db.NewInsert().Model(&book).Exec(context.Background())
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(&book).Exec(ctx); err != nil {
+			return fmt.Errorf("error inserting book: %w", err)
+		}
+		return replaceContributors(ctx, tx, &book)
+	})
 	if err != nil {
-		return models.Book{}, fmt.Errorf("error inserting book: %w", err)
+		return models.Book{}, err
 	}
+	// Nobody can have reserved a book that did not exist
+	book.Available = book.Stock
 	return book, nil
 }
 
 // GetBook fetches a book by ID
 func (r *BookRepository) GetBook(id int) (models.Book, error) {
 	var book models.Book
-	err := r.db.NewSelect().Model(&book).Where("book.id = ?", id).Relation("Author").Scan(context.Background())
+	err := withBookRelations(r.db.NewSelect().Model(&book).Where("book.id = ?", id)).Scan(context.Background())
 	if err != nil {
-		return models.Book{}, fmt.Errorf("book not found: %w", err)
+		return models.Book{}, lookupError(err, "book with ID %d not found", id)
+	}
+	return book, nil
+}
+
+// GetBookForUpdate fetches a book like GetBook and locks its row until the
+// transaction the repository works in ends, the way Reserve locks it
+func (r *BookRepository) GetBookForUpdate(id int) (models.Book, error) {
+	var book models.Book
+	query := withBookRelations(r.db.NewSelect().Model(&book).Where("book.id = ?", id))
+	if err := query.For("UPDATE OF ?TableAlias").Scan(context.Background()); err != nil {
+		return models.Book{}, lookupError(err, "book with ID %d not found", id)
 	}
 	return book, nil
 }
 
-// UpdateBook modifies an existing book
+// UpdateBook modifies an existing book. Contributors are only rewritten when
+// book.Contributors is non-nil. book.Version must be the version the caller
+// read; ErrVersionConflict is returned when the book changed since.
 func (r *BookRepository) UpdateBook(id int, book models.Book) (models.Book, error) {
 	book.ID = id
+	expected := book.Version
+	book.Version = expected + 1
 
-	result, err := r.db.NewUpdate().
-		Model(&book).
-		Where("id = ?", id).
-		Returning("*").
-		Exec(context.Background())
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewUpdate().
+			Model(&book).
+			ExcludeColumn("cover_key", "cover_url", "cover_medium_url", "cover_thumbnail_url", "deleted_at").
+			Where("id = ?", id).
+			Where("version = ?", expected).
+			Returning("*").
+			Exec(ctx)
+
+		if err != nil {
+			return fmt.Errorf("error updating book: %w", err)
+		}
 
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return versionMismatch(ctx, tx, (*models.Book)(nil), id, NotFoundf("book with ID %d not found", id))
+		}
+
+		if book.Contributors == nil {
+			return nil
+		}
+		return replaceContributors(ctx, tx, &book)
+	})
 	if err != nil {
-		return models.Book{}, fmt.Errorf("error updating book: %w", err)
+		return models.Book{}, err
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return models.Book{}, fmt.Errorf("book with ID %d not found", id)
-	}
 	var updatedBook models.Book
-	err = r.db.NewSelect().
+	err = withBookRelations(r.db.NewSelect().
 		Model(&updatedBook).
-		Where("?TableAlias.id = ?", id).
-		Relation("Author").
+		Where("?TableAlias.id = ?", id)).
 		Scan(context.Background())
 
 	if err != nil {
@@ -79,17 +132,39 @@ func (r *BookRepository) UpdateBook(id int, book models.Book) (models.Book, erro
 	return updatedBook, nil
 }
 
-// DeleteBook removes a book
-func (r *BookRepository) DeleteBook(id int) error {
+// SetBookCover stores the cover of a book; an empty cover removes it
+func (r *BookRepository) SetBookCover(id int, cover models.BookCover) (models.Book, error) {
+	book := models.Book{ID: id, Cover: cover}
+	result, err := r.db.NewUpdate().
+		Model(&book).
+		Column("cover_key", "cover_url", "cover_medium_url", "cover_thumbnail_url", "version").
+		Value("version", "version + 1").
+		WherePK().
+		Exec(context.Background())
+	if err != nil {
+		return models.Book{}, fmt.Errorf("error updating book cover: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Book{}, NotFoundf("book with ID %d not found", id)
+	}
+	return r.GetBook(id)
+}
+
+// DeleteBook soft-deletes a book at the given version; it stays in past
+// orders and can be restored
+func (r *BookRepository) DeleteBook(id int, version int) error {
 	var book models.Book
 	err := r.db.NewSelect().Model(&book).Where("id = ?", id).Scan(context.Background())
 
 	if err != nil {
-		return fmt.Errorf("book with ID %d not found", id) 
+		return NotFoundf("book with ID %d not found", id) 
 	}
 	result, err := r.db.NewDelete().
 		Model((*models.Book)(nil)).
 		Where("id = ?", id).
+		Where("version = ?", version).
 		Exec(context.Background())
 
 	if err != nil {
@@ -97,7 +172,7 @@ func (r *BookRepository) DeleteBook(id int) error {
 	}
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("book with ID %d not found", id)
+		return versionMismatch(context.Background(), r.db, (*models.Book)(nil), id, NotFoundf("book with ID %d not found", id))
 	}
 
 	return nil
@@ -106,19 +181,33 @@ func (r *BookRepository) DeleteBook(id int) error {
 // SearchBooks filters books by criteria
 func (r *BookRepository) SearchBooks(criteria models.SearchCriteria) ([]models.Book, error) {
 	var books []models.Book
-	query := r.db.NewSelect().Model(&books).Relation("Author")
+	query := withBookRelations(r.db.NewSelect().Model(&books))
 
+	if criteria.IncludeDeleted {
+		query = query.WhereAllWithDeleted()
+	}
 	if criteria.Title != "" {
 		query = query.Where("?TableAlias.title ILIKE ?", "%"+criteria.Title+"%")
 	}
 
-	if criteria.Author != "" {
-		query = query.
-			Join("JOIN authors ON authors.id = ?TableAlias.author_id").
-			Where("LOWER(authors.first_name || ' ' || authors.last_name) LIKE ?", "%"+strings.ToLower(criteria.Author)+"%")
+	if criteria.Author != "" || criteria.Role != "" {
+		sub := r.db.NewSelect().
+			TableExpr("book_contributors AS bc").
+			ColumnExpr("1").
+			Join("JOIN authors AS a ON a.id = bc.author_id").
+			Where("bc.book_id = book.id")
+		if criteria.Author != "" {
+			sub = sub.Where("LOWER(a.first_name || ' ' || a.last_name) LIKE ?", "%"+strings.ToLower(criteria.Author)+"%")
+		}
+		if criteria.Role != "" {
+			sub = sub.Where("bc.role = ?", criteria.Role)
+		}
+		query = query.Where("EXISTS (?)", sub)
 	}
 	if criteria.Genre != "" {
-		query = query.Where("? = ANY(?TableAlias.genres)", criteria.Genre)
+		// A genre matches its aliases and every sub-genre below it
+		slug := models.Slugify(criteria.Genre)
+		query = query.Where("?TableAlias.genres && "+genreSubtreeSQL, slug, slug)
 	}
 	err := query.Scan(context.Background())
 	if err != nil {
@@ -127,12 +216,100 @@ func (r *BookRepository) SearchBooks(criteria models.SearchCriteria) ([]models.B
 	return books, nil
 }
 
+// RestoreBook brings back a soft-deleted book
+func (r *BookRepository) RestoreBook(id int) (models.Book, error) {
+	result, err := r.db.NewUpdate().
+		Model((*models.Book)(nil)).
+		Set("deleted_at = NULL").
+		Set("version = version + 1").
+		Where("id = ?", id).
+		WhereDeleted().
+		Exec(context.Background())
+	if err != nil {
+		return models.Book{}, fmt.Errorf("error restoring book: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Book{}, NotFoundf("deleted book with ID %d not found", id)
+	}
+	return r.GetBook(id)
+}
+
+// PurgeDeletedBooks permanently removes books deleted before the given time.
+// Books that appear in an order, an order's discounts or a purchase order
+// are kept so the order and purchasing history stays intact.
+func (r *BookRepository) PurgeDeletedBooks(before time.Time) (int, error) {
+	result, err := r.db.NewDelete().
+		Model((*models.Book)(nil)).
+		WhereDeleted().
+		Where("deleted_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM order_items AS oi WHERE oi.book_id = book.id)").
+		Where("NOT EXISTS (SELECT 1 FROM order_discounts AS od WHERE od.book_id = book.id)").
+		Where("NOT EXISTS (SELECT 1 FROM purchase_order_lines AS pol WHERE pol.book_id = book.id)").
+		Where("NOT EXISTS (SELECT 1 FROM goods_receipt_lines AS grl WHERE grl.book_id = book.id)").
+		ForceDelete().
+		Exec(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("error purging deleted books: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return int(rowsAffected), nil
+}
+
 // ListBooks fetches all books
 func (r *BookRepository) ListBooks() ([]models.Book, error) {
 	var books []models.Book
-	err := r.db.NewSelect().Model(&books).Relation("Author").Scan(context.Background())
+	err := withBookRelations(r.db.NewSelect().Model(&books)).Scan(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving books: %w", err)
 	}
 	return books, nil
 }
+
+// withBookRelations loads everything shown with a book: its lead author,
+// contributors in credit order, publisher, series and editions, and the
+// stock not held by reservations
+func withBookRelations(q *bun.SelectQuery) *bun.SelectQuery {
+	return q.
+		ColumnExpr("?TableAlias.*").
+		ColumnExpr("?TableAlias.stock - (SELECT COALESCE(SUM(r.quantity), 0) FROM reservations AS r "+
+			"WHERE r.book_id = ?TableAlias.id AND r.expires_at > ?) AS available", time.Now()).
+		Relation("Author").
+		Relation("Contributors", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("position ASC")
+		}).
+		Relation("Contributors.Author").
+		Relation("Publisher").
+		Relation("Series").
+		Relation("Editions", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("id ASC")
+		}).
+		Relation("StockLevels", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("location_id ASC")
+		}).
+		Relation("StockLevels.Location")
+}
+
+// replaceContributors rewrites the contributor rows of a book. A book saved
+// without an explicit list is credited to its AuthorID alone.
+func replaceContributors(ctx context.Context, idb bun.IDB, book *models.Book) error {
+	if _, err := idb.NewDelete().
+		Model((*models.BookContributor)(nil)).
+		Where("book_id = ?", book.ID).
+		Exec(ctx); err != nil {
+		return fmt.Errorf("error clearing book contributors: %w", err)
+	}
+
+	if len(book.Contributors) == 0 {
+		book.Contributors = []models.BookContributor{{AuthorID: book.AuthorID, Role: models.RoleAuthor, Position: 1}}
+	}
+	for i := range book.Contributors {
+		book.Contributors[i].BookID = book.ID
+	}
+
+	if _, err := idb.NewInsert().Model(&book.Contributors).Exec(ctx); err != nil {
+		return fmt.Errorf("error inserting book contributors: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// CouponStore interface
+type CouponStore interface {
+	CreateCoupon(c models.Coupon) (models.Coupon, error)
+	GetCoupon(id int) (models.Coupon, error)
+	FindCoupon(code string) (models.Coupon, error)
+	UpdateCoupon(id int, c models.Coupon) (models.Coupon, error)
+	DeleteCoupon(id int) error
+	ListCoupons() ([]models.Coupon, error)
+	Redeem(couponID, userID int) (models.CouponRedemption, error)
+	AttachRedemption(redemptionID, orderID int) error
+	ListRedemptions(couponID int) ([]models.CouponRedemption, error)
+	CouponUsage(from, to time.Time) ([]models.CouponUsage, error)
+	WithTx(tx bun.IDB) CouponStore
+}
+
+// PostgreSQL-backed implementation of CouponStore
+type CouponRepository struct {
+	db bun.IDB
+}
+
+// NewCouponRepository returns a new instance
+func NewCouponRepository(db *bun.DB) *CouponRepository {
+	return &CouponRepository{db: db}
+}
+
+// WithTx returns a CouponStore that works inside tx
+func (r *CouponRepository) WithTx(tx bun.IDB) CouponStore {
+	return &CouponRepository{db: tx}
+}
+
+// CreateCoupon inserts a new coupon
+func (r *CouponRepository) CreateCoupon(coupon models.Coupon) (models.Coupon, error) {
+	_, err := r.db.NewInsert().Model(&coupon).Returning("*").Exec(context.Background())
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Coupon{}, Conflictf("coupon %q already exists", coupon.Code)
+		}
+		return models.Coupon{}, fmt.Errorf("error inserting coupon: %w", err)
+	}
+	return coupon, nil
+}
+
+// GetCoupon fetches a coupon by ID
+func (r *CouponRepository) GetCoupon(id int) (models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.db.NewSelect().Model(&coupon).Where("id = ?", id).Scan(context.Background())
+	if err != nil {
+		return models.Coupon{}, lookupError(err, "coupon with ID %d not found", id)
+	}
+	return coupon, nil
+}
+
+// FindCoupon fetches a coupon by code
+func (r *CouponRepository) FindCoupon(code string) (models.Coupon, error) {
+	var coupon models.Coupon
+	err := r.db.NewSelect().Model(&coupon).Where("code = ?", code).Scan(context.Background())
+	if err != nil {
+		return models.Coupon{}, lookupError(err, "coupon %q not found", code)
+	}
+	return coupon, nil
+}
+
+// UpdateCoupon modifies an existing coupon
+func (r *CouponRepository) UpdateCoupon(id int, coupon models.Coupon) (models.Coupon, error) {
+	coupon.ID = id
+
+	result, err := r.db.NewUpdate().
+		Model(&coupon).
+		ExcludeColumn("created_at").
+		Where("id = ?", id).
+		Returning("*").
+		Exec(context.Background())
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Coupon{}, Conflictf("coupon %q already exists", coupon.Code)
+		}
+		return models.Coupon{}, fmt.Errorf("error updating coupon: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Coupon{}, NotFoundf("coupon with ID %d not found", id)
+	}
+	return coupon, nil
+}
+
+// DeleteCoupon removes a coupon that was never redeemed
+func (r *CouponRepository) DeleteCoupon(id int) error {
+	ctx := context.Background()
+
+	var inUse bool
+	err := r.db.NewSelect().
+		ColumnExpr("EXISTS (SELECT 1 FROM coupon_redemptions WHERE coupon_id = ?)", id).
+		Scan(ctx, &inUse)
+	if err != nil {
+		return fmt.Errorf("error checking coupon usage: %w", err)
+	}
+	if inUse {
+		return Conflictf("cannot delete coupon with ID %d because it has been redeemed; let it expire instead", id)
+	}
+
+	result, err := r.db.NewDelete().
+		Model((*models.Coupon)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error deleting coupon: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return NotFoundf("coupon with ID %d not found", id)
+	}
+	return nil
+}
+
+// ListCoupons fetches all coupons by code
+func (r *CouponRepository) ListCoupons() ([]models.Coupon, error) {
+	var coupons []models.Coupon
+	if err := r.db.NewSelect().Model(&coupons).Order("code ASC").Scan(context.Background()); err != nil {
+		return nil, fmt.Errorf("error retrieving coupons: %w", err)
+	}
+	return coupons, nil
+}
+
+// Redeem takes one use of a coupon for a user. The coupon row is locked
+// while its redemptions are counted, so concurrent orders cannot go past
+// its limits.
+func (r *CouponRepository) Redeem(couponID, userID int) (models.CouponRedemption, error) {
+	redemption := models.CouponRedemption{CouponID: couponID, UserID: userID}
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		var coupon models.Coupon
+		err := tx.NewSelect().
+			Model(&coupon).
+			Where("id = ?", couponID).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return lookupError(err, "coupon with ID %d not found", couponID)
+		}
+
+		var total, byUser int
+		err = tx.NewSelect().
+			Model((*models.CouponRedemption)(nil)).
+			ColumnExpr("COUNT(*)").
+			ColumnExpr("COUNT(*) FILTER (WHERE user_id = ?)", userID).
+			Where("coupon_id = ?", couponID).
+			Scan(ctx, &total, &byUser)
+		if err != nil {
+			return fmt.Errorf("error counting coupon redemptions: %w", err)
+		}
+		if coupon.MaxRedemptions > 0 && total >= coupon.MaxRedemptions {
+			return Conflictf("coupon %q has been fully redeemed", coupon.Code)
+		}
+		if coupon.PerCustomerLimit > 0 && byUser >= coupon.PerCustomerLimit {
+			return Conflictf("coupon %q can only be used %d times per customer", coupon.Code, coupon.PerCustomerLimit)
+		}
+
+		if _, err := tx.NewInsert().Model(&redemption).Returning("*").Exec(ctx); err != nil {
+			return fmt.Errorf("error inserting coupon redemption: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.CouponRedemption{}, err
+	}
+	return redemption, nil
+}
+
+// AttachRedemption points a redemption at the order that used it
+func (r *CouponRepository) AttachRedemption(redemptionID, orderID int) error {
+	_, err := r.db.NewUpdate().
+		Model((*models.CouponRedemption)(nil)).
+		Set("order_id = ?", orderID).
+		Where("id = ?", redemptionID).
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("error updating coupon redemption: %w", err)
+	}
+	return nil
+}
+
+// ListRedemptions fetches the redemptions of a coupon, newest first
+func (r *CouponRepository) ListRedemptions(couponID int) ([]models.CouponRedemption, error) {
+	var redemptions []models.CouponRedemption
+	err := r.db.NewSelect().
+		Model(&redemptions).
+		Where("coupon_id = ?", couponID).
+		Order("created_at DESC", "id DESC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving coupon redemptions: %w", err)
+	}
+	return redemptions, nil
+}
+
+// CouponUsage sums the redemptions of each coupon on orders placed between
+// from and to, most redeemed first, in each currency they were given in
+func (r *CouponRepository) CouponUsage(from, to time.Time) ([]models.CouponUsage, error) {
+	var usage []models.CouponUsage
+	err := r.db.NewSelect().
+		TableExpr("coupon_redemptions AS cr").
+		ColumnExpr("c.id AS coupon_id, c.code").
+		ColumnExpr("COUNT(*) AS redemptions").
+		ColumnExpr("COUNT(DISTINCT cr.user_id) AS customers").
+		ColumnExpr("COALESCE(SUM(o.coupon_discount_minor), 0) AS amount_minor, o.coupon_discount_currency AS amount_currency").
+		Join("JOIN coupons AS c ON c.id = cr.coupon_id").
+		Join("JOIN orders AS o ON o.id = cr.order_id").
+		Where("o.created_at BETWEEN ? AND ?", from, to).
+		GroupExpr("c.id, c.code, o.coupon_discount_currency").
+		OrderExpr("redemptions DESC, c.code ASC").
+		Scan(context.Background(), &usage)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving coupon usage: %w", err)
+	}
+	return usage, nil
+}
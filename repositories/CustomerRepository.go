@@ -4,6 +4,7 @@ import (
 	"FinalProject/models"
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/uptrace/bun"
 )
@@ -12,8 +13,10 @@ import (
 type CustomerStore interface {
 	GetCustomer(id int) (models.User, error)
 	UpdateCustomer(id int, c models.User) (models.User, error)
-	DeleteCustomer(id int) error
-	ListCustomers() ([]models.User, error)
+	DeleteCustomer(id int, version int) error
+	ListCustomers(includeDeleted bool) ([]models.User, error)
+	RestoreCustomer(id int) (models.User, error)
+	PurgeDeletedCustomers(before time.Time) (int, error)
 }
 
 // PostgreSQL-backed implementation of CustomerStore
@@ -30,48 +33,57 @@ func (r *CustomerRepository) GetCustomer(id int) (models.User, error) {
 	var User models.User
 	err := r.db.NewSelect().Model(&User).Where("id = ?", id).Scan(context.Background())
 	if err != nil {
-		return models.User{}, fmt.Errorf("User not found with ID %d", id)
+		return models.User{}, NotFoundf("User not found with ID %d", id)
 	}
 	return User, nil
 }
 
-// UpdateCustomer modifies an existing User
+// UpdateCustomer modifies an existing User; User.Version must be the version
+// the caller read
 func (r *CustomerRepository) UpdateCustomer(id int, User models.User) (models.User, error) {
 	// Retrieve the existing User to preserve `CreatedAt`
 	var existingCustomer models.User
 	err := r.db.NewSelect().Model(&existingCustomer).Where("id = ?", id).Scan(context.Background())
 	if err != nil {
-		return models.User{}, fmt.Errorf("User with ID %d not found", id)
+		return models.User{}, NotFoundf("User with ID %d not found", id)
 	}
 
 	User.ID = id
 	User.CreatedAt = existingCustomer.CreatedAt
+	expected := User.Version
+	User.Version = expected + 1
 
-	_, err = r.db.NewUpdate().
+	result, err := r.db.NewUpdate().
 		Model(&User).
 		Column("name", "email",
-			"street", "city", "state", "postal_code", "country").
+			"street", "city", "state", "postal_code", "country", "version").
 		Where("id = ?", id).
+		Where("version = ?", expected).
 		Exec(context.Background())
 
 	if err != nil {
 		return models.User{}, fmt.Errorf("error updating User: %w", err)
 	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.User{}, ErrVersionConflict
+	}
 
 	return User, nil
 }
 
-// DeleteCustomer removes a User
-func (r *CustomerRepository) DeleteCustomer(id int) error {
+// DeleteCustomer soft-deletes a User at the given version; their orders are kept
+func (r *CustomerRepository) DeleteCustomer(id int, version int) error {
 	var User models.User
 	err := r.db.NewSelect().Model(&User).Where("id = ?", id).Scan(context.Background())
 	if err != nil {
-		return fmt.Errorf("User with ID %d not found", id)
+		return NotFoundf("User with ID %d not found", id)
 	}
 
 	result, err := r.db.NewDelete().
 		Model((*models.User)(nil)).
 		Where("id = ?", id).
+		Where("version = ?", version).
 		Exec(context.Background())
 
 	if err != nil {
@@ -79,18 +91,59 @@ func (r *CustomerRepository) DeleteCustomer(id int) error {
 	}
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("User with ID %d not found", id)
+		return versionMismatch(context.Background(), r.db, (*models.User)(nil), id, NotFoundf("User with ID %d not found", id))
 	}
 
 	return nil
 }
 
-// ListCustomers fetches all customers
-func (r *CustomerRepository) ListCustomers() ([]models.User, error) {
+// ListCustomers fetches all customers, optionally including deleted ones
+func (r *CustomerRepository) ListCustomers(includeDeleted bool) ([]models.User, error) {
 	var customers []models.User
-	err := r.db.NewSelect().Model(&customers).Scan(context.Background())
+	query := r.db.NewSelect().Model(&customers)
+	if includeDeleted {
+		query = query.WhereAllWithDeleted()
+	}
+	err := query.Scan(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving customers: %w", err)
 	}
 	return customers, nil
 }
+
+// RestoreCustomer brings back a soft-deleted User
+func (r *CustomerRepository) RestoreCustomer(id int) (models.User, error) {
+	result, err := r.db.NewUpdate().
+		Model((*models.User)(nil)).
+		Set("deleted_at = NULL").
+		Set("version = version + 1").
+		Where("id = ?", id).
+		WhereDeleted().
+		Exec(context.Background())
+	if err != nil {
+		return models.User{}, fmt.Errorf("error restoring User: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.User{}, NotFoundf("deleted User with ID %d not found", id)
+	}
+	return r.GetCustomer(id)
+}
+
+// PurgeDeletedCustomers permanently removes customers deleted before the
+// given time. Customers with orders are kept so the order history stays intact.
+func (r *CustomerRepository) PurgeDeletedCustomers(before time.Time) (int, error) {
+	result, err := r.db.NewDelete().
+		Model((*models.User)(nil)).
+		WhereDeleted().
+		Where("deleted_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM orders AS o WHERE o.user_id = \"user\".id)").
+		ForceDelete().
+		Exec(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("error purging deleted customers: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return int(rowsAffected), nil
+}
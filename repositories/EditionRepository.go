@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// EditionStore interface
+type EditionStore interface {
+	CreateEdition(e models.Edition) (models.Edition, error)
+	GetEdition(id int) (models.Edition, error)
+	GetEditionByISBN(isbn string) (models.Edition, error)
+	UpdateEdition(id int, e models.Edition) (models.Edition, error)
+	AdjustEditionStock(id, delta int) (models.Edition, error)
+	DeleteEdition(id int) error
+	ListEditions(bookID int) ([]models.Edition, error)
+	WithTx(tx bun.IDB) EditionStore
+}
+
+// PostgreSQL-backed implementation of EditionStore
+type EditionRepository struct {
+	db bun.IDB
+}
+
+// NewEditionRepository returns a new instance
+func NewEditionRepository(db *bun.DB) *EditionRepository {
+	return &EditionRepository{db: db}
+}
+
+// WithTx returns an EditionStore that works inside tx
+func (r *EditionRepository) WithTx(tx bun.IDB) EditionStore {
+	return &EditionRepository{db: tx}
+}
+
+// CreateEdition inserts a new edition of a book
+func (r *EditionRepository) CreateEdition(edition models.Edition) (models.Edition, error) {
+	_, err := r.db.NewInsert().Model(&edition).Returning("*").Exec(context.Background())
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Edition{}, Conflictf("an edition with ISBN %s already exists", edition.ISBN)
+		}
+		return models.Edition{}, fmt.Errorf("error inserting edition: %w", err)
+	}
+	return edition, nil
+}
+
+// GetEdition fetches an edition by ID
+func (r *EditionRepository) GetEdition(id int) (models.Edition, error) {
+	var edition models.Edition
+	err := r.db.NewSelect().Model(&edition).Where("id = ?", id).Scan(context.Background())
+	if err != nil {
+		return models.Edition{}, NotFoundf("edition with ID %d not found", id)
+	}
+	return edition, nil
+}
+
+// GetEditionByISBN fetches an edition by its normalized ISBN
+func (r *EditionRepository) GetEditionByISBN(isbn string) (models.Edition, error) {
+	var edition models.Edition
+	err := r.db.NewSelect().Model(&edition).Where("isbn = ?", isbn).Scan(context.Background())
+	if err != nil {
+		return models.Edition{}, NotFoundf("edition with ISBN %s not found", isbn)
+	}
+	return edition, nil
+}
+
+// UpdateEdition modifies an existing edition
+func (r *EditionRepository) UpdateEdition(id int, edition models.Edition) (models.Edition, error) {
+	edition.ID = id
+
+	result, err := r.db.NewUpdate().
+		Model(&edition).
+		Where("id = ?", id).
+		Returning("*").
+		Exec(context.Background())
+
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Edition{}, Conflictf("an edition with ISBN %s already exists", edition.ISBN)
+		}
+		return models.Edition{}, fmt.Errorf("error updating edition: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Edition{}, NotFoundf("edition with ID %d not found", id)
+	}
+	return edition, nil
+}
+
+// AdjustEditionStock changes the stock of an edition by delta in a single
+// statement, so two orders cannot both take the last copies and an edit made
+// at the same time is not overwritten. Stock that would fall below zero is
+// a conflict.
+func (r *EditionRepository) AdjustEditionStock(id, delta int) (models.Edition, error) {
+	var edition models.Edition
+	result, err := r.db.NewUpdate().
+		Model(&edition).
+		Set("stock = stock + ?", delta).
+		Where("id = ?", id).
+		Where("stock + ? >= 0", delta).
+		Returning("*").
+		Exec(context.Background())
+	if err != nil {
+		return models.Edition{}, fmt.Errorf("error updating edition stock: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		if _, err := r.GetEdition(id); err != nil {
+			return models.Edition{}, err
+		}
+		return models.Edition{}, Conflictf("insufficient stock for edition ID %d", id)
+	}
+	return edition, nil
+}
+
+// DeleteEdition removes an edition that no order refers to
+func (r *EditionRepository) DeleteEdition(id int) error {
+	ctx := context.Background()
+
+	ordered, err := r.db.NewSelect().
+		Model((*models.OrderItem)(nil)).
+		Where("edition_id = ?", id).
+		Exists(ctx)
+	if err != nil {
+		return fmt.Errorf("error checking edition usage: %w", err)
+	}
+	if ordered {
+		return Conflictf("cannot delete edition with ID %d because it has been ordered", id)
+	}
+
+	result, err := r.db.NewDelete().
+		Model((*models.Edition)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error deleting edition: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return NotFoundf("edition with ID %d not found", id)
+	}
+	return nil
+}
+
+// ListEditions fetches the editions of a book
+func (r *EditionRepository) ListEditions(bookID int) ([]models.Edition, error) {
+	var editions []models.Edition
+	err := r.db.NewSelect().
+		Model(&editions).
+		Where("book_id = ?", bookID).
+		Order("id ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving editions: %w", err)
+	}
+	return editions, nil
+}
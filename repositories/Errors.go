@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Error kinds shared by repositories and services. An error of a kind keeps
+// its own message and matches the kind with errors.Is, which is how the
+// controllers choose the HTTP status and error code of a response.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// kindError is an error of one of the kinds above
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// NotFoundf formats an error of kind ErrNotFound; like fmt.Errorf, %w wraps
+func NotFoundf(format string, args ...any) error {
+	return &kindError{kind: ErrNotFound, err: fmt.Errorf(format, args...)}
+}
+
+// Conflictf formats an error of kind ErrConflict: the request clashes with
+// the current state, e.g. a duplicate or a record still in use
+func Conflictf(format string, args ...any) error {
+	return &kindError{kind: ErrConflict, err: fmt.Errorf(format, args...)}
+}
+
+// Invalidf formats an error of kind ErrValidation: the request breaks a rule
+func Invalidf(format string, args ...any) error {
+	return &kindError{kind: ErrValidation, err: fmt.Errorf(format, args...)}
+}
+
+// Forbiddenf formats an error of kind ErrForbidden
+func Forbiddenf(format string, args ...any) error {
+	return &kindError{kind: ErrForbidden, err: fmt.Errorf(format, args...)}
+}
+
+// Unauthorizedf formats an error of kind ErrUnauthorized
+func Unauthorizedf(format string, args ...any) error {
+	return &kindError{kind: ErrUnauthorized, err: fmt.Errorf(format, args...)}
+}
+
+// lookupError reports a lookup that matched no row as not found and passes
+// any other failure through
+func lookupError(err error, format string, args ...any) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return NotFoundf(format, args...)
+	}
+	return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), err)
+}
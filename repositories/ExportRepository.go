@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// ExportStore interface
+type ExportStore interface {
+	StreamBooks(ctx context.Context, filter models.ExportFilter, fn func(models.ExportRecord) error) error
+	StreamAuthors(ctx context.Context, filter models.ExportFilter, fn func(models.ExportRecord) error) error
+	StreamCustomers(ctx context.Context, filter models.ExportFilter, fn func(models.ExportRecord) error) error
+	StreamOrders(ctx context.Context, filter models.ExportFilter, fn func(models.ExportRecord) error) error
+}
+
+// ExportRepository is the PostgreSQL-backed implementation of ExportStore.
+// It streams flattened rows straight from a database cursor so exports run
+// in constant memory regardless of the table size.
+type ExportRepository struct {
+	db *bun.DB
+}
+
+// NewExportRepository returns a new instance
+func NewExportRepository(db *bun.DB) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+// StreamBooks calls fn for every book matching the SearchBooks filters
+func (r *ExportRepository) StreamBooks(ctx context.Context, filter models.ExportFilter, fn func(models.ExportRecord) error) error {
+	query := r.db.NewSelect().
+		TableExpr("books AS b").
+		ColumnExpr("b.id, b.title, b.author_id").
+		ColumnExpr("a.first_name || ' ' || a.last_name AS author_name").
+		ColumnExpr(`COALESCE((SELECT STRING_AGG(ca.first_name || ' ' || ca.last_name || ' (' || bc.role || ')', '; ' ORDER BY bc.position)
+			FROM book_contributors AS bc JOIN authors AS ca ON ca.id = bc.author_id
+			WHERE bc.book_id = b.id), '') AS contributors`).
+		ColumnExpr("b.genres, b.published_at, b.price_minor, b.price_currency, b.stock").
+		Join("JOIN authors AS a ON a.id = b.author_id").
+		Where("b.deleted_at IS NULL").
+		OrderExpr("b.id ASC")
+
+	if filter.Title != "" {
+		query = query.Where("b.title ILIKE ?", "%"+filter.Title+"%")
+	}
+	if filter.Author != "" {
+		query = query.Where(`EXISTS (SELECT 1 FROM book_contributors AS bc JOIN authors AS ca ON ca.id = bc.author_id
+			WHERE bc.book_id = b.id AND LOWER(ca.first_name || ' ' || ca.last_name) LIKE ?)`, "%"+strings.ToLower(filter.Author)+"%")
+	}
+	if filter.Genre != "" {
+		slug := models.Slugify(filter.Genre)
+		query = query.Where("b.genres && "+genreSubtreeSQL, slug, slug)
+	}
+
+	return streamRows[models.BookExportRow](ctx, r.db, query, "books", fn)
+}
+
+// StreamAuthors calls fn for every author matching the SearchAuthors filters
+func (r *ExportRepository) StreamAuthors(ctx context.Context, filter models.ExportFilter, fn func(models.ExportRecord) error) error {
+	query := r.db.NewSelect().
+		TableExpr("authors AS a").
+		ColumnExpr("a.id, a.first_name, a.last_name, COALESCE(a.bio, '') AS bio").
+		ColumnExpr(`(SELECT COUNT(DISTINCT bc.book_id) FROM book_contributors AS bc JOIN books AS cb ON cb.id = bc.book_id
+			WHERE bc.author_id = a.id AND cb.deleted_at IS NULL) AS book_count`).
+		Where("a.deleted_at IS NULL").
+		OrderExpr("a.id ASC")
+
+	if filter.FirstName != "" {
+		query = query.Where("LOWER(a.first_name) LIKE ?", "%"+strings.ToLower(filter.FirstName)+"%")
+	}
+	if filter.LastName != "" {
+		query = query.Where("LOWER(a.last_name) LIKE ?", "%"+strings.ToLower(filter.LastName)+"%")
+	}
+
+	return streamRows[models.AuthorExportRow](ctx, r.db, query, "authors", fn)
+}
+
+// StreamCustomers calls fn for every customer
+func (r *ExportRepository) StreamCustomers(ctx context.Context, filter models.ExportFilter, fn func(models.ExportRecord) error) error {
+	query := r.db.NewSelect().
+		TableExpr("users AS u").
+		ColumnExpr("u.id, u.name, u.email, u.role").
+		ColumnExpr("COALESCE(u.street, '') AS street, COALESCE(u.city, '') AS city, COALESCE(u.state, '') AS state").
+		ColumnExpr("COALESCE(u.postal_code, '') AS postal_code, COALESCE(u.country, '') AS country, u.created_at").
+		Where("u.deleted_at IS NULL").
+		OrderExpr("u.id ASC")
+
+	return streamRows[models.CustomerExportRow](ctx, r.db, query, "customers", fn)
+}
+
+// StreamOrders calls fn for every order matching the date range and customer filters
+func (r *ExportRepository) StreamOrders(ctx context.Context, filter models.ExportFilter, fn func(models.ExportRecord) error) error {
+	query := r.db.NewSelect().
+		TableExpr("orders AS o").
+		ColumnExpr("o.id, o.user_id, u.name AS customer_name, u.email AS customer_email").
+		ColumnExpr("o.status, o.created_at, o.total_price_minor, o.total_price_currency, o.tax_minor, o.tax_currency").
+		ColumnExpr("COUNT(oi.id) AS item_count, COALESCE(SUM(oi.quantity), 0) AS total_quantity").
+		Join("JOIN users AS u ON u.id = o.user_id").
+		Join("LEFT JOIN order_items AS oi ON oi.order_id = o.id").
+		GroupExpr("o.id, u.id").
+		OrderExpr("o.id ASC")
+
+	if !filter.From.IsZero() && !filter.To.IsZero() {
+		query = query.Where("o.created_at BETWEEN ? AND ?", filter.From, filter.To)
+	}
+	if filter.CustomerID > 0 {
+		query = query.Where("o.user_id = ?", filter.CustomerID)
+	}
+
+	return streamRows[models.OrderExportRow](ctx, r.db, query, "orders", fn)
+}
+
+// streamRows iterates a query's cursor, scanning one row at a time into T
+func streamRows[T models.ExportRecord](ctx context.Context, db *bun.DB, query *bun.SelectQuery, entity string, fn func(models.ExportRecord) error) error {
+	rows, err := query.Rows(ctx)
+	if err != nil {
+		return fmt.Errorf("error querying %s for export: %w", entity, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row T
+		if err := db.ScanRow(ctx, rows, &row); err != nil {
+			return fmt.Errorf("error scanning %s for export: %w", entity, err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error reading %s for export: %w", entity, err)
+	}
+	return nil
+}
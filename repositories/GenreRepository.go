@@ -0,0 +1,254 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+// ErrGenreNotFound is returned when no genre has the given slug or alias
+var ErrGenreNotFound = NotFoundf("genre not found")
+
+// genreSubtreeSQL selects the slugs of the genre matching a slug or alias
+// together with all of its descendants. It takes the slug twice.
+const genreSubtreeSQL = `ARRAY(WITH RECURSIVE subtree AS (
+		SELECT g.id, g.slug FROM genres AS g WHERE g.slug = ? OR ? = ANY(g.aliases)
+		UNION
+		SELECT c.id, c.slug FROM genres AS c JOIN subtree AS s ON c.parent_id = s.id
+	) SELECT slug FROM subtree)::text[]`
+
+// GenreStore interface
+type GenreStore interface {
+	CreateGenre(g models.Genre) (models.Genre, error)
+	GetGenre(id int) (models.Genre, error)
+	UpdateGenre(id int, g models.Genre) (models.Genre, error)
+	DeleteGenre(id int) error
+	ListGenres() ([]models.Genre, error)
+	FindGenre(slug string) (models.Genre, error)
+	MergeGenres(targetID, sourceID int) (models.Genre, error)
+}
+
+// PostgreSQL-backed implementation of GenreStore
+type GenreRepository struct {
+	db *bun.DB
+}
+
+// NewGenreRepository returns a new instance
+func NewGenreRepository(db *bun.DB) *GenreRepository {
+	return &GenreRepository{db: db}
+}
+
+// CreateGenre inserts a new genre
+func (r *GenreRepository) CreateGenre(genre models.Genre) (models.Genre, error) {
+	_, err := r.db.NewInsert().Model(&genre).Returning("*").Exec(context.Background())
+	if err != nil {
+		return models.Genre{}, fmt.Errorf("error inserting genre: %w", err)
+	}
+	return genre, nil
+}
+
+// GetGenre fetches a genre by ID with its parent
+func (r *GenreRepository) GetGenre(id int) (models.Genre, error) {
+	var genre models.Genre
+	err := r.db.NewSelect().
+		Model(&genre).
+		Where("?TableAlias.id = ?", id).
+		Relation("Parent").
+		Scan(context.Background())
+	if err != nil {
+		return models.Genre{}, NotFoundf("genre with ID %d not found", id)
+	}
+	return genre, nil
+}
+
+// UpdateGenre modifies a genre. When its slug changes, books tagged with the
+// old slug are retagged in the same transaction.
+func (r *GenreRepository) UpdateGenre(id int, genre models.Genre) (models.Genre, error) {
+	genre.ID = id
+
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		var oldSlug string
+		err := tx.NewSelect().
+			Model((*models.Genre)(nil)).
+			Column("slug").
+			Where("id = ?", id).
+			For("UPDATE").
+			Scan(ctx, &oldSlug)
+		if err != nil {
+			return NotFoundf("genre with ID %d not found", id)
+		}
+
+		if _, err := tx.NewUpdate().Model(&genre).WherePK().Exec(ctx); err != nil {
+			return fmt.Errorf("error updating genre: %w", err)
+		}
+
+		if oldSlug == genre.Slug {
+			return nil
+		}
+		_, err = tx.NewUpdate().
+			Model((*models.Book)(nil)).
+			Set("genres = ARRAY_REPLACE(genres, ?, ?)", oldSlug, genre.Slug).
+			Set("version = version + 1").
+			Where("? = ANY(genres)", oldSlug).
+			WhereAllWithDeleted().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error retagging books: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Genre{}, err
+	}
+	return r.GetGenre(id)
+}
+
+// DeleteGenre removes a genre that has no sub-genres and tags no book
+func (r *GenreRepository) DeleteGenre(id int) error {
+	genre, err := r.GetGenre(id)
+	if err != nil {
+		return err
+	}
+
+	children, err := r.db.NewSelect().
+		Model((*models.Genre)(nil)).
+		Where("parent_id = ?", id).
+		Count(context.Background())
+	if err != nil {
+		return fmt.Errorf("error checking sub-genres: %w", err)
+	}
+	if children > 0 {
+		return Conflictf("cannot delete genre %s: it has %d sub-genre(s)", genre.Slug, children)
+	}
+
+	books, err := r.db.NewSelect().
+		Model((*models.Book)(nil)).
+		Where("? = ANY(genres)", genre.Slug).
+		WhereAllWithDeleted().
+		Count(context.Background())
+	if err != nil {
+		return fmt.Errorf("error checking tagged books: %w", err)
+	}
+	if books > 0 {
+		return Conflictf("cannot delete genre %s: it is used by %d book(s)", genre.Slug, books)
+	}
+
+	_, err = r.db.NewDelete().
+		Model((*models.Genre)(nil)).
+		Where("id = ?", id).
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("error deleting genre: %w", err)
+	}
+	return nil
+}
+
+// ListGenres fetches every genre ordered by name
+func (r *GenreRepository) ListGenres() ([]models.Genre, error) {
+	var genres []models.Genre
+	err := r.db.NewSelect().Model(&genres).Order("name ASC").Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving genres: %w", err)
+	}
+	return genres, nil
+}
+
+// MergeGenres folds the source genre into the target: books, sub-genres and
+// promotions move to the target, the source slug and aliases become target aliases, and
+// the source genre is deleted
+func (r *GenreRepository) MergeGenres(targetID, sourceID int) (models.Genre, error) {
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		var target, source models.Genre
+		if err := tx.NewSelect().Model(&target).Where("id = ?", targetID).For("UPDATE").Scan(ctx); err != nil {
+			return NotFoundf("genre with ID %d not found", targetID)
+		}
+		if err := tx.NewSelect().Model(&source).Where("id = ?", sourceID).For("UPDATE").Scan(ctx); err != nil {
+			return NotFoundf("genre with ID %d not found", sourceID)
+		}
+
+		// Retag books, dropping the source slug where the target is already present
+		_, err := tx.NewUpdate().
+			Model((*models.Book)(nil)).
+			Set("genres = CASE WHEN ? = ANY(genres) THEN ARRAY_REMOVE(genres, ?) ELSE ARRAY_REPLACE(genres, ?, ?) END",
+				target.Slug, source.Slug, source.Slug, target.Slug).
+			Set("version = version + 1").
+			Where("? = ANY(genres)", source.Slug).
+			WhereAllWithDeleted().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error retagging books: %w", err)
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*models.Genre)(nil)).
+			Set("parent_id = ?", targetID).
+			Where("parent_id = ?", sourceID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error moving sub-genres: %w", err)
+		}
+
+		_, err = tx.NewUpdate().
+			Model((*models.Promotion)(nil)).
+			Set("genre = ?", target.Slug).
+			Where("genre = ?", source.Slug).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error moving promotions: %w", err)
+		}
+
+		if _, err := tx.NewDelete().Model((*models.Genre)(nil)).Where("id = ?", sourceID).Exec(ctx); err != nil {
+			return fmt.Errorf("error deleting merged genre: %w", err)
+		}
+
+		for _, alias := range append([]string{source.Slug}, source.Aliases...) {
+			if alias != target.Slug && !containsAlias(target.Aliases, alias) {
+				target.Aliases = append(target.Aliases, alias)
+			}
+		}
+		_, err = tx.NewUpdate().Model(&target).Column("aliases").WherePK().Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error updating genre aliases: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Genre{}, err
+	}
+	return r.GetGenre(targetID)
+}
+
+func containsAlias(aliases []string, alias string) bool {
+	for _, a := range aliases {
+		if a == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// FindGenre fetches the genre whose slug or one of whose aliases equals slug
+func (r *GenreRepository) FindGenre(slug string) (models.Genre, error) {
+	return findGenre(context.Background(), r.db, slug)
+}
+
+func findGenre(ctx context.Context, idb bun.IDB, slug string) (models.Genre, error) {
+	var genre models.Genre
+	err := idb.NewSelect().
+		Model(&genre).
+		Where("slug = ? OR ? = ANY(aliases)", slug, slug).
+		OrderExpr("slug = ? DESC", slug).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Genre{}, ErrGenreNotFound
+		}
+		return models.Genre{}, fmt.Errorf("error looking up genre: %w", err)
+	}
+	return genre, nil
+}
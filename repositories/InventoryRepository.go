@@ -0,0 +1,312 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// InventoryStore interface
+type InventoryStore interface {
+	CreateLocation(l models.Location) (models.Location, error)
+	GetLocation(id int) (models.Location, error)
+	UpdateLocation(id int, l models.Location) (models.Location, error)
+	DeleteLocation(id int) error
+	ListLocations() ([]models.Location, error)
+	StockLevels(bookID int) ([]models.StockLevel, error)
+	AdjustStock(bookID, locationID, delta int) (models.StockLevel, error)
+	TransferStock(t models.StockTransfer) (models.StockTransfer, error)
+	ListTransfers(bookID int) ([]models.StockTransfer, error)
+	RecordMovements(movements []models.StockMovement) error
+	ListMovements(bookID, limit int) ([]models.StockMovement, error)
+	StockDrift() ([]models.StockDrift, error)
+	LowStock(since time.Time) ([]models.StockAlert, error)
+	RunInTx(ctx context.Context, fn func(ctx context.Context, tx bun.IDB) error) error
+	WithTx(tx bun.IDB) InventoryStore
+}
+
+// PostgreSQL-backed implementation of InventoryStore
+type InventoryRepository struct {
+	db bun.IDB
+}
+
+// NewInventoryRepository returns a new instance
+func NewInventoryRepository(db *bun.DB) *InventoryRepository {
+	return &InventoryRepository{db: db}
+}
+
+// WithTx returns an InventoryStore that works inside tx
+func (r *InventoryRepository) WithTx(tx bun.IDB) InventoryStore {
+	return &InventoryRepository{db: tx}
+}
+
+// RunInTx executes fn inside a transaction, rolling back if fn fails
+func (r *InventoryRepository) RunInTx(ctx context.Context, fn func(ctx context.Context, tx bun.IDB) error) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return fn(ctx, tx)
+	})
+}
+
+// CreateLocation inserts a new location
+func (r *InventoryRepository) CreateLocation(location models.Location) (models.Location, error) {
+	_, err := r.db.NewInsert().Model(&location).Returning("*").Exec(context.Background())
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Location{}, Conflictf("location %q already exists", location.Code)
+		}
+		return models.Location{}, fmt.Errorf("error inserting location: %w", err)
+	}
+	return location, nil
+}
+
+// GetLocation fetches a location by ID
+func (r *InventoryRepository) GetLocation(id int) (models.Location, error) {
+	var location models.Location
+	err := r.db.NewSelect().Model(&location).Where("id = ?", id).Scan(context.Background())
+	if err != nil {
+		return models.Location{}, lookupError(err, "location with ID %d not found", id)
+	}
+	return location, nil
+}
+
+// UpdateLocation modifies an existing location
+func (r *InventoryRepository) UpdateLocation(id int, location models.Location) (models.Location, error) {
+	location.ID = id
+
+	result, err := r.db.NewUpdate().
+		Model(&location).
+		Where("id = ?", id).
+		Returning("*").
+		Exec(context.Background())
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Location{}, Conflictf("location %q already exists", location.Code)
+		}
+		return models.Location{}, fmt.Errorf("error updating location: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Location{}, NotFoundf("location with ID %d not found", id)
+	}
+	return location, nil
+}
+
+// DeleteLocation removes a location that holds no stock. Orders shipped from
+// it keep their items, without the location.
+func (r *InventoryRepository) DeleteLocation(id int) error {
+	return r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		var stocked bool
+		err := tx.NewSelect().
+			ColumnExpr("EXISTS (SELECT 1 FROM stock_levels WHERE location_id = ? AND quantity > 0)", id).
+			Scan(ctx, &stocked)
+		if err != nil {
+			return fmt.Errorf("error checking location stock: %w", err)
+		}
+		if stocked {
+			return Conflictf("cannot delete location with ID %d because it still holds stock; transfer it first", id)
+		}
+
+		if _, err := tx.NewDelete().Model((*models.StockLevel)(nil)).Where("location_id = ?", id).Exec(ctx); err != nil {
+			return fmt.Errorf("error deleting stock levels: %w", err)
+		}
+		result, err := tx.NewDelete().Model((*models.Location)(nil)).Where("id = ?", id).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error deleting location: %w", err)
+		}
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return NotFoundf("location with ID %d not found", id)
+		}
+		return nil
+	})
+}
+
+// ListLocations fetches every location in fulfillment order
+func (r *InventoryRepository) ListLocations() ([]models.Location, error) {
+	var locations []models.Location
+	if err := r.db.NewSelect().Model(&locations).Order("priority ASC", "id ASC").Scan(context.Background()); err != nil {
+		return nil, fmt.Errorf("error retrieving locations: %w", err)
+	}
+	return locations, nil
+}
+
+// StockLevels fetches the stock of a book at every location that has held
+// it, in fulfillment order
+func (r *InventoryRepository) StockLevels(bookID int) ([]models.StockLevel, error) {
+	var levels []models.StockLevel
+	err := r.db.NewSelect().
+		Model(&levels).
+		Relation("Location").
+		Where("stock_level.book_id = ?", bookID).
+		Order("location.priority ASC", "location.id ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving stock levels: %w", err)
+	}
+	return levels, nil
+}
+
+// AdjustStock changes the stock of a book at a location by delta. Stock never
+// goes below zero: taking more than the location holds is a conflict.
+func (r *InventoryRepository) AdjustStock(bookID, locationID, delta int) (models.StockLevel, error) {
+	return adjustStock(context.Background(), r.db, bookID, locationID, delta)
+}
+
+func adjustStock(ctx context.Context, idb bun.IDB, bookID, locationID, delta int) (models.StockLevel, error) {
+	level := models.StockLevel{BookID: bookID, LocationID: locationID, Quantity: delta}
+	if delta >= 0 {
+		_, err := idb.NewInsert().
+			Model(&level).
+			On("CONFLICT (book_id, location_id) DO UPDATE").
+			Set("quantity = stock_level.quantity + EXCLUDED.quantity").
+			Returning("*").
+			Exec(ctx)
+		if err != nil {
+			return models.StockLevel{}, fmt.Errorf("error adding stock: %w", err)
+		}
+		return level, nil
+	}
+
+	result, err := idb.NewUpdate().
+		Model(&level).
+		Set("quantity = quantity + ?", delta).
+		Where("book_id = ? AND location_id = ?", bookID, locationID).
+		Where("quantity >= ?", -delta).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return models.StockLevel{}, fmt.Errorf("error removing stock: %w", err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.StockLevel{}, Conflictf("insufficient stock for book ID %d at location %d", bookID, locationID)
+	}
+	return level, nil
+}
+
+// TransferStock moves stock between two locations and records the transfer
+// in the transfer list and the stock ledger
+func (r *InventoryRepository) TransferStock(transfer models.StockTransfer) (models.StockTransfer, error) {
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := adjustStock(ctx, tx, transfer.BookID, transfer.FromLocationID, -transfer.Quantity); err != nil {
+			return err
+		}
+		if _, err := adjustStock(ctx, tx, transfer.BookID, transfer.ToLocationID, transfer.Quantity); err != nil {
+			return err
+		}
+		if _, err := tx.NewInsert().Model(&transfer).Returning("*").Exec(ctx); err != nil {
+			return fmt.Errorf("error recording transfer: %w", err)
+		}
+		reason := fmt.Sprintf("transfer %d", transfer.ID)
+		return insertMovements(ctx, tx, []models.StockMovement{
+			{BookID: transfer.BookID, LocationID: transfer.FromLocationID, Kind: models.MovementTransfer, Quantity: -transfer.Quantity, Reason: reason, ActorID: transfer.ActorID},
+			{BookID: transfer.BookID, LocationID: transfer.ToLocationID, Kind: models.MovementTransfer, Quantity: transfer.Quantity, Reason: reason, ActorID: transfer.ActorID},
+		})
+	})
+	if err != nil {
+		return models.StockTransfer{}, err
+	}
+	return transfer, nil
+}
+
+// ListTransfers fetches transfers, newest first; bookID 0 lists every book
+func (r *InventoryRepository) ListTransfers(bookID int) ([]models.StockTransfer, error) {
+	var transfers []models.StockTransfer
+	query := r.db.NewSelect().Model(&transfers).Order("created_at DESC", "id DESC")
+	if bookID > 0 {
+		query = query.Where("book_id = ?", bookID)
+	}
+	if err := query.Scan(context.Background()); err != nil {
+		return nil, fmt.Errorf("error retrieving stock transfers: %w", err)
+	}
+	return transfers, nil
+}
+
+// RecordMovements appends movements to the stock ledger
+func (r *InventoryRepository) RecordMovements(movements []models.StockMovement) error {
+	return insertMovements(context.Background(), r.db, movements)
+}
+
+func insertMovements(ctx context.Context, idb bun.IDB, movements []models.StockMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+	if _, err := idb.NewInsert().Model(&movements).Exec(ctx); err != nil {
+		return fmt.Errorf("error recording stock movements: %w", err)
+	}
+	return nil
+}
+
+// ListMovements fetches the ledger of a book and its editions, newest first
+func (r *InventoryRepository) ListMovements(bookID, limit int) ([]models.StockMovement, error) {
+	var movements []models.StockMovement
+	err := r.db.NewSelect().
+		Model(&movements).
+		Where("book_id = ?", bookID).
+		Order("created_at DESC", "id DESC").
+		Limit(limit).
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving stock movements: %w", err)
+	}
+	return movements, nil
+}
+
+// StockDrift compares the stock of every book, stock level and edition with
+// the sum of its ledger and returns those that differ
+func (r *InventoryRepository) StockDrift() ([]models.StockDrift, error) {
+	var drift []models.StockDrift
+	err := r.db.NewRaw(`
+		SELECT b.id AS book_id, 0 AS edition_id, 0 AS location_id,
+			COALESCE(SUM(m.quantity), 0) AS recorded, b.stock AS actual
+		FROM books AS b
+		LEFT JOIN stock_movements AS m ON m.book_id = b.id AND m.edition_id IS NULL
+		GROUP BY b.id
+		HAVING COALESCE(SUM(m.quantity), 0) <> b.stock
+		UNION ALL
+		SELECT l.book_id, 0, l.location_id, COALESCE(SUM(m.quantity), 0), l.quantity
+		FROM stock_levels AS l
+		LEFT JOIN stock_movements AS m
+			ON m.book_id = l.book_id AND m.location_id = l.location_id AND m.edition_id IS NULL
+		GROUP BY l.book_id, l.location_id, l.quantity
+		HAVING COALESCE(SUM(m.quantity), 0) <> l.quantity
+		UNION ALL
+		SELECT e.book_id, e.id, 0, COALESCE(SUM(m.quantity), 0), e.stock
+		FROM editions AS e
+		LEFT JOIN stock_movements AS m ON m.edition_id = e.id
+		GROUP BY e.id
+		HAVING COALESCE(SUM(m.quantity), 0) <> e.stock
+		ORDER BY book_id, edition_id, location_id`).
+		Scan(context.Background(), &drift)
+	if err != nil {
+		return nil, fmt.Errorf("error checking stock drift: %w", err)
+	}
+	return drift, nil
+}
+
+// LowStock fetches the books at or below their reorder threshold, lowest
+// stock first, with the copies they sold since the given time
+func (r *InventoryRepository) LowStock(since time.Time) ([]models.StockAlert, error) {
+	var alerts []models.StockAlert
+	err := r.db.NewRaw(`
+		SELECT b.id AS book_id, b.title, b.stock, b.reorder_threshold AS threshold,
+			COALESCE(-SUM(m.quantity), 0) AS sold
+		FROM books AS b
+		LEFT JOIN stock_movements AS m
+			ON m.book_id = b.id AND m.edition_id IS NULL
+			AND m.kind IN (?, ?) AND m.created_at >= ?
+		WHERE b.deleted_at IS NULL AND b.reorder_threshold > 0 AND b.stock <= b.reorder_threshold
+		GROUP BY b.id
+		ORDER BY b.stock ASC, b.id ASC`,
+		models.MovementSale, models.MovementCancellation, since).
+		Scan(context.Background(), &alerts)
+	if err != nil {
+		return nil, fmt.Errorf("error checking low stock: %w", err)
+	}
+	return alerts, nil
+}
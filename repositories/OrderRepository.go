@@ -14,15 +14,21 @@ type OrderStore interface {
 	CreateOrder(o models.Order) (models.Order, error)
 	GetOrder(id int) (models.Order, error)
 	UpdateOrder(id int, o models.Order) (models.Order, error)
-	DeleteOrder(id int) error
+	DeleteOrder(id int, version int) error
 	ListOrders() ([]models.Order, error)
 	GetOrdersByDateRange(from, to time.Time) ([]models.Order, error)
 	SearchOrdersByUserID(UserID int) ([]models.Order, error)
+	BackorderedItems(bookID int) ([]models.OrderItem, error)
+	BackorderedBookIDs() ([]int, error)
+	FulfillBackorder(itemID int, shipped []models.OrderItem) error
+	RefreshExpectedDates() error
+	RunInTx(ctx context.Context, fn func(ctx context.Context, tx bun.IDB) error) error
+	WithTx(tx bun.IDB) OrderStore
 }
 
 // PostgreSQL-backed implementation of OrderStore
 type OrderRepository struct {
-	db *bun.DB
+	db bun.IDB
 }
 
 // NewOrderRepository returns a new instance
@@ -30,27 +36,50 @@ func NewOrderRepository(db *bun.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
-// CreateOrder inserts a new order
-func (r *OrderRepository) CreateOrder(order models.Order) (models.Order, error) {
-	// Insert Order
-	_, err := r.db.NewInsert().
-		Model(&order).
-		Returning("*").
-		Exec(context.Background())
-	if err != nil {
-		return models.Order{}, fmt.Errorf("error inserting order: %w", err)
-	}
+// WithTx returns an OrderStore that works inside tx
+func (r *OrderRepository) WithTx(tx bun.IDB) OrderStore {
+	return &OrderRepository{db: tx}
+}
+
+// RunInTx executes fn inside a transaction, rolling back if fn fails. On a
+// repository that already works inside one, fn runs in a savepoint of it.
+func (r *OrderRepository) RunInTx(ctx context.Context, fn func(ctx context.Context, tx bun.IDB) error) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return fn(ctx, tx)
+	})
+}
 
-	// Insert Order Items
-	for i := range order.Items {
-		order.Items[i].OrderID = order.ID
-		_, err := r.db.NewInsert().
-			Model(&order.Items[i]).
+// CreateOrder inserts a new order with its items, discounts and taxes in
+// one transaction
+func (r *OrderRepository) CreateOrder(order models.Order) (models.Order, error) {
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		// Insert Order
+		_, err := tx.NewInsert().
+			Model(&order).
 			Returning("*").
-			Exec(context.Background())
+			Exec(ctx)
 		if err != nil {
-			return models.Order{}, fmt.Errorf("error inserting order item: %w", err)
+			return fmt.Errorf("error inserting order: %w", err)
+		}
+
+		// Insert Order Items
+		for i := range order.Items {
+			order.Items[i].OrderID = order.ID
+			_, err := tx.NewInsert().
+				Model(&order.Items[i]).
+				Returning("*").
+				Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("error inserting order item: %w", err)
+			}
 		}
+		if err := insertDiscounts(ctx, tx, order.ID, order.Discounts); err != nil {
+			return err
+		}
+		return insertTaxes(ctx, tx, order.ID, order.Taxes)
+	})
+	if err != nil {
+		return models.Order{}, err
 	}
 
 	return order, nil
@@ -62,13 +91,17 @@ func (r *OrderRepository) GetOrder(id int) (models.Order, error) {
 	err := r.db.NewSelect().
 		Model(&order).
 		Where("?TableAlias.id = ?", id).
-		Relation("User").
-		Relation("Items.Book").
-		Relation("Items.Book.Author").
+		Relation("Items.Edition").
+		Relation("Items.Location").
+		Relation("Discounts").
+		Relation("Taxes").
 		Scan(context.Background())
 
 	if err != nil {
-		return models.Order{}, fmt.Errorf("order not found: %w", err)
+		return models.Order{}, lookupError(err, "order with ID %d not found", id)
+	}
+	if err := r.loadOrderRelations(context.Background(), []*models.Order{&order}); err != nil {
+		return models.Order{}, err
 	}
 	return order, nil
 }
@@ -80,13 +113,18 @@ func (r *OrderRepository) GetOrdersByDateRange(from, to time.Time) ([]models.Ord
 	err := r.db.NewSelect().
 		Model(&orders).
 		Where("?TableAlias.created_at BETWEEN ? AND ?", from, to, from.UTC(), to.UTC()).
-		Relation("User").
-		Relation("Items.Book.Author").
+		Relation("Items.Edition").
+		Relation("Items.Location").
+		Relation("Discounts").
+		Relation("Taxes").
 		Scan(context.Background())
 
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving orders: %w", err)
 	}
+	if err := r.loadOrderRelations(context.Background(), orderPointers(orders)); err != nil {
+		return nil, err
+	}
 	return orders, nil
 }
 
@@ -95,95 +133,161 @@ func (r *OrderRepository) ListOrders() ([]models.Order, error) {
 	var orders []models.Order
 	err := r.db.NewSelect().
 		Model(&orders).
-		Relation("User").
-		Relation("Items.Book").
-		Relation("Items.Book.Author").
+		Relation("Items.Edition").
+		Relation("Items.Location").
+		Relation("Discounts").
+		Relation("Taxes").
 		Scan(context.Background())
 
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving orders: %w", err)
 	}
+	if err := r.loadOrderRelations(context.Background(), orderPointers(orders)); err != nil {
+		return nil, err
+	}
 
 	return orders, nil
 }
 
-// UpdateOrder modifies an existing order
+// UpdateOrder modifies an existing order; order.Version must be the version
+// the caller read. The order row is locked before its lines are rewritten,
+// and the lines and the order are saved in one transaction, so a caller
+// that loses the race changes nothing.
 func (r *OrderRepository) UpdateOrder(id int, order models.Order) (models.Order, error) {
-	var existingOrder models.Order
-	err := r.db.NewSelect().
-		Model(&existingOrder).
-		Where("?TableAlias.id = ?", id).
-		Relation("Items").
-		Scan(context.Background())
-
-	if err != nil {
-		return models.Order{}, fmt.Errorf("order with ID %d not found", id)
-	}
-	// to keep the original created_at and status
-	order.ID = id
-	order.CreatedAt = existingOrder.CreatedAt
-	order.Status = existingOrder.Status
-
-	_, err = r.db.NewDelete().
-		Model((*models.OrderItem)(nil)).
-		Where("order_id = ?", id).
-		Exec(context.Background())
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		var existingOrder models.Order
+		err := tx.NewSelect().
+			Model(&existingOrder).
+			Where("?TableAlias.id = ?", id).
+			For("UPDATE").
+			Scan(ctx)
 
-	if err != nil {
-		return models.Order{}, fmt.Errorf("error clearing previous order items: %w", err)
-	}
+		if err != nil {
+			return lookupError(err, "order with ID %d not found", id)
+		}
+		if existingOrder.Version != order.Version {
+			return ErrVersionConflict
+		}
+		// to keep the original created_at and status
+		order.ID = id
+		order.CreatedAt = existingOrder.CreatedAt
+		order.Status = existingOrder.Status
 
-	totalPrice := 0.0
-	for i := range order.Items {
-		var book models.Book
-		err := r.db.NewSelect().
-			Model(&book).
-			Where("?TableAlias.id = ?", order.Items[i].BookID).
-			Scan(context.Background())
+		_, err = tx.NewDelete().
+			Model((*models.OrderItem)(nil)).
+			Where("order_id = ?", id).
+			Exec(ctx)
 
 		if err != nil {
-			return models.Order{}, fmt.Errorf("book with ID %d not found", order.Items[i].BookID)
+			return fmt.Errorf("error clearing previous order items: %w", err)
 		}
 
-		order.Items[i].Book = &book
-		order.Items[i].Book.PublishedAt = book.PublishedAt
+		totalPrice := models.Money{Currency: order.Currency}
+		for i := range order.Items {
+			var book models.Book
+			err := tx.NewSelect().
+				Model(&book).
+				Where("?TableAlias.id = ?", order.Items[i].BookID).
+				Scan(ctx)
+
+			if err != nil {
+				return Invalidf("book with ID %d not found", order.Items[i].BookID)
+			}
+
+			order.Items[i].Book = &book
+			order.Items[i].Book.PublishedAt = book.PublishedAt
+
+			// An item ordered as a specific edition must be one of the book's
+			if order.Items[i].EditionID > 0 {
+				var edition models.Edition
+				err := tx.NewSelect().
+					Model(&edition).
+					Where("id = ? AND book_id = ?", order.Items[i].EditionID, book.ID).
+					Scan(ctx)
+				if err != nil {
+					return Invalidf("edition with ID %d not found for book ID %d", order.Items[i].EditionID, book.ID)
+				}
+			}
+
+			order.Items[i].OrderID = id
+			_, err = tx.NewInsert().Model(&order.Items[i]).Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("error inserting order item: %w", err)
+			}
+
+			totalPrice = totalPrice.Add(order.Items[i].UnitPrice.Times(order.Items[i].Quantity)).Sub(order.Items[i].Discount)
+		}
 
-		order.Items[i].OrderID = id
-		_, err = r.db.NewInsert().Model(&order.Items[i]).Exec(context.Background())
+		_, err = tx.NewDelete().
+			Model((*models.OrderDiscount)(nil)).
+			Where("order_id = ?", id).
+			Exec(ctx)
 		if err != nil {
-			return models.Order{}, fmt.Errorf("error inserting order item: %w", err)
+			return fmt.Errorf("error clearing previous order discounts: %w", err)
+		}
+		if err := insertDiscounts(ctx, tx, id, order.Discounts); err != nil {
+			return err
 		}
 
-		totalPrice += book.Price * float64(order.Items[i].Quantity)
-	}
-	order.TotalPrice = totalPrice
+		_, err = tx.NewDelete().
+			Model((*models.OrderTax)(nil)).
+			Where("order_id = ?", id).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error clearing previous order taxes: %w", err)
+		}
+		if err := insertTaxes(ctx, tx, id, order.Taxes); err != nil {
+			return err
+		}
+		order.TotalPrice = totalPrice.Sub(order.CouponDiscount)
+		if order.TaxMode != models.TaxInclusive {
+			order.TotalPrice = order.TotalPrice.Add(order.Tax)
+		}
+		expected := order.Version
+		order.Version = expected + 1
 
-	_, err = r.db.NewUpdate().
-		Model(&order).
-		Where("?TableAlias.id = ?", id).
-		Returning("*").
-		Exec(context.Background())
+		result, err := tx.NewUpdate().
+			Model(&order).
+			Where("?TableAlias.id = ?", id).
+			Where("?TableAlias.version = ?", expected).
+			Returning("*").
+			Exec(ctx)
 
+		if err != nil {
+			return fmt.Errorf("error updating order: %w", err)
+		}
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return ErrVersionConflict
+		}
+		return nil
+	})
 	if err != nil {
-		return models.Order{}, fmt.Errorf("error updating order: %w", err)
+		return models.Order{}, err
 	}
 
 	var updatedOrder models.Order
 	err = r.db.NewSelect().
 		Model(&updatedOrder).
 		Where("?TableAlias.id = ?", id).
-		Relation("User").
-		Relation("Items.Book.Author").
+		Relation("Items.Edition").
+		Relation("Items.Location").
+		Relation("Discounts").
+		Relation("Taxes").
 		Scan(context.Background())
 
 	if err != nil {
 		return models.Order{}, fmt.Errorf("error retrieving updated order: %w", err)
 	}
+	if err := r.loadOrderRelations(context.Background(), []*models.Order{&updatedOrder}); err != nil {
+		return models.Order{}, err
+	}
 
 	return updatedOrder, nil
 }
 
-func (r *OrderRepository) DeleteOrder(id int) error {
+// DeleteOrder removes an order at the given version
+func (r *OrderRepository) DeleteOrder(id int, version int) error {
 	var order models.Order
 	err := r.db.NewSelect().
 		Model(&order).
@@ -191,11 +295,12 @@ func (r *OrderRepository) DeleteOrder(id int) error {
 		Scan(context.Background())
 
 	if err != nil {
-		return fmt.Errorf("order with ID %d not found", id)
+		return NotFoundf("order with ID %d not found", id)
 	}
 	result, err := r.db.NewDelete().
 		Model((*models.Order)(nil)).
 		Where("id = ?", id).
+		Where("version = ?", version).
 		Exec(context.Background())
 
 	if err != nil {
@@ -204,7 +309,7 @@ func (r *OrderRepository) DeleteOrder(id int) error {
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("order with ID %d not found", id)
+		return versionMismatch(context.Background(), r.db, (*models.Order)(nil), id, NotFoundf("order with ID %d not found", id))
 	}
 
 	return nil
@@ -217,22 +322,205 @@ func (r *OrderRepository) SearchOrdersByUserID(UserID int) ([]models.Order, erro
 	err := r.db.NewSelect().
 		Model(&User).
 		Where("id = ?", UserID).
+		WhereAllWithDeleted(). // deleted customers keep their order history
 		Scan(context.Background())
 
 	if err != nil {
-		return nil, fmt.Errorf("User with ID %d not found", UserID)
+		return nil, NotFoundf("User with ID %d not found", UserID)
 	}
 
 	err = r.db.NewSelect().
 		Model(&orders).
 		Where("?TableAlias.User_id = ?", UserID).
-		Relation("User").
-		Relation("Items.Book.Author").
+		Relation("Items.Edition").
+		Relation("Items.Location").
+		Relation("Discounts").
+		Relation("Taxes").
 		Scan(context.Background())
 
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving orders for User ID %d: %w", UserID, err)
 	}
+	if err := r.loadOrderRelations(context.Background(), orderPointers(orders)); err != nil {
+		return nil, err
+	}
 
 	return orders, nil
 }
+
+// loadOrderRelations fills in the customer of each order and the book (with
+// its author) of each item. They are loaded apart from the order query so
+// that soft-deleted customers, books and authors still show up in past orders.
+func (r *OrderRepository) loadOrderRelations(ctx context.Context, orders []*models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	var userIDs, bookIDs []int
+	for _, order := range orders {
+		userIDs = append(userIDs, order.UserID)
+		for _, item := range order.Items {
+			bookIDs = append(bookIDs, item.BookID)
+		}
+	}
+
+	var users []models.User
+	err := r.db.NewSelect().
+		Model(&users).
+		Where("id IN (?)", bun.In(userIDs)).
+		WhereAllWithDeleted().
+		Scan(ctx)
+	if err != nil {
+		return fmt.Errorf("error retrieving order customers: %w", err)
+	}
+	usersByID := make(map[int]*models.User, len(users))
+	for i := range users {
+		usersByID[users[i].ID] = &users[i]
+	}
+
+	booksByID := make(map[int]*models.Book)
+	if len(bookIDs) > 0 {
+		var books []models.Book
+		err := r.db.NewSelect().
+			Model(&books).
+			Relation("Author").
+			Where("?TableAlias.id IN (?)", bun.In(bookIDs)).
+			WhereAllWithDeleted().
+			Scan(ctx)
+		if err != nil {
+			return fmt.Errorf("error retrieving order books: %w", err)
+		}
+		for i := range books {
+			booksByID[books[i].ID] = &books[i]
+		}
+	}
+
+	for _, order := range orders {
+		order.User = usersByID[order.UserID]
+		for i := range order.Items {
+			order.Items[i].Book = booksByID[order.Items[i].BookID]
+		}
+	}
+	return nil
+}
+
+// insertDiscounts stores the discounts applied to an order
+func insertDiscounts(ctx context.Context, idb bun.IDB, orderID int, discounts []models.OrderDiscount) error {
+	if len(discounts) == 0 {
+		return nil
+	}
+	for i := range discounts {
+		discounts[i].ID = 0
+		discounts[i].OrderID = orderID
+	}
+	if _, err := idb.NewInsert().Model(&discounts).Returning("*").Exec(ctx); err != nil {
+		return fmt.Errorf("error inserting order discounts: %w", err)
+	}
+	return nil
+}
+
+// insertTaxes stores the tax lines of an order
+func insertTaxes(ctx context.Context, idb bun.IDB, orderID int, taxes []models.OrderTax) error {
+	if len(taxes) == 0 {
+		return nil
+	}
+	for i := range taxes {
+		taxes[i].ID = 0
+		taxes[i].OrderID = orderID
+	}
+	if _, err := idb.NewInsert().Model(&taxes).Returning("*").Exec(ctx); err != nil {
+		return fmt.Errorf("error inserting order taxes: %w", err)
+	}
+	return nil
+}
+
+// orderPointers returns pointers into orders so they can be filled in place
+func orderPointers(orders []models.Order) []*models.Order {
+	pointers := make([]*models.Order, len(orders))
+	for i := range orders {
+		pointers[i] = &orders[i]
+	}
+	return pointers
+}
+
+// BackorderedItems fetches the backordered items of a book, oldest order first
+func (r *OrderRepository) BackorderedItems(bookID int) ([]models.OrderItem, error) {
+	var items []models.OrderItem
+	err := r.db.NewSelect().
+		Model(&items).
+		Join("JOIN orders AS o ON o.id = order_item.order_id").
+		Where("order_item.book_id = ?", bookID).
+		Where("order_item.backordered").
+		Where("order_item.edition_id IS NULL").
+		Order("o.created_at ASC", "order_item.id ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving backordered items: %w", err)
+	}
+	return items, nil
+}
+
+// BackorderedBookIDs fetches the IDs of every book with backordered items
+func (r *OrderRepository) BackorderedBookIDs() ([]int, error) {
+	var ids []int
+	err := r.db.NewSelect().
+		Model((*models.OrderItem)(nil)).
+		ColumnExpr("DISTINCT book_id").
+		Where("backordered").
+		Where("edition_id IS NULL").
+		Order("book_id ASC").
+		Scan(context.Background(), &ids)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving backordered books: %w", err)
+	}
+	return ids, nil
+}
+
+// FulfillBackorder replaces a backordered item with the items shipping its
+// copies and bumps the version of the order
+func (r *OrderRepository) FulfillBackorder(itemID int, shipped []models.OrderItem) error {
+	return r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		var item models.OrderItem
+		err := tx.NewDelete().
+			Model(&item).
+			Where("id = ?", itemID).
+			Where("backordered").
+			Returning("*").
+			Scan(ctx)
+		if err != nil {
+			return lookupError(err, "backordered item with ID %d not found", itemID)
+		}
+
+		for i := range shipped {
+			shipped[i].ID = 0
+			shipped[i].OrderID = item.OrderID
+		}
+		if _, err := tx.NewInsert().Model(&shipped).Exec(ctx); err != nil {
+			return fmt.Errorf("error inserting order items: %w", err)
+		}
+		_, err = tx.NewUpdate().
+			Model((*models.Order)(nil)).
+			Set("version = version + 1").
+			Where("id = ?", item.OrderID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error updating order: %w", err)
+		}
+		return nil
+	})
+}
+
+// RefreshExpectedDates sets the expected date of every backordered item to
+// the release date of its book, or once released to its restock date
+func (r *OrderRepository) RefreshExpectedDates() error {
+	_, err := r.db.NewUpdate().
+		Model((*models.OrderItem)(nil)).
+		TableExpr("books AS b").
+		Set("expected_at = CASE WHEN b.published_at > NOW() THEN b.published_at ELSE b.restock_expected_at END").
+		Where("b.id = order_item.book_id").
+		Where("order_item.backordered").
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("error refreshing expected dates: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// PricingStore interface
+type PricingStore interface {
+	ListExchangeRates() ([]models.ExchangeRate, error)
+	GetExchangeRate(currency string) (models.ExchangeRate, error)
+	SetExchangeRates(rates []models.ExchangeRate) ([]models.ExchangeRate, error)
+	DeleteExchangeRate(currency string) error
+
+	CreatePriceList(list models.PriceList) (models.PriceList, error)
+	GetPriceList(id int) (models.PriceList, error)
+	FindPriceList(currency string) (models.PriceList, error)
+	PriceListForCountry(country string) (models.PriceList, error)
+	UpdatePriceList(id int, list models.PriceList) (models.PriceList, error)
+	DeletePriceList(id int) error
+	ListPriceLists() ([]models.PriceList, error)
+
+	SetBookPrice(price models.BookPrice) (models.BookPrice, error)
+	DeleteBookPrice(priceListID, bookID int) error
+	ListBookPrices(priceListID int) ([]models.BookPrice, error)
+	BookPrices(bookIDs []int, priceListID int) (map[int]models.Money, error)
+}
+
+// PostgreSQL-backed implementation of PricingStore
+type PricingRepository struct {
+	db *bun.DB
+}
+
+// NewPricingRepository returns a new instance
+func NewPricingRepository(db *bun.DB) *PricingRepository {
+	return &PricingRepository{db: db}
+}
+
+// ListExchangeRates fetches every exchange rate by currency
+func (r *PricingRepository) ListExchangeRates() ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	if err := r.db.NewSelect().Model(&rates).Order("currency ASC").Scan(context.Background()); err != nil {
+		return nil, fmt.Errorf("error retrieving exchange rates: %w", err)
+	}
+	return rates, nil
+}
+
+// GetExchangeRate fetches the exchange rate of a currency
+func (r *PricingRepository) GetExchangeRate(currency string) (models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	err := r.db.NewSelect().Model(&rate).Where("currency = ?", currency).Scan(context.Background())
+	if err != nil {
+		return models.ExchangeRate{}, lookupError(err, "no exchange rate for %s", currency)
+	}
+	return rate, nil
+}
+
+// SetExchangeRates adds or replaces exchange rates, all or none of them
+func (r *PricingRepository) SetExchangeRates(rates []models.ExchangeRate) ([]models.ExchangeRate, error) {
+	now := time.Now()
+	for i := range rates {
+		rates[i].UpdatedAt = now
+	}
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().
+			Model(&rates).
+			On("CONFLICT (currency) DO UPDATE").
+			Set("rate = EXCLUDED.rate").
+			Set("updated_at = EXCLUDED.updated_at").
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error saving exchange rates: %w", err)
+	}
+	return rates, nil
+}
+
+// DeleteExchangeRate removes the exchange rate of a currency
+func (r *PricingRepository) DeleteExchangeRate(currency string) error {
+	result, err := r.db.NewDelete().
+		Model((*models.ExchangeRate)(nil)).
+		Where("currency = ?", currency).
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("error deleting exchange rate: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return NotFoundf("no exchange rate for %s", currency)
+	}
+	return nil
+}
+
+// CreatePriceList inserts a new price list
+func (r *PricingRepository) CreatePriceList(list models.PriceList) (models.PriceList, error) {
+	_, err := r.db.NewInsert().Model(&list).Returning("*").Exec(context.Background())
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.PriceList{}, Conflictf("a price list in %s already exists", list.Currency)
+		}
+		return models.PriceList{}, fmt.Errorf("error inserting price list: %w", err)
+	}
+	return list, nil
+}
+
+// GetPriceList fetches a price list by ID
+func (r *PricingRepository) GetPriceList(id int) (models.PriceList, error) {
+	var list models.PriceList
+	err := r.db.NewSelect().Model(&list).Where("id = ?", id).Scan(context.Background())
+	if err != nil {
+		return models.PriceList{}, lookupError(err, "price list with ID %d not found", id)
+	}
+	return list, nil
+}
+
+// FindPriceList fetches the price list of a currency
+func (r *PricingRepository) FindPriceList(currency string) (models.PriceList, error) {
+	var list models.PriceList
+	err := r.db.NewSelect().Model(&list).Where("currency = ?", currency).Scan(context.Background())
+	if err != nil {
+		return models.PriceList{}, lookupError(err, "no price list in %s", currency)
+	}
+	return list, nil
+}
+
+// PriceListForCountry fetches the oldest price list naming a country,
+// ignoring case
+func (r *PricingRepository) PriceListForCountry(country string) (models.PriceList, error) {
+	var list models.PriceList
+	err := r.db.NewSelect().
+		Model(&list).
+		Where("EXISTS (SELECT 1 FROM UNNEST(countries) AS c WHERE LOWER(c) = LOWER(?))", country).
+		Order("id ASC").
+		Limit(1).
+		Scan(context.Background())
+	if err != nil {
+		return models.PriceList{}, lookupError(err, "no price list for %s", country)
+	}
+	return list, nil
+}
+
+// UpdatePriceList modifies an existing price list
+func (r *PricingRepository) UpdatePriceList(id int, list models.PriceList) (models.PriceList, error) {
+	list.ID = id
+
+	result, err := r.db.NewUpdate().
+		Model(&list).
+		ExcludeColumn("created_at").
+		Where("id = ?", id).
+		Returning("*").
+		Exec(context.Background())
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.PriceList{}, Conflictf("a price list in %s already exists", list.Currency)
+		}
+		return models.PriceList{}, fmt.Errorf("error updating price list: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.PriceList{}, NotFoundf("price list with ID %d not found", id)
+	}
+	return list, nil
+}
+
+// DeletePriceList removes a price list and its book prices
+func (r *PricingRepository) DeletePriceList(id int) error {
+	return r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().
+			Model((*models.BookPrice)(nil)).
+			Where("price_list_id = ?", id).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error deleting book prices: %w", err)
+		}
+
+		result, err := tx.NewDelete().
+			Model((*models.PriceList)(nil)).
+			Where("id = ?", id).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error deleting price list: %w", err)
+		}
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return NotFoundf("price list with ID %d not found", id)
+		}
+		return nil
+	})
+}
+
+// ListPriceLists fetches all price lists by currency
+func (r *PricingRepository) ListPriceLists() ([]models.PriceList, error) {
+	var lists []models.PriceList
+	if err := r.db.NewSelect().Model(&lists).Order("currency ASC").Scan(context.Background()); err != nil {
+		return nil, fmt.Errorf("error retrieving price lists: %w", err)
+	}
+	return lists, nil
+}
+
+// SetBookPrice adds or replaces the price of a book in a price list
+func (r *PricingRepository) SetBookPrice(price models.BookPrice) (models.BookPrice, error) {
+	price.UpdatedAt = time.Now()
+	_, err := r.db.NewInsert().
+		Model(&price).
+		On("CONFLICT (price_list_id, book_id) DO UPDATE").
+		Set("price_minor = EXCLUDED.price_minor").
+		Set("price_currency = EXCLUDED.price_currency").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(context.Background())
+	if err != nil {
+		return models.BookPrice{}, fmt.Errorf("error saving book price: %w", err)
+	}
+	return price, nil
+}
+
+// DeleteBookPrice removes the price of a book from a price list
+func (r *PricingRepository) DeleteBookPrice(priceListID, bookID int) error {
+	result, err := r.db.NewDelete().
+		Model((*models.BookPrice)(nil)).
+		Where("price_list_id = ? AND book_id = ?", priceListID, bookID).
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("error deleting book price: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return NotFoundf("price list with ID %d has no price for book ID %d", priceListID, bookID)
+	}
+	return nil
+}
+
+// ListBookPrices fetches the book prices of a price list by book
+func (r *PricingRepository) ListBookPrices(priceListID int) ([]models.BookPrice, error) {
+	var prices []models.BookPrice
+	err := r.db.NewSelect().
+		Model(&prices).
+		Where("price_list_id = ?", priceListID).
+		Order("book_id ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving book prices: %w", err)
+	}
+	return prices, nil
+}
+
+// BookPrices maps the books of bookIDs that a price list has a price for to
+// that price
+func (r *PricingRepository) BookPrices(bookIDs []int, priceListID int) (map[int]models.Money, error) {
+	prices := make(map[int]models.Money)
+	if len(bookIDs) == 0 {
+		return prices, nil
+	}
+	var rows []models.BookPrice
+	err := r.db.NewSelect().
+		Model(&rows).
+		Where("price_list_id = ?", priceListID).
+		Where("book_id IN (?)", bun.In(bookIDs)).
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving book prices: %w", err)
+	}
+	for _, row := range rows {
+		prices[row.BookID] = row.Price
+	}
+	return prices, nil
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// PromotionStore interface
+type PromotionStore interface {
+	CreatePromotion(p models.Promotion) (models.Promotion, error)
+	GetPromotion(id int) (models.Promotion, error)
+	UpdatePromotion(id int, p models.Promotion) (models.Promotion, error)
+	DeletePromotion(id int) error
+	ListPromotions(activeAt time.Time) ([]models.Promotion, error)
+	PromotionUsage(from, to time.Time) ([]models.PromotionUsage, error)
+}
+
+// PostgreSQL-backed implementation of PromotionStore
+type PromotionRepository struct {
+	db *bun.DB
+}
+
+// NewPromotionRepository returns a new instance
+func NewPromotionRepository(db *bun.DB) *PromotionRepository {
+	return &PromotionRepository{db: db}
+}
+
+// CreatePromotion inserts a new promotion
+func (r *PromotionRepository) CreatePromotion(promotion models.Promotion) (models.Promotion, error) {
+	_, err := r.db.NewInsert().Model(&promotion).Returning("*").Exec(context.Background())
+	if err != nil {
+		return models.Promotion{}, fmt.Errorf("error inserting promotion: %w", err)
+	}
+	return promotion, nil
+}
+
+// GetPromotion fetches a promotion by ID
+func (r *PromotionRepository) GetPromotion(id int) (models.Promotion, error) {
+	var promotion models.Promotion
+	err := r.db.NewSelect().Model(&promotion).Where("id = ?", id).Scan(context.Background())
+	if err != nil {
+		return models.Promotion{}, lookupError(err, "promotion with ID %d not found", id)
+	}
+	return promotion, nil
+}
+
+// UpdatePromotion modifies an existing promotion
+func (r *PromotionRepository) UpdatePromotion(id int, promotion models.Promotion) (models.Promotion, error) {
+	promotion.ID = id
+
+	result, err := r.db.NewUpdate().
+		Model(&promotion).
+		ExcludeColumn("created_at").
+		Where("id = ?", id).
+		Returning("*").
+		Exec(context.Background())
+	if err != nil {
+		return models.Promotion{}, fmt.Errorf("error updating promotion: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Promotion{}, NotFoundf("promotion with ID %d not found", id)
+	}
+	return promotion, nil
+}
+
+// DeletePromotion removes a promotion. Orders keep the discounts it gave.
+func (r *PromotionRepository) DeletePromotion(id int) error {
+	result, err := r.db.NewDelete().
+		Model((*models.Promotion)(nil)).
+		Where("id = ?", id).
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("error deleting promotion: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return NotFoundf("promotion with ID %d not found", id)
+	}
+	return nil
+}
+
+// ListPromotions fetches the promotions running at activeAt, or every
+// promotion when activeAt is zero
+func (r *PromotionRepository) ListPromotions(activeAt time.Time) ([]models.Promotion, error) {
+	var promotions []models.Promotion
+	query := r.db.NewSelect().Model(&promotions).Order("id ASC")
+	if !activeAt.IsZero() {
+		query = query.
+			Where("starts_at IS NULL OR starts_at <= ?", activeAt).
+			Where("ends_at IS NULL OR ends_at > ?", activeAt)
+	}
+	if err := query.Scan(context.Background()); err != nil {
+		return nil, fmt.Errorf("error retrieving promotions: %w", err)
+	}
+	return promotions, nil
+}
+
+// PromotionUsage sums the discounts each promotion gave on orders placed
+// between from and to, most generous first, in each currency they were
+// given in
+func (r *PromotionRepository) PromotionUsage(from, to time.Time) ([]models.PromotionUsage, error) {
+	var usage []models.PromotionUsage
+	err := r.db.NewSelect().
+		TableExpr("order_discounts AS d").
+		ColumnExpr("COALESCE(d.promotion_id, 0) AS promotion_id").
+		ColumnExpr("d.name").
+		ColumnExpr("COUNT(DISTINCT d.order_id) AS orders").
+		ColumnExpr("SUM(d.amount_minor) AS amount_minor, d.amount_currency").
+		Join("JOIN orders AS o ON o.id = d.order_id").
+		Where("o.created_at BETWEEN ? AND ?", from, to).
+		GroupExpr("d.promotion_id, d.name, d.amount_currency").
+		OrderExpr("amount_minor DESC").
+		Scan(context.Background(), &usage)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving promotion usage: %w", err)
+	}
+	return usage, nil
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// PublisherStore interface
+type PublisherStore interface {
+	CreatePublisher(p models.Publisher) (models.Publisher, error)
+	GetPublisher(id int) (models.Publisher, error)
+	UpdatePublisher(id int, p models.Publisher) (models.Publisher, error)
+	DeletePublisher(id int) error
+	ListPublishers(name string) ([]models.Publisher, error)
+}
+
+// PostgreSQL-backed implementation of PublisherStore
+type PublisherRepository struct {
+	db *bun.DB
+}
+
+// NewPublisherRepository returns a new instance
+func NewPublisherRepository(db *bun.DB) *PublisherRepository {
+	return &PublisherRepository{db: db}
+}
+
+// CreatePublisher inserts a new publisher
+func (r *PublisherRepository) CreatePublisher(publisher models.Publisher) (models.Publisher, error) {
+	_, err := r.db.NewInsert().Model(&publisher).Returning("*").Exec(context.Background())
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Publisher{}, Conflictf("publisher %q already exists", publisher.Name)
+		}
+		return models.Publisher{}, fmt.Errorf("error inserting publisher: %w", err)
+	}
+	return publisher, nil
+}
+
+// GetPublisher fetches a publisher by ID
+func (r *PublisherRepository) GetPublisher(id int) (models.Publisher, error) {
+	var publisher models.Publisher
+	err := r.db.NewSelect().Model(&publisher).Where("id = ?", id).Scan(context.Background())
+	if err != nil {
+		return models.Publisher{}, NotFoundf("publisher with ID %d not found", id)
+	}
+	return publisher, nil
+}
+
+// UpdatePublisher modifies an existing publisher
+func (r *PublisherRepository) UpdatePublisher(id int, publisher models.Publisher) (models.Publisher, error) {
+	publisher.ID = id
+
+	result, err := r.db.NewUpdate().
+		Model(&publisher).
+		Where("id = ?", id).
+		Returning("*").
+		Exec(context.Background())
+
+	if err != nil {
+		return models.Publisher{}, fmt.Errorf("error updating publisher: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Publisher{}, NotFoundf("publisher with ID %d not found", id)
+	}
+	return publisher, nil
+}
+
+// DeletePublisher removes a publisher that no book or series refers to
+func (r *PublisherRepository) DeletePublisher(id int) error {
+	ctx := context.Background()
+
+	var inUse bool
+	err := r.db.NewSelect().
+		ColumnExpr("EXISTS (SELECT 1 FROM books WHERE publisher_id = ?) OR EXISTS (SELECT 1 FROM series WHERE publisher_id = ?)", id, id).
+		Scan(ctx, &inUse)
+	if err != nil {
+		return fmt.Errorf("error checking publisher usage: %w", err)
+	}
+	if inUse {
+		return Conflictf("cannot delete publisher with ID %d because books or series refer to it", id)
+	}
+
+	result, err := r.db.NewDelete().
+		Model((*models.Publisher)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error deleting publisher: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return NotFoundf("publisher with ID %d not found", id)
+	}
+	return nil
+}
+
+// ListPublishers fetches all publishers, optionally filtered by name
+func (r *PublisherRepository) ListPublishers(name string) ([]models.Publisher, error) {
+	var publishers []models.Publisher
+	query := r.db.NewSelect().Model(&publishers).Order("name ASC")
+	if name != "" {
+		query = query.Where("name ILIKE ?", "%"+name+"%")
+	}
+	if err := query.Scan(context.Background()); err != nil {
+		return nil, fmt.Errorf("error retrieving publishers: %w", err)
+	}
+	return publishers, nil
+}
@@ -0,0 +1,355 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// PurchasingStore interface
+type PurchasingStore interface {
+	CreateSupplier(s models.Supplier) (models.Supplier, error)
+	GetSupplier(id int) (models.Supplier, error)
+	UpdateSupplier(id int, s models.Supplier) (models.Supplier, error)
+	DeleteSupplier(id int) error
+	ListSuppliers(name string) ([]models.Supplier, error)
+	CreatePurchaseOrder(po models.PurchaseOrder) (models.PurchaseOrder, error)
+	GetPurchaseOrder(id int) (models.PurchaseOrder, error)
+	UpdatePurchaseOrder(id int, po models.PurchaseOrder) (models.PurchaseOrder, error)
+	DeletePurchaseOrder(id int) error
+	ListPurchaseOrders(status string, supplierID int) ([]models.PurchaseOrder, error)
+	SendPurchaseOrder(id int) error
+	ReceiveGoods(receipt models.GoodsReceipt) (models.GoodsReceipt, error)
+	ListReceipts(purchaseOrderID int) ([]models.GoodsReceipt, error)
+}
+
+// PostgreSQL-backed implementation of PurchasingStore
+type PurchasingRepository struct {
+	db *bun.DB
+}
+
+// NewPurchasingRepository returns a new instance
+func NewPurchasingRepository(db *bun.DB) *PurchasingRepository {
+	return &PurchasingRepository{db: db}
+}
+
+// CreateSupplier inserts a new supplier
+func (r *PurchasingRepository) CreateSupplier(supplier models.Supplier) (models.Supplier, error) {
+	_, err := r.db.NewInsert().Model(&supplier).Returning("*").Exec(context.Background())
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Supplier{}, Conflictf("supplier %q already exists", supplier.Name)
+		}
+		return models.Supplier{}, fmt.Errorf("error inserting supplier: %w", err)
+	}
+	return supplier, nil
+}
+
+// GetSupplier fetches a supplier by ID
+func (r *PurchasingRepository) GetSupplier(id int) (models.Supplier, error) {
+	var supplier models.Supplier
+	err := r.db.NewSelect().Model(&supplier).Where("id = ?", id).Scan(context.Background())
+	if err != nil {
+		return models.Supplier{}, lookupError(err, "supplier with ID %d not found", id)
+	}
+	return supplier, nil
+}
+
+// UpdateSupplier modifies an existing supplier
+func (r *PurchasingRepository) UpdateSupplier(id int, supplier models.Supplier) (models.Supplier, error) {
+	supplier.ID = id
+
+	result, err := r.db.NewUpdate().
+		Model(&supplier).
+		Where("id = ?", id).
+		Returning("*").
+		Exec(context.Background())
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.Supplier{}, Conflictf("supplier %q already exists", supplier.Name)
+		}
+		return models.Supplier{}, fmt.Errorf("error updating supplier: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Supplier{}, NotFoundf("supplier with ID %d not found", id)
+	}
+	return supplier, nil
+}
+
+// DeleteSupplier removes a supplier no purchase order refers to
+func (r *PurchasingRepository) DeleteSupplier(id int) error {
+	ctx := context.Background()
+
+	var inUse bool
+	err := r.db.NewSelect().
+		ColumnExpr("EXISTS (SELECT 1 FROM purchase_orders WHERE supplier_id = ?)", id).
+		Scan(ctx, &inUse)
+	if err != nil {
+		return fmt.Errorf("error checking supplier usage: %w", err)
+	}
+	if inUse {
+		return Conflictf("cannot delete supplier with ID %d because purchase orders refer to it", id)
+	}
+
+	result, err := r.db.NewDelete().
+		Model((*models.Supplier)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("error deleting supplier: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return NotFoundf("supplier with ID %d not found", id)
+	}
+	return nil
+}
+
+// ListSuppliers fetches all suppliers, optionally filtered by name
+func (r *PurchasingRepository) ListSuppliers(name string) ([]models.Supplier, error) {
+	var suppliers []models.Supplier
+	query := r.db.NewSelect().Model(&suppliers).Order("name ASC")
+	if name != "" {
+		query = query.Where("name ILIKE ?", "%"+name+"%")
+	}
+	if err := query.Scan(context.Background()); err != nil {
+		return nil, fmt.Errorf("error retrieving suppliers: %w", err)
+	}
+	return suppliers, nil
+}
+
+// CreatePurchaseOrder inserts a purchase order with its lines
+func (r *PurchasingRepository) CreatePurchaseOrder(po models.PurchaseOrder) (models.PurchaseOrder, error) {
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(&po).Returning("*").Exec(ctx); err != nil {
+			return fmt.Errorf("error inserting purchase order: %w", err)
+		}
+		return insertPurchaseOrderLines(ctx, tx, &po)
+	})
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	return r.GetPurchaseOrder(po.ID)
+}
+
+func insertPurchaseOrderLines(ctx context.Context, tx bun.Tx, po *models.PurchaseOrder) error {
+	for i := range po.Lines {
+		po.Lines[i].ID = 0
+		po.Lines[i].PurchaseOrderID = po.ID
+	}
+	if len(po.Lines) == 0 {
+		return nil
+	}
+	if _, err := tx.NewInsert().Model(&po.Lines).Returning("*").Exec(ctx); err != nil {
+		return fmt.Errorf("error inserting purchase order lines: %w", err)
+	}
+	return nil
+}
+
+// GetPurchaseOrder fetches a purchase order with its supplier and lines
+func (r *PurchasingRepository) GetPurchaseOrder(id int) (models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	err := r.db.NewSelect().
+		Model(&po).
+		Relation("Supplier").
+		Relation("Lines", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("id ASC")
+		}).
+		Relation("Lines.Book").
+		Where("?TableAlias.id = ?", id).
+		Scan(context.Background())
+	if err != nil {
+		return models.PurchaseOrder{}, lookupError(err, "purchase order with ID %d not found", id)
+	}
+	return po, nil
+}
+
+// UpdatePurchaseOrder replaces the supplier and lines of a draft purchase order
+func (r *PurchasingRepository) UpdatePurchaseOrder(id int, po models.PurchaseOrder) (models.PurchaseOrder, error) {
+	po.ID = id
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewUpdate().
+			Model(&po).
+			Column("supplier_id").
+			Where("id = ?", id).
+			Where("status = ?", models.PurchaseOrderDraft).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error updating purchase order: %w", err)
+		}
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return notDraft(ctx, tx, id)
+		}
+
+		if _, err := tx.NewDelete().Model((*models.PurchaseOrderLine)(nil)).Where("purchase_order_id = ?", id).Exec(ctx); err != nil {
+			return fmt.Errorf("error replacing purchase order lines: %w", err)
+		}
+		return insertPurchaseOrderLines(ctx, tx, &po)
+	})
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	return r.GetPurchaseOrder(id)
+}
+
+// DeletePurchaseOrder removes a draft purchase order
+func (r *PurchasingRepository) DeletePurchaseOrder(id int) error {
+	return r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewDelete().
+			Model((*models.PurchaseOrder)(nil)).
+			Where("id = ?", id).
+			Where("status = ?", models.PurchaseOrderDraft).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error deleting purchase order: %w", err)
+		}
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return notDraft(ctx, tx, id)
+		}
+		return nil
+	})
+}
+
+// notDraft explains why a change to a draft purchase order matched no row
+func notDraft(ctx context.Context, idb bun.IDB, id int) error {
+	var status string
+	err := idb.NewSelect().Model((*models.PurchaseOrder)(nil)).Column("status").Where("id = ?", id).Scan(ctx, &status)
+	if err != nil {
+		return lookupError(err, "purchase order with ID %d not found", id)
+	}
+	return Conflictf("purchase order with ID %d is %s; only drafts can be changed", id, status)
+}
+
+// ListPurchaseOrders fetches purchase orders, newest first, optionally
+// filtered by status and supplier (0 for any)
+func (r *PurchasingRepository) ListPurchaseOrders(status string, supplierID int) ([]models.PurchaseOrder, error) {
+	var pos []models.PurchaseOrder
+	query := r.db.NewSelect().
+		Model(&pos).
+		Relation("Supplier").
+		Relation("Lines", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Order("id ASC")
+		}).
+		Order("purchase_order.created_at DESC", "purchase_order.id DESC")
+	if status != "" {
+		query = query.Where("purchase_order.status = ?", status)
+	}
+	if supplierID > 0 {
+		query = query.Where("purchase_order.supplier_id = ?", supplierID)
+	}
+	if err := query.Scan(context.Background()); err != nil {
+		return nil, fmt.Errorf("error retrieving purchase orders: %w", err)
+	}
+	return pos, nil
+}
+
+// SendPurchaseOrder marks a draft purchase order as sent to its supplier
+func (r *PurchasingRepository) SendPurchaseOrder(id int) error {
+	return r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		result, err := tx.NewUpdate().
+			Model((*models.PurchaseOrder)(nil)).
+			Set("status = ?", models.PurchaseOrderSent).
+			Set("sent_at = ?", time.Now()).
+			Where("id = ?", id).
+			Where("status = ?", models.PurchaseOrderDraft).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error sending purchase order: %w", err)
+		}
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return notDraft(ctx, tx, id)
+		}
+		return nil
+	})
+}
+
+// ReceiveGoods records a goods receipt, adds its quantities to the received
+// quantities of the purchase order lines and moves the purchase order to
+// partially received or, once every line is complete, received. A line can
+// never receive more than was ordered.
+func (r *PurchasingRepository) ReceiveGoods(receipt models.GoodsReceipt) (models.GoodsReceipt, error) {
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		for _, line := range receipt.Lines {
+			result, err := tx.NewUpdate().
+				Model((*models.PurchaseOrderLine)(nil)).
+				Set("received_quantity = received_quantity + ?", line.Quantity).
+				Where("id = ? AND purchase_order_id = ?", line.PurchaseOrderLineID, receipt.PurchaseOrderID).
+				Where("received_quantity + ? <= quantity", line.Quantity).
+				Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("error updating purchase order line: %w", err)
+			}
+			rowsAffected, _ := result.RowsAffected()
+			if rowsAffected == 0 {
+				return Conflictf("line %d of purchase order %d has fewer copies outstanding than received", line.PurchaseOrderLineID, receipt.PurchaseOrderID)
+			}
+		}
+
+		var complete bool
+		err := tx.NewSelect().
+			ColumnExpr("bool_and(received_quantity >= quantity)").
+			TableExpr("purchase_order_lines").
+			Where("purchase_order_id = ?", receipt.PurchaseOrderID).
+			Scan(ctx, &complete)
+		if err != nil {
+			return fmt.Errorf("error checking purchase order lines: %w", err)
+		}
+		query := tx.NewUpdate().
+			Model((*models.PurchaseOrder)(nil)).
+			Where("id = ?", receipt.PurchaseOrderID).
+			Where("status IN (?)", bun.In([]string{models.PurchaseOrderSent, models.PurchaseOrderPartiallyReceived}))
+		if complete {
+			query = query.Set("status = ?", models.PurchaseOrderReceived).Set("received_at = ?", time.Now())
+		} else {
+			query = query.Set("status = ?", models.PurchaseOrderPartiallyReceived)
+		}
+		result, err := query.Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error updating purchase order status: %w", err)
+		}
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return Conflictf("purchase order with ID %d is not awaiting goods", receipt.PurchaseOrderID)
+		}
+
+		if _, err := tx.NewInsert().Model(&receipt).Returning("*").Exec(ctx); err != nil {
+			return fmt.Errorf("error inserting goods receipt: %w", err)
+		}
+		for i := range receipt.Lines {
+			receipt.Lines[i].GoodsReceiptID = receipt.ID
+		}
+		if _, err := tx.NewInsert().Model(&receipt.Lines).Returning("*").Exec(ctx); err != nil {
+			return fmt.Errorf("error inserting goods receipt lines: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.GoodsReceipt{}, err
+	}
+	return receipt, nil
+}
+
+// ListReceipts fetches the goods receipts of a purchase order, oldest first
+func (r *PurchasingRepository) ListReceipts(purchaseOrderID int) ([]models.GoodsReceipt, error) {
+	var receipts []models.GoodsReceipt
+	err := r.db.NewSelect().
+		Model(&receipts).
+		Relation("Lines").
+		Where("purchase_order_id = ?", purchaseOrderID).
+		Order("created_at ASC", "id ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving goods receipts: %w", err)
+	}
+	return receipts, nil
+}
@@ -0,0 +1,196 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ReservationStore interface. Only reservations that have not expired are
+// ever returned or counted.
+type ReservationStore interface {
+	Reserve(reservation models.Reservation) (models.Reservation, error)
+	GetReservation(id int) (models.Reservation, error)
+	ListReservations(userID int) ([]models.Reservation, error)
+	DeleteReservation(id int) error
+	HeldByOthers(bookID, userID int) (int, error)
+	ConsumeReservations(userID int, quantities map[int]int) error
+	DeleteExpired() (int, error)
+	WithTx(tx bun.IDB) ReservationStore
+}
+
+// PostgreSQL-backed implementation of ReservationStore
+type ReservationRepository struct {
+	db bun.IDB
+}
+
+// NewReservationRepository returns a new instance
+func NewReservationRepository(db *bun.DB) *ReservationRepository {
+	return &ReservationRepository{db: db}
+}
+
+// WithTx returns a ReservationStore that works inside tx
+func (r *ReservationRepository) WithTx(tx bun.IDB) ReservationStore {
+	return &ReservationRepository{db: tx}
+}
+
+// Reserve holds copies of a book for a user, replacing any reservation the
+// user already has on the book. The book row is locked so that two
+// customers cannot reserve the same last copy.
+func (r *ReservationRepository) Reserve(reservation models.Reservation) (models.Reservation, error) {
+	err := r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		var book models.Book
+		err := tx.NewSelect().
+			Model(&book).
+			Column("id", "stock").
+			Where("id = ?", reservation.BookID).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			return lookupError(err, "book with ID %d not found", reservation.BookID)
+		}
+
+		held, err := heldByOthers(ctx, tx, reservation.BookID, reservation.UserID)
+		if err != nil {
+			return err
+		}
+		if available := book.Stock - held; available < reservation.Quantity {
+			return Conflictf("only %d copies of book ID %d are available", max(available, 0), book.ID)
+		}
+
+		_, err = tx.NewDelete().
+			Model((*models.Reservation)(nil)).
+			Where("book_id = ?", reservation.BookID).
+			Where("user_id = ?", reservation.UserID).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error replacing reservation: %w", err)
+		}
+		if _, err := tx.NewInsert().Model(&reservation).Returning("*").Exec(ctx); err != nil {
+			return fmt.Errorf("error inserting reservation: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Reservation{}, err
+	}
+	return reservation, nil
+}
+
+// GetReservation fetches a reservation by ID
+func (r *ReservationRepository) GetReservation(id int) (models.Reservation, error) {
+	var reservation models.Reservation
+	err := r.db.NewSelect().
+		Model(&reservation).
+		Where("id = ?", id).
+		Where("expires_at > ?", time.Now()).
+		Scan(context.Background())
+	if err != nil {
+		return models.Reservation{}, lookupError(err, "reservation with ID %d not found", id)
+	}
+	return reservation, nil
+}
+
+// ListReservations fetches the reservations of a user, or of everyone when
+// userID is 0, soonest to expire first
+func (r *ReservationRepository) ListReservations(userID int) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	query := r.db.NewSelect().
+		Model(&reservations).
+		Where("expires_at > ?", time.Now()).
+		Order("expires_at ASC", "id ASC")
+	if userID > 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.Scan(context.Background()); err != nil {
+		return nil, fmt.Errorf("error retrieving reservations: %w", err)
+	}
+	return reservations, nil
+}
+
+// DeleteReservation releases a reservation
+func (r *ReservationRepository) DeleteReservation(id int) error {
+	result, err := r.db.NewDelete().
+		Model((*models.Reservation)(nil)).
+		Where("id = ?", id).
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("error deleting reservation: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return NotFoundf("reservation with ID %d not found", id)
+	}
+	return nil
+}
+
+// HeldByOthers counts the copies of a book reserved by users other than userID
+func (r *ReservationRepository) HeldByOthers(bookID, userID int) (int, error) {
+	return heldByOthers(context.Background(), r.db, bookID, userID)
+}
+
+// ConsumeReservations takes the copies a user bought, quantities by book
+// ID, off the user's reservations on those books. A reservation is deleted
+// once none of its copies are left.
+func (r *ReservationRepository) ConsumeReservations(userID int, quantities map[int]int) error {
+	return r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		for bookID, quantity := range quantities {
+			if quantity <= 0 {
+				continue
+			}
+			_, err := tx.NewDelete().
+				Model((*models.Reservation)(nil)).
+				Where("user_id = ?", userID).
+				Where("book_id = ?", bookID).
+				Where("quantity <= ?", quantity).
+				Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("error releasing reservations: %w", err)
+			}
+			_, err = tx.NewUpdate().
+				Model((*models.Reservation)(nil)).
+				Set("quantity = quantity - ?", quantity).
+				Where("user_id = ?", userID).
+				Where("book_id = ?", bookID).
+				Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("error releasing reservations: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteExpired deletes every expired reservation and returns how many
+// there were
+func (r *ReservationRepository) DeleteExpired() (int, error) {
+	result, err := r.db.NewDelete().
+		Model((*models.Reservation)(nil)).
+		Where("expires_at <= ?", time.Now()).
+		Exec(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired reservations: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return int(rowsAffected), nil
+}
+
+func heldByOthers(ctx context.Context, idb bun.IDB, bookID, userID int) (int, error) {
+	var held int
+	err := idb.NewSelect().
+		Model((*models.Reservation)(nil)).
+		ColumnExpr("COALESCE(SUM(quantity), 0)").
+		Where("book_id = ?", bookID).
+		Where("user_id <> ?", userID).
+		Where("expires_at > ?", time.Now()).
+		Scan(ctx, &held)
+	if err != nil {
+		return 0, fmt.Errorf("error counting reserved copies: %w", err)
+	}
+	return held, nil
+}
@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testS3Region    = "eu-west-1"
+	testS3AccessKey = "AKIDEXAMPLE"
+	testS3SecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+)
+
+type s3Object struct {
+	contentType string
+	data        []byte
+}
+
+// fakeS3 is a single-bucket S3 endpoint that rejects requests whose
+// Signature Version 4 Authorization header does not verify
+type fakeS3 struct {
+	t       *testing.T
+	bucket  string
+	mu      sync.Mutex
+	objects map[string]s3Object
+}
+
+func newFakeS3(t *testing.T, bucket string) *httptest.Server {
+	f := &fakeS3{t: t, bucket: bucket, objects: map[string]s3Object{}}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if msg := f.verify(r, body); msg != "" {
+		http.Error(w, msg, http.StatusForbidden)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if !ok {
+		http.Error(w, "no such bucket", http.StatusNotFound)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[key] = s3Object{contentType: r.Header.Get("Content-Type"), data: body}
+	case http.MethodGet:
+		obj, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Write(obj.data)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verify rebuilds the canonical request from what arrived on the wire and
+// checks the signature with the shared secret; it returns why a request is
+// rejected, or "" if it is signed correctly
+func (f *fakeS3) verify(r *http.Request, body []byte) string {
+	auth := r.Header.Get("Authorization")
+	rest, ok := strings.CutPrefix(auth, "AWS4-HMAC-SHA256 ")
+	if !ok {
+		return "missing SigV4 authorization"
+	}
+	fields := map[string]string{}
+	for _, part := range strings.Split(rest, ", ") {
+		name, value, _ := strings.Cut(part, "=")
+		fields[name] = value
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	if len(amzDate) != len("20060102T150405Z") {
+		return "bad x-amz-date"
+	}
+	scope := amzDate[:8] + "/" + testS3Region + "/s3/aws4_request"
+	if fields["Credential"] != testS3AccessKey+"/"+scope {
+		return "bad credential " + fields["Credential"]
+	}
+	if r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(body) {
+		return "payload hash does not match body"
+	}
+
+	var canonicalHeaders strings.Builder
+	signed := strings.Split(fields["SignedHeaders"], ";")
+	for _, name := range signed {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	for _, required := range []string{"host", "x-amz-content-sha256", "x-amz-date"} {
+		if !strings.Contains(";"+fields["SignedHeaders"]+";", ";"+required+";") {
+			return required + " is not signed"
+		}
+	}
+	if r.Header.Get("Content-Type") != "" && !strings.HasPrefix(fields["SignedHeaders"], "content-type;") {
+		return "content-type is not signed"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		canonicalHeaders.String(),
+		fields["SignedHeaders"],
+		r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := []byte("AWS4" + testS3SecretKey)
+	for _, part := range []string{amzDate[:8], testS3Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	if want := hex.EncodeToString(hmacSHA256(key, stringToSign)); fields["Signature"] != want {
+		return "signature does not match"
+	}
+	return ""
+}
+
+func newTestS3BlobStore(t *testing.T, endpoint, secretKey string) *S3BlobStore {
+	store, err := NewS3BlobStore(S3Config{
+		Endpoint:  endpoint,
+		Region:    testS3Region,
+		Bucket:    "covers",
+		AccessKey: testS3AccessKey,
+		SecretKey: secretKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestS3BlobStorePutGetDelete(t *testing.T) {
+	server := newFakeS3(t, "covers")
+	store := newTestS3BlobStore(t, server.URL, testS3SecretKey)
+	ctx := context.Background()
+	key := "covers/7/a b+c/original.png"
+
+	if err := store.Put(ctx, key, "image/png", []byte("png bytes")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	rc, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "png bytes" {
+		t.Errorf("Get returned %q", data)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Get after Delete: got %v, want ErrBlobNotFound", err)
+	}
+	// Deleting what is already gone is not an error
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("second Delete: %v", err)
+	}
+
+	if got, want := store.URL(key), server.URL+"/covers/"+key; got != want {
+		t.Errorf("URL = %q, want %q", got, want)
+	}
+}
+
+func TestS3BlobStoreRejectedSignature(t *testing.T) {
+	server := newFakeS3(t, "covers")
+	store := newTestS3BlobStore(t, server.URL, "not-the-secret")
+
+	err := store.Put(context.Background(), "covers/1/original.jpg", "image/jpeg", []byte("jpeg"))
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("Put with a wrong secret: got %v, want a 403 error", err)
+	}
+}
+
+func TestNewS3BlobStoreRequiresConfig(t *testing.T) {
+	if _, err := NewS3BlobStore(S3Config{Endpoint: "http://localhost:9000", Bucket: "covers"}); err == nil {
+		t.Error("store without credentials was accepted")
+	}
+	if _, err := NewS3BlobStore(S3Config{Endpoint: "localhost", Bucket: "b", AccessKey: "a", SecretKey: "s"}); err == nil {
+		t.Error("endpoint without a host was accepted")
+	}
+}
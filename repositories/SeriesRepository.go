@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+// SeriesStore interface
+type SeriesStore interface {
+	CreateSeries(s models.Series) (models.Series, error)
+	GetSeries(id int) (models.Series, error)
+	UpdateSeries(id int, s models.Series) (models.Series, error)
+	DeleteSeries(id int) error
+	ListSeries(name string) ([]models.Series, error)
+	ListSeriesBooks(id int) ([]models.Book, error)
+}
+
+// PostgreSQL-backed implementation of SeriesStore
+type SeriesRepository struct {
+	db *bun.DB
+}
+
+// NewSeriesRepository returns a new instance
+func NewSeriesRepository(db *bun.DB) *SeriesRepository {
+	return &SeriesRepository{db: db}
+}
+
+// CreateSeries inserts a new series
+func (r *SeriesRepository) CreateSeries(series models.Series) (models.Series, error) {
+	_, err := r.db.NewInsert().Model(&series).Returning("*").Exec(context.Background())
+	if err != nil {
+		return models.Series{}, fmt.Errorf("error inserting series: %w", err)
+	}
+	return series, nil
+}
+
+// GetSeries fetches a series by ID with its publisher
+func (r *SeriesRepository) GetSeries(id int) (models.Series, error) {
+	var series models.Series
+	err := r.db.NewSelect().
+		Model(&series).
+		Where("?TableAlias.id = ?", id).
+		Relation("Publisher").
+		Scan(context.Background())
+	if err != nil {
+		return models.Series{}, NotFoundf("series with ID %d not found", id)
+	}
+	return series, nil
+}
+
+// UpdateSeries modifies an existing series
+func (r *SeriesRepository) UpdateSeries(id int, series models.Series) (models.Series, error) {
+	series.ID = id
+
+	result, err := r.db.NewUpdate().
+		Model(&series).
+		Where("id = ?", id).
+		Returning("*").
+		Exec(context.Background())
+
+	if err != nil {
+		return models.Series{}, fmt.Errorf("error updating series: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.Series{}, NotFoundf("series with ID %d not found", id)
+	}
+	return r.GetSeries(id)
+}
+
+// DeleteSeries removes a series; its books are kept and simply leave the series
+func (r *SeriesRepository) DeleteSeries(id int) error {
+	return r.db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().
+			Model((*models.Book)(nil)).
+			Set("series_id = NULL").
+			Set("series_position = NULL").
+			Set("version = version + 1").
+			Where("series_id = ?", id).
+			WhereAllWithDeleted().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error detaching books from series: %w", err)
+		}
+
+		result, err := tx.NewDelete().
+			Model((*models.Series)(nil)).
+			Where("id = ?", id).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("error deleting series: %w", err)
+		}
+
+		rowsAffected, _ := result.RowsAffected()
+		if rowsAffected == 0 {
+			return NotFoundf("series with ID %d not found", id)
+		}
+		return nil
+	})
+}
+
+// ListSeries fetches all series, optionally filtered by name
+func (r *SeriesRepository) ListSeries(name string) ([]models.Series, error) {
+	var series []models.Series
+	query := r.db.NewSelect().Model(&series).Relation("Publisher").Order("series.name ASC")
+	if name != "" {
+		query = query.Where("series.name ILIKE ?", "%"+name+"%")
+	}
+	if err := query.Scan(context.Background()); err != nil {
+		return nil, fmt.Errorf("error retrieving series: %w", err)
+	}
+	return series, nil
+}
+
+// ListSeriesBooks fetches the books of a series in reading order
+func (r *SeriesRepository) ListSeriesBooks(id int) ([]models.Book, error) {
+	var books []models.Book
+	err := withBookRelations(r.db.NewSelect().Model(&books)).
+		Where("?TableAlias.series_id = ?", id).
+		OrderExpr("?TableAlias.series_position ASC NULLS LAST, ?TableAlias.id ASC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving books of series %d: %w", id, err)
+	}
+	return books, nil
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"FinalProject/models"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// TaxStore interface
+type TaxStore interface {
+	CreateTaxRate(rate models.TaxRate) (models.TaxRate, error)
+	GetTaxRate(id int) (models.TaxRate, error)
+	UpdateTaxRate(id int, rate models.TaxRate) (models.TaxRate, error)
+	DeleteTaxRate(id int) error
+	ListTaxRates(country string) ([]models.TaxRate, error)
+	RatesFor(country, state string) ([]models.TaxRate, error)
+}
+
+// PostgreSQL-backed implementation of TaxStore
+type TaxRepository struct {
+	db *bun.DB
+}
+
+// NewTaxRepository returns a new instance
+func NewTaxRepository(db *bun.DB) *TaxRepository {
+	return &TaxRepository{db: db}
+}
+
+// CreateTaxRate inserts a new tax rate
+func (r *TaxRepository) CreateTaxRate(rate models.TaxRate) (models.TaxRate, error) {
+	_, err := r.db.NewInsert().Model(&rate).Returning("*").Exec(context.Background())
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.TaxRate{}, Conflictf("a tax rate for this country, state and category already exists")
+		}
+		return models.TaxRate{}, fmt.Errorf("error inserting tax rate: %w", err)
+	}
+	return rate, nil
+}
+
+// GetTaxRate fetches a tax rate by ID
+func (r *TaxRepository) GetTaxRate(id int) (models.TaxRate, error) {
+	var rate models.TaxRate
+	err := r.db.NewSelect().Model(&rate).Where("id = ?", id).Scan(context.Background())
+	if err != nil {
+		return models.TaxRate{}, lookupError(err, "tax rate with ID %d not found", id)
+	}
+	return rate, nil
+}
+
+// UpdateTaxRate modifies an existing tax rate
+func (r *TaxRepository) UpdateTaxRate(id int, rate models.TaxRate) (models.TaxRate, error) {
+	rate.ID = id
+
+	result, err := r.db.NewUpdate().
+		Model(&rate).
+		ExcludeColumn("created_at").
+		Where("id = ?", id).
+		Returning("*").
+		Exec(context.Background())
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return models.TaxRate{}, Conflictf("a tax rate for this country, state and category already exists")
+		}
+		return models.TaxRate{}, fmt.Errorf("error updating tax rate: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return models.TaxRate{}, NotFoundf("tax rate with ID %d not found", id)
+	}
+	return rate, nil
+}
+
+// DeleteTaxRate removes a tax rate; orders keep the tax they were charged
+func (r *TaxRepository) DeleteTaxRate(id int) error {
+	result, err := r.db.NewDelete().
+		Model((*models.TaxRate)(nil)).
+		Where("id = ?", id).
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("error deleting tax rate: %w", err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return NotFoundf("tax rate with ID %d not found", id)
+	}
+	return nil
+}
+
+// ListTaxRates fetches every tax rate, or those of one country, by country,
+// state and category
+func (r *TaxRepository) ListTaxRates(country string) ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	query := r.db.NewSelect().Model(&rates)
+	if country != "" {
+		query = query.Where("LOWER(country) = LOWER(?)", country)
+	}
+	err := query.
+		OrderExpr("country ASC, state ASC NULLS FIRST, category ASC NULLS FIRST").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving tax rates: %w", err)
+	}
+	return rates, nil
+}
+
+// RatesFor fetches the tax rates that may apply to an address: those of its
+// country as a whole and those of its state, ignoring case
+func (r *TaxRepository) RatesFor(country, state string) ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	err := r.db.NewSelect().
+		Model(&rates).
+		Where("LOWER(country) = LOWER(?)", country).
+		Where("state IS NULL OR LOWER(state) = LOWER(?)", state).
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving tax rates: %w", err)
+	}
+	return rates, nil
+}
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+// ErrVersionConflict is returned when a versioned row was changed by someone
+// else after the caller read it
+var ErrVersionConflict = errors.New("the record was modified by someone else; fetch it again and retry")
+
+// versionMismatch explains why a versioned update or delete of model (a nil
+// pointer to the row type) matched no row: either the row is gone or its
+// version moved on
+func versionMismatch(ctx context.Context, idb bun.IDB, model interface{}, id int, notFound error) error {
+	exists, err := idb.NewSelect().Model(model).Where("id = ?", id).Exists(ctx)
+	if err != nil {
+		return fmt.Errorf("error checking record version: %w", err)
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return notFound
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// actorKey is the context key holding the ID of the user making a request
+type actorKey struct{}
+
+// WithActor returns a copy of ctx that carries the acting user's ID
+func WithActor(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// ActorFromContext returns the acting user's ID, or 0 when there is none
+func ActorFromContext(ctx context.Context) int {
+	id, _ := ctx.Value(actorKey{}).(int)
+	return id
+}
+
+// AuditService records who changed what in the audit log. A nil
+// *AuditService records nothing.
+type AuditService struct {
+	store repositories.AuditStore
+}
+
+func NewAuditService(store repositories.AuditStore) *AuditService {
+	if store == nil {
+		log.Fatal("ERROR: AuditStore is nil in AuditService")
+	}
+	return &AuditService{store: store}
+}
+
+// withTx returns a copy of the service that records inside tx
+func (s *AuditService) withTx(tx bun.IDB) *AuditService {
+	if s == nil {
+		return nil
+	}
+	return &AuditService{store: s.store.WithTx(tx)}
+}
+
+// Record stores the field-level difference between before and after. Pass
+// nil as before for a creation and as after for a deletion; an update that
+// changed nothing is not recorded. The mutation has already happened by the
+// time this is called, so a failure is logged rather than returned.
+func (s *AuditService) Record(ctx context.Context, entity string, entityID int, action string, before, after any) {
+	if s == nil {
+		return
+	}
+	changes := diffFields(before, after)
+	if action == models.AuditUpdate && len(changes) == 0 {
+		return
+	}
+
+	entry := models.AuditEntry{
+		Entity:   entity,
+		EntityID: entityID,
+		Action:   action,
+		ActorID:  ActorFromContext(ctx),
+		Changes:  changes,
+	}
+	if err := s.store.CreateAuditEntry(entry); err != nil {
+		log.Printf("Failed to record audit entry for %s %d: %v", entity, entityID, err)
+	}
+}
+
+// ListAudit fetches the audit trail of an entity type, or of one entity
+func (s *AuditService) ListAudit(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	return s.store.ListAuditEntries(filter)
+}
+
+var baseModelType = reflect.TypeOf(bun.BaseModel{})
+
+// diffFields compares two snapshots of an entity field by field
+func diffFields(before, after any) map[string]models.FieldChange {
+	b, a := auditFields(before), auditFields(after)
+	changes := map[string]models.FieldChange{}
+	for name, value := range a {
+		if old, ok := b[name]; !ok || !bytes.Equal(old, value) {
+			changes[name] = models.FieldChange{Before: b[name], After: value}
+		}
+	}
+	for name, old := range b {
+		if _, ok := a[name]; !ok {
+			changes[name] = models.FieldChange{Before: old}
+		}
+	}
+	return changes
+}
+
+// auditFields flattens an entity into the JSON value of each of its own
+// fields, keyed by the name used in API responses. Relations are left out
+// since related entities are audited on their own, and so are fields hidden
+// from JSON such as password hashes.
+func auditFields(v any) map[string]json.RawMessage {
+	fields := map[string]json.RawMessage{}
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if !rv.IsValid() || rv.Kind() != reflect.Struct {
+		return fields
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() || f.Type == baseModelType || strings.Contains(f.Tag.Get("bun"), "rel:") {
+			continue
+		}
+		name := f.Name
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+
+		data, err := json.Marshal(rv.Field(i).Interface())
+		if err != nil {
+			continue
+		}
+		fields[name] = data
+	}
+	return fields
+}
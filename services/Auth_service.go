@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"errors"
 	"os"
 	"time"
 	"regexp"
@@ -14,10 +13,10 @@ import (
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserExists        = errors.New("user with this email already exists")
-	ErrWeakPassword      = errors.New("password does not meet complexity requirements")
-	ErrInvalidToken      = errors.New("invalid or expired token")
+	ErrInvalidCredentials = unauthorizedf("invalid credentials")
+	ErrUserExists        = conflictf("user with this email already exists")
+	ErrWeakPassword      = invalidf("password does not meet complexity requirements")
+	ErrInvalidToken      = unauthorizedf("invalid or expired token")
 )
 
 type AuthConfig struct {
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"FinalProject/models"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Duplicate scores by kind of match
+const (
+	scoreSameName      = 1.0
+	scoreSwappedName   = 0.9
+	scoreInitials      = 0.8
+	scoreSpellingScale = 0.9 // applied to the edit-distance similarity
+)
+
+// FindDuplicateAuthors scores every pair of authors whose normalized names
+// look alike and returns the pairs scoring at least minScore, best first.
+// The author with the lower ID of each pair is listed first.
+func (s *AuthorService) FindDuplicateAuthors(ctx context.Context, minScore float64) ([]models.AuthorDuplicate, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	authors, err := s.authorRepo.ListAuthors()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(authors, func(i, j int) bool { return authors[i].ID < authors[j].ID })
+
+	names := make([]authorName, len(authors))
+	// Only authors sharing the initial of one of their names are compared,
+	// which still catches swapped first and last names
+	buckets := make(map[rune][]int)
+	for i, a := range authors {
+		names[i] = newAuthorName(a)
+		for _, r := range names[i].initials() {
+			buckets[r] = append(buckets[r], i)
+		}
+	}
+
+	seen := make(map[[2]int]bool)
+	duplicates := []models.AuthorDuplicate{}
+	for _, members := range buckets {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				i, j := members[x], members[y]
+				if seen[[2]int{i, j}] {
+					continue
+				}
+				seen[[2]int{i, j}] = true
+
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				default:
+				}
+
+				score, reason := scoreAuthorNames(names[i], names[j])
+				if score >= minScore && score > 0 {
+					duplicates = append(duplicates, models.AuthorDuplicate{
+						Author:    authors[i],
+						Duplicate: authors[j],
+						Score:     score,
+						Reasons:   []string{reason},
+					})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		if duplicates[i].Score != duplicates[j].Score {
+			return duplicates[i].Score > duplicates[j].Score
+		}
+		return duplicates[i].Author.ID < duplicates[j].Author.ID
+	})
+	return duplicates, nil
+}
+
+// MergeAuthors folds the source author into the target. Afterwards the
+// source ID keeps resolving to the target.
+func (s *AuthorService) MergeAuthors(ctx context.Context, targetID, sourceID int) (models.Author, error) {
+	select {
+	case <-ctx.Done():
+		return models.Author{}, ctx.Err()
+	default:
+	}
+
+	if targetID == sourceID {
+		return models.Author{}, invalidf("an author cannot be merged into itself")
+	}
+	target, err := s.authorRepo.GetAuthor(targetID)
+	if err != nil {
+		return models.Author{}, notFoundf("author with ID %d not found", targetID)
+	}
+	source, err := s.authorRepo.GetAuthor(sourceID)
+	if err != nil {
+		return models.Author{}, notFoundf("author with ID %d not found", sourceID)
+	}
+
+	merged, err := s.authorRepo.MergeAuthors(targetID, sourceID)
+	if err != nil {
+		return models.Author{}, err
+	}
+	s.audit.Record(ctx, models.AuditAuthor, sourceID, models.AuditMerge, source, nil)
+	s.audit.Record(ctx, models.AuditAuthor, targetID, models.AuditMerge, target, merged)
+	return merged, nil
+}
+
+// authorName is an author's name split into normalized words
+type authorName struct {
+	first []string
+	last  []string
+}
+
+func newAuthorName(a models.Author) authorName {
+	return authorName{first: nameWords(a.FirstName), last: nameWords(a.LastName)}
+}
+
+func (n authorName) full() string {
+	return strings.Join(append(append([]string{}, n.first...), n.last...), " ")
+}
+
+func (n authorName) initials() []rune {
+	var initials []rune
+	for _, words := range [][]string{n.first, n.last} {
+		if len(words) > 0 {
+			initials = append(initials, []rune(words[0])[0])
+		}
+	}
+	return initials
+}
+
+// nameWords lowercases a name and splits it into words, treating
+// punctuation as a separator ("J.R.R." -> j r r, "O'Brien" -> o brien)
+func nameWords(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// scoreAuthorNames rates how likely two names belong to the same person
+func scoreAuthorNames(a, b authorName) (float64, string) {
+	fullA, fullB := a.full(), b.full()
+	if fullA == "" || fullB == "" {
+		return 0, ""
+	}
+	if fullA == fullB {
+		return scoreSameName, "same normalized name"
+	}
+	if strings.Join(a.first, " ") == strings.Join(b.last, " ") && strings.Join(a.last, " ") == strings.Join(b.first, " ") {
+		return scoreSwappedName, "first and last names swapped"
+	}
+	if strings.Join(a.last, " ") == strings.Join(b.last, " ") && initialsCompatible(a.first, b.first) {
+		return scoreInitials, "same last name, first names match by initials"
+	}
+
+	similarity := 1 - float64(levenshtein(fullA, fullB))/float64(max(len([]rune(fullA)), len([]rune(fullB))))
+	return similarity * scoreSpellingScale, fmt.Sprintf("similar spelling (%.0f%% alike)", similarity*100)
+}
+
+// initialsCompatible reports whether two first-name word lists agree once
+// abbreviations are allowed: "j r r" matches "john ronald reuel" and "j"
+// matches "john", but "john" does not match "james"
+func initialsCompatible(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 || len(a) != len(b) && min(len(a), len(b)) != 1 {
+		return false
+	}
+	abbreviated := false
+	for i := 0; i < min(len(a), len(b)); i++ {
+		x, y := a[i], b[i]
+		if x == y {
+			continue
+		}
+		if len([]rune(x)) == 1 && strings.HasPrefix(y, x) || len([]rune(y)) == 1 && strings.HasPrefix(x, y) {
+			abbreviated = true
+			continue
+		}
+		return false
+	}
+	return abbreviated || len(a) != len(b)
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
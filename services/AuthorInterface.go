@@ -5,15 +5,17 @@ import (
 	"FinalProject/repositories"
 	"context"
 	"fmt"
+	"time"
 )
 
 // AuthorService now interacts with DB repository
 type AuthorService struct {
 	authorRepo *repositories.AuthorRepository
+	audit      *AuditService
 }
 
-func NewAuthorService(authorRepo *repositories.AuthorRepository) *AuthorService {
-	return &AuthorService{authorRepo: authorRepo}
+func NewAuthorService(authorRepo *repositories.AuthorRepository, audit *AuditService) *AuthorService {
+	return &AuthorService{authorRepo: authorRepo, audit: audit}
 }
 
 // CreateAuthor inserts a new author
@@ -30,6 +32,8 @@ func (s *AuthorService) CreateAuthor(ctx context.Context, author models.Author)
 	}
 	defer tx.Rollback()
 
+	author.DeletedAt = time.Time{}
+	author.Version = 0
 	createdAuthor, err := s.authorRepo.CreateAuthor(author)
 	if err != nil {
 		return models.Author{}, fmt.Errorf("error creating author: %w", err)
@@ -39,6 +43,7 @@ func (s *AuthorService) CreateAuthor(ctx context.Context, author models.Author)
 		return models.Author{}, err
 	}
 
+	s.audit.Record(ctx, models.AuditAuthor, createdAuthor.ID, models.AuditCreate, nil, createdAuthor)
 	return createdAuthor, nil
 }
 
@@ -66,6 +71,14 @@ func (s *AuthorService) UpdateAuthor(ctx context.Context, id int, author models.
 	}
 	defer tx.Rollback()
 
+	existingAuthor, err := s.authorRepo.GetAuthor(id)
+	if err != nil {
+		return models.Author{}, notFoundf("author with ID %d not found", id)
+	}
+	if author.Version, err = matchVersion(author.Version, existingAuthor.Version); err != nil {
+		return models.Author{}, err
+	}
+
 	updatedAuthor, err := s.authorRepo.UpdateAuthor(id, author)
 	if err != nil {
 		return models.Author{}, fmt.Errorf("error updating author: %w", err)
@@ -75,11 +88,38 @@ func (s *AuthorService) UpdateAuthor(ctx context.Context, id int, author models.
 		return models.Author{}, err
 	}
 
+	s.audit.Record(ctx, models.AuditAuthor, id, models.AuditUpdate, existingAuthor, updatedAuthor)
 	return updatedAuthor, nil
 }
 
-// DeleteAuthor removes an author
-func (s *AuthorService) DeleteAuthor(ctx context.Context, id int) error {
+// PatchAuthor applies a merge patch or JSON Patch to an author
+func (s *AuthorService) PatchAuthor(ctx context.Context, id int, version int, contentType string, patch []byte) (models.Author, error) {
+	select {
+	case <-ctx.Done():
+		return models.Author{}, ctx.Err()
+	default:
+	}
+
+	existingAuthor, err := s.authorRepo.GetAuthor(id)
+	if err != nil {
+		return models.Author{}, notFoundf("author with ID %d not found", id)
+	}
+	var author models.Author
+	if err := applyPatch(existingAuthor, contentType, patch, &author); err != nil {
+		return models.Author{}, err
+	}
+	if err := validatePatched(author, &models.AuthorRequest{}); err != nil {
+		return models.Author{}, err
+	}
+
+	// A merged author's old ID resolves to the surviving author
+	author.ID = existingAuthor.ID
+	author.Version = version
+	return s.UpdateAuthor(ctx, existingAuthor.ID, author)
+}
+
+// DeleteAuthor soft-deletes an author if it is still at the given version (0 for any)
+func (s *AuthorService) DeleteAuthor(ctx context.Context, id int, version int) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
@@ -92,7 +132,15 @@ func (s *AuthorService) DeleteAuthor(ctx context.Context, id int) error {
 	}
 	defer tx.Rollback()
 
-	err = s.authorRepo.DeleteAuthor(id)
+	existingAuthor, err := s.authorRepo.GetAuthor(id)
+	if err != nil {
+		return notFoundf("author with ID %d not found", id)
+	}
+	if version, err = matchVersion(version, existingAuthor.Version); err != nil {
+		return err
+	}
+
+	err = s.authorRepo.DeleteAuthor(id, version)
 	if err != nil {
 		return err 
 	}
@@ -101,9 +149,26 @@ func (s *AuthorService) DeleteAuthor(ctx context.Context, id int) error {
 		return err
 	}
 
+	s.audit.Record(ctx, models.AuditAuthor, id, models.AuditDelete, existingAuthor, nil)
 	return nil
 }
 
+// RestoreAuthor brings back a soft-deleted author
+func (s *AuthorService) RestoreAuthor(ctx context.Context, id int) (models.Author, error) {
+	select {
+	case <-ctx.Done():
+		return models.Author{}, ctx.Err()
+	default:
+	}
+
+	author, err := s.authorRepo.RestoreAuthor(id)
+	if err != nil {
+		return models.Author{}, err
+	}
+	s.audit.Record(ctx, models.AuditAuthor, id, models.AuditRestore, nil, author)
+	return author, nil
+}
+
 // ListAuthors retrieves all authors
 func (s *AuthorService) ListAuthors(ctx context.Context) ([]models.Author, error) {
 	select {
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"FinalProject/models"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// normalizeContributors validates a contributor list, defaults missing roles
+// to author, numbers missing positions in list order and sorts by position.
+// Authors are not resolved here; callers do that with their own store.
+func normalizeContributors(contributors []models.BookContributor) ([]models.BookContributor, error) {
+	normalized := make([]models.BookContributor, len(contributors))
+	copy(normalized, contributors)
+
+	for i := range normalized {
+		c := &normalized[i]
+		c.Role = strings.ToLower(strings.TrimSpace(c.Role))
+		if c.Role == "" {
+			c.Role = models.RoleAuthor
+		}
+		if !models.IsContributorRole(c.Role) {
+			return nil, invalidf("contributor %d has unknown role %q, expected one of %s", i+1, c.Role, strings.Join(models.ContributorRoles, ", "))
+		}
+		if c.Position <= 0 {
+			c.Position = i + 1
+		}
+		if c.AuthorID <= 0 && (c.Author == nil || strings.TrimSpace(c.Author.FirstName) == "" || strings.TrimSpace(c.Author.LastName) == "") {
+			return nil, invalidf("contributor %d needs an AuthorID or an author first and last name", i+1)
+		}
+	}
+
+	sort.SliceStable(normalized, func(i, j int) bool { return normalized[i].Position < normalized[j].Position })
+
+	if leadAuthorIndex(normalized) < 0 {
+		return nil, invalidf("at least one contributor must have the %q role", models.RoleAuthor)
+	}
+	return normalized, nil
+}
+
+// leadAuthorIndex returns the index of the first contributor with the author role
+func leadAuthorIndex(contributors []models.BookContributor) int {
+	for i, c := range contributors {
+		if c.Role == models.RoleAuthor {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkDuplicateContributors rejects the same author credited twice in one role
+func checkDuplicateContributors(contributors []models.BookContributor) error {
+	seen := make(map[string]bool, len(contributors))
+	for _, c := range contributors {
+		key := fmt.Sprintf("%d|%s", c.AuthorID, c.Role)
+		if seen[key] {
+			return invalidf("author with ID %d is listed more than once as %s", c.AuthorID, c.Role)
+		}
+		seen[key] = true
+	}
+	return nil
+}
@@ -0,0 +1,609 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// errImportRolledBack aborts an atomic import once a row has failed
+var errImportRolledBack = errors.New("import rolled back because at least one row failed")
+
+// BookImportService loads books in bulk from CSV or NDJSON streams
+type BookImportService struct {
+	store repositories.BookImportStore
+	audit *AuditService
+}
+
+func NewBookImportService(store repositories.BookImportStore, audit *AuditService) *BookImportService {
+	if store == nil {
+		log.Fatal("ERROR: BookImportStore is nil in BookImportService")
+	}
+	return &BookImportService{store: store, audit: audit}
+}
+
+// importRow is one parsed input record together with its parse errors
+type importRow struct {
+	line int
+	book models.Book
+	errs []models.ImportRowError
+}
+
+// rowSource yields rows one at a time and returns io.EOF when exhausted
+type rowSource interface {
+	Next() (importRow, error)
+}
+
+// importCache deduplicates authors and genres across the rows of one import
+// run. Author keys are normalized "first|last" names; dry runs store negative
+// IDs for authors that would have been created. Genres map an input slug to
+// the canonical slug it resolved to.
+type importCache struct {
+	byName   map[string]int
+	knownIDs map[int]bool
+	nextFake int
+	genres   map[string]string
+}
+
+func newImportCache() *importCache {
+	return &importCache{byName: map[string]int{}, knownIDs: map[int]bool{}, nextFake: -1, genres: map[string]string{}}
+}
+
+// rowResolution collects everything a row resolved, applied to the cache
+// and report only once the row is known to be persisted
+type rowResolution struct {
+	authors []authorResolution
+	genres  []genreResolution
+	book    models.Book // the inserted book; zero on dry runs
+}
+
+// genreResolution records how one genre of a row was resolved
+type genreResolution struct {
+	key     string
+	slug    string
+	created bool
+}
+
+// authorResolution records how a row's author was resolved so the cache
+// can be updated only once the row is known to be persisted
+type authorResolution struct {
+	key     string
+	id      int
+	created bool
+	matched bool
+}
+
+// ImportBooks streams rows from r and creates books according to opts
+func (s *BookImportService) ImportBooks(ctx context.Context, r io.Reader, opts models.ImportOptions) (models.ImportReport, error) {
+	select {
+	case <-ctx.Done():
+		return models.ImportReport{}, ctx.Err()
+	default:
+	}
+
+	if opts.Mode == "" {
+		opts.Mode = models.ImportModeAtomic
+	}
+	if opts.Mode != models.ImportModeAtomic && opts.Mode != models.ImportModeBestEffort {
+		return models.ImportReport{}, invalidf("unknown import mode %q", opts.Mode)
+	}
+
+	var source rowSource
+	switch opts.Format {
+	case "csv":
+		src, err := newCSVRowSource(r)
+		if err != nil {
+			return models.ImportReport{}, err
+		}
+		source = src
+	case "ndjson":
+		source = newNDJSONRowSource(r)
+	case "onix":
+		source = newONIXRowSource(r)
+	default:
+		return models.ImportReport{}, invalidf("unsupported import format %q", opts.Format)
+	}
+
+	report := models.ImportReport{
+		Format: opts.Format,
+		Mode:   opts.Mode,
+		DryRun: opts.DryRun,
+		Errors: []models.ImportRowError{},
+	}
+	cache := newImportCache()
+	// Books inserted so far, audited once their transaction has committed
+	var imported []models.Book
+	finish := func() models.ImportReport {
+		if u, ok := source.(interface{ Unmapped() map[string]int }); ok && len(u.Unmapped()) > 0 {
+			report.UnmappedFields = u.Unmapped()
+		}
+		return report
+	}
+
+	// Best-effort runs and dry runs write (or skip) each row independently.
+	if opts.Mode == models.ImportModeBestEffort || opts.DryRun {
+		err := s.eachRow(ctx, source, &report, func(row importRow) (rowResolution, error) {
+			if opts.DryRun {
+				return s.processRow(ctx, s.store.DB(), cache, row, true)
+			}
+			var res rowResolution
+			err := s.store.RunInTx(ctx, func(ctx context.Context, idb bun.IDB) error {
+				var err error
+				res, err = s.processRow(ctx, idb, cache, row, false)
+				return err
+			})
+			if err == nil {
+				imported = append(imported, res.book)
+			}
+			return res, err
+		}, cache)
+		s.auditImported(ctx, imported)
+		if err != nil {
+			return models.ImportReport{}, err
+		}
+		report.Committed = !opts.DryRun && report.ImportedRows > 0
+		return finish(), nil
+	}
+
+	// Atomic runs share a single transaction that is rolled back on any failure.
+	err := s.store.RunInTx(ctx, func(ctx context.Context, idb bun.IDB) error {
+		err := s.eachRow(ctx, source, &report, func(row importRow) (rowResolution, error) {
+			// Once a row has failed the transaction is doomed, so the rest of
+			// the stream is only checked to report every error in one pass.
+			if report.FailedRows > 0 {
+				return s.processRow(ctx, s.store.DB(), cache, row, true)
+			}
+			res, err := s.processRow(ctx, idb, cache, row, false)
+			if err == nil {
+				imported = append(imported, res.book)
+			}
+			return res, err
+		}, cache)
+		if err != nil {
+			return err
+		}
+		if report.FailedRows > 0 {
+			return errImportRolledBack
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportRolledBack) {
+		return models.ImportReport{}, err
+	}
+
+	report.Committed = err == nil
+	if report.Committed {
+		s.auditImported(ctx, imported)
+	} else {
+		report.ImportedRows = 0
+		report.AuthorsCreated = 0
+		report.AuthorsMatched = 0
+		report.GenresCreated = 0
+	}
+	return finish(), nil
+}
+
+// auditImported records the creation of every committed imported book
+func (s *BookImportService) auditImported(ctx context.Context, books []models.Book) {
+	for _, book := range books {
+		s.audit.Record(ctx, models.AuditBook, book.ID, models.AuditCreate, nil, book)
+	}
+}
+
+// eachRow drives the row source, tallying results into the report
+func (s *BookImportService) eachRow(ctx context.Context, source rowSource, report *models.ImportReport, handle func(importRow) (rowResolution, error), cache *importCache) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		row, err := source.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading import stream: %w", err)
+		}
+		report.TotalRows++
+
+		if len(row.errs) == 0 {
+			row.errs = validateImportedBook(row.line, row.book)
+		}
+		if len(row.errs) > 0 {
+			report.FailedRows++
+			report.Errors = append(report.Errors, row.errs...)
+			continue
+		}
+
+		resolution, err := handle(row)
+		if err != nil {
+			report.FailedRows++
+			report.Errors = append(report.Errors, models.ImportRowError{Row: row.line, Message: err.Error()})
+			continue
+		}
+
+		report.ImportedRows++
+		for _, res := range resolution.genres {
+			if res.created {
+				report.GenresCreated++
+			}
+			cache.genres[res.key] = res.slug
+		}
+		for _, res := range resolution.authors {
+			if res.created {
+				report.AuthorsCreated++
+			}
+			if res.matched {
+				report.AuthorsMatched++
+			}
+			if res.key != "" {
+				cache.byName[res.key] = res.id
+			}
+			if res.id != 0 {
+				cache.knownIDs[res.id] = true
+			}
+		}
+	}
+}
+
+// processRow resolves the contributors and genres of a row and inserts the
+// book unless dryRun is set. Rows without a contributor list are credited to
+// their single author, as with POST /api/books.
+func (s *BookImportService) processRow(ctx context.Context, idb bun.IDB, cache *importCache, row importRow, dryRun bool) (rowResolution, error) {
+	book := row.book
+	contributors := book.Contributors
+	if len(contributors) == 0 {
+		contributors = []models.BookContributor{{AuthorID: book.AuthorID, Author: book.Author, Role: models.RoleAuthor, Position: 1}}
+	}
+	contributors, err := normalizeContributors(contributors)
+	if err != nil {
+		return rowResolution{}, err
+	}
+
+	// Two contributors in the same row may name the same new author, so each
+	// resolution is cached locally before the next one is looked up.
+	local := &importCache{byName: map[string]int{}, knownIDs: cache.knownIDs, nextFake: cache.nextFake}
+	for k, v := range cache.byName {
+		local.byName[k] = v
+	}
+
+	var resolution rowResolution
+	for i := range contributors {
+		res, err := s.resolveAuthor(ctx, idb, local, contributors[i].AuthorID, contributors[i].Author, dryRun)
+		if err != nil {
+			return rowResolution{}, err
+		}
+		if res.key != "" {
+			local.byName[res.key] = res.id
+		}
+		contributors[i].AuthorID = res.id
+		contributors[i].Author = nil
+		resolution.authors = append(resolution.authors, res)
+	}
+	cache.nextFake = local.nextFake
+
+	if err := checkDuplicateContributors(contributors); err != nil {
+		return rowResolution{}, err
+	}
+
+	genres := make([]string, 0, len(book.Genres))
+	for _, g := range book.Genres {
+		res, err := s.resolveGenre(ctx, idb, cache, g, dryRun)
+		if err != nil {
+			return rowResolution{}, err
+		}
+		if res.slug == "" || containsString(genres, res.slug) {
+			continue
+		}
+		genres = append(genres, res.slug)
+		resolution.genres = append(resolution.genres, res)
+	}
+
+	if dryRun {
+		return resolution, nil
+	}
+
+	book.ID = 0
+	book.AuthorID = contributors[leadAuthorIndex(contributors)].AuthorID
+	book.Author = nil
+	book.Contributors = contributors
+	book.Genres = genres
+	if err := storeAmount("price", &book.Price); err != nil {
+		return rowResolution{}, err
+	}
+	book.CostPrice = models.Money{Currency: models.DefaultCurrency}
+	book.Cover = models.BookCover{}
+	book.DeletedAt = time.Time{}
+	book.Version = 0
+	if err := s.store.InsertBook(ctx, idb, &book); err != nil {
+		return rowResolution{}, err
+	}
+	if book.Stock > 0 {
+		receipt := models.StockMovement{
+			BookID:   book.ID,
+			Kind:     models.MovementReceipt,
+			Quantity: book.Stock,
+			Reason:   "imported",
+			ActorID:  ActorFromContext(ctx),
+		}
+		if err := s.store.RecordMovement(ctx, idb, receipt); err != nil {
+			return rowResolution{}, err
+		}
+	}
+	resolution.book = book
+	return resolution, nil
+}
+
+// resolveGenre maps a genre name, slug or alias to a taxonomy slug. Unlike
+// POST /api/books, imports add unknown genres as new top-level genres so
+// feeds with free-text subjects are not rejected.
+func (s *BookImportService) resolveGenre(ctx context.Context, idb bun.IDB, cache *importCache, name string, dryRun bool) (genreResolution, error) {
+	key := models.Slugify(name)
+	if key == "" {
+		return genreResolution{}, nil
+	}
+	if slug, ok := cache.genres[key]; ok {
+		return genreResolution{key: key, slug: slug}, nil
+	}
+
+	existing, err := s.store.FindGenre(ctx, idb, key)
+	if err == nil {
+		return genreResolution{key: key, slug: existing.Slug}, nil
+	}
+	if !errors.Is(err, repositories.ErrGenreNotFound) {
+		return genreResolution{}, err
+	}
+
+	if dryRun {
+		return genreResolution{key: key, slug: key, created: true}, nil
+	}
+	created := models.Genre{Name: strings.TrimSpace(name), Slug: key}
+	if err := s.store.InsertGenre(ctx, idb, &created); err != nil {
+		return genreResolution{}, err
+	}
+	return genreResolution{key: key, slug: created.Slug, created: true}, nil
+}
+
+// resolveAuthor finds an author by ID, then by normalized name, and creates
+// one only when nothing matches
+func (s *BookImportService) resolveAuthor(ctx context.Context, idb bun.IDB, cache *importCache, authorID int, author *models.Author, dryRun bool) (authorResolution, error) {
+	if authorID > 0 {
+		if cache.knownIDs[authorID] {
+			return authorResolution{id: authorID}, nil
+		}
+		resolved, err := s.store.ResolveAuthorID(ctx, idb, authorID)
+		if err != nil {
+			return authorResolution{}, err
+		}
+		if resolved == 0 {
+			return authorResolution{}, invalidf("author with ID %d does not exist", authorID)
+		}
+		return authorResolution{id: resolved}, nil
+	}
+
+	key := repositories.NormalizeName(author.FirstName) + "|" + repositories.NormalizeName(author.LastName)
+	if id, ok := cache.byName[key]; ok {
+		return authorResolution{key: key, id: id, matched: true}, nil
+	}
+
+	existing, err := s.store.FindAuthorByName(ctx, idb, author.FirstName, author.LastName)
+	if err == nil {
+		return authorResolution{key: key, id: existing.ID, matched: true}, nil
+	}
+	if !errors.Is(err, repositories.ErrAuthorNotFound) {
+		return authorResolution{}, err
+	}
+
+	if dryRun {
+		id := cache.nextFake
+		cache.nextFake--
+		return authorResolution{key: key, id: id, created: true}, nil
+	}
+
+	created := models.Author{
+		FirstName: strings.TrimSpace(author.FirstName),
+		LastName:  strings.TrimSpace(author.LastName),
+		Bio:       author.Bio,
+	}
+	if err := s.store.InsertAuthor(ctx, idb, &created); err != nil {
+		return authorResolution{}, err
+	}
+	return authorResolution{key: key, id: created.ID, created: true}, nil
+}
+
+// validateImportedBook applies the same rules as a single book creation
+func validateImportedBook(line int, book models.Book) []models.ImportRowError {
+	var errs []models.ImportRowError
+	add := func(field, msg string) {
+		errs = append(errs, models.ImportRowError{Row: line, Field: field, Message: msg})
+	}
+
+	if strings.TrimSpace(book.Title) == "" {
+		add("title", "title is required")
+	}
+	if len(book.Contributors) > 0 {
+		if _, err := normalizeContributors(book.Contributors); err != nil {
+			add("contributors", err.Error())
+		}
+	} else if book.AuthorID <= 0 {
+		if book.Author == nil || strings.TrimSpace(book.Author.FirstName) == "" || strings.TrimSpace(book.Author.LastName) == "" {
+			add("author", "either author_id or author first and last name is required")
+		}
+	}
+	if book.PublishedAt.IsZero() {
+		add("published_at", "published_at is required")
+	}
+	price := book.Price
+	if err := storeAmount("price", &price); err != nil {
+		add("price", err.Error())
+	}
+	if book.Stock < 0 {
+		add("stock", "stock cannot be negative")
+	}
+	return errs
+}
+
+// csvRowSource reads books from CSV with a header row. Recognized columns:
+// title, author_id, author_first_name, author_last_name, author_bio,
+// genres (separated by "|" or ";"), published_at, price, stock.
+type csvRowSource struct {
+	reader  *csv.Reader
+	columns map[string]int
+	line    int
+}
+
+func newCSVRowSource(r io.Reader) (*csvRowSource, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, invalidf("CSV input is empty")
+	}
+	if err != nil {
+		return nil, invalidf("error reading CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, invalidf("CSV header must contain a title column")
+	}
+	return &csvRowSource{reader: reader, columns: columns, line: 1}, nil
+}
+
+func (c *csvRowSource) Next() (importRow, error) {
+	record, err := c.reader.Read()
+	if err == io.EOF {
+		return importRow{}, io.EOF
+	}
+	c.line++
+	row := importRow{line: c.line}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			row.errs = append(row.errs, models.ImportRowError{Row: c.line, Message: parseErr.Error()})
+			return row, nil
+		}
+		return importRow{}, err
+	}
+
+	get := func(name string) string {
+		if i, ok := c.columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	fail := func(field, msg string) {
+		row.errs = append(row.errs, models.ImportRowError{Row: c.line, Field: field, Message: msg})
+	}
+
+	book := models.Book{
+		Title: get("title"),
+		Author: &models.Author{
+			FirstName: get("author_first_name"),
+			LastName:  get("author_last_name"),
+			Bio:       get("author_bio"),
+		},
+	}
+
+	if v := get("author_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			fail("author_id", "author_id must be an integer")
+		}
+		book.AuthorID = id
+	}
+	if v := get("genres"); v != "" {
+		for _, g := range strings.FieldsFunc(v, func(r rune) bool { return r == '|' || r == ';' }) {
+			if g = strings.TrimSpace(g); g != "" {
+				book.Genres = append(book.Genres, g)
+			}
+		}
+	}
+	if v := get("published_at"); v != "" {
+		t, err := parseImportDate(v)
+		if err != nil {
+			fail("published_at", "published_at must be YYYY-MM-DD or RFC3339")
+		}
+		book.PublishedAt = t
+	}
+	if v := get("price"); v != "" {
+		price, err := models.ParseMoney(v, models.DefaultCurrency)
+		if err != nil {
+			fail("price", "price must be a number")
+		}
+		book.Price = price
+	}
+	if v := get("stock"); v != "" {
+		stock, err := strconv.Atoi(v)
+		if err != nil {
+			fail("stock", "stock must be an integer")
+		}
+		book.Stock = stock
+	}
+
+	row.book = book
+	return row, nil
+}
+
+// ndjsonRowSource reads one JSON book per line, in the same shape as the
+// body of POST /api/books
+type ndjsonRowSource struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONRowSource(r io.Reader) *ndjsonRowSource {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &ndjsonRowSource{scanner: scanner}
+}
+
+func (n *ndjsonRowSource) Next() (importRow, error) {
+	for n.scanner.Scan() {
+		n.line++
+		text := strings.TrimSpace(n.scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		row := importRow{line: n.line}
+		if err := json.Unmarshal([]byte(text), &row.book); err != nil {
+			row.errs = append(row.errs, models.ImportRowError{Row: n.line, Message: "invalid JSON: " + err.Error()})
+		}
+		if row.book.Author == nil {
+			row.book.Author = &models.Author{}
+		}
+		return row, nil
+	}
+	if err := n.scanner.Err(); err != nil {
+		return importRow{}, err
+	}
+	return importRow{}, io.EOF
+}
+
+func parseImportDate(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"FinalProject/models"
+	"strings"
+	"testing"
+)
+
+func TestNDJSONRowPriceCurrency(t *testing.T) {
+	const book = `"Title": "Dune", "Author": {"FirstName": "Frank", "LastName": "Herbert"}, "PublishedAt": "1965-08-01T00:00:00Z"`
+	cases := []struct {
+		name, price string
+		want        models.Money
+		wantErr     bool
+	}{
+		{"bare number", `10.5`, models.NewMoney(1050, models.DefaultCurrency), false},
+		{"object without currency", `{"Amount": "10"}`, models.NewMoney(1000, models.DefaultCurrency), false},
+		{"store currency", `{"Amount": "10", "Currency": "` + models.DefaultCurrency + `"}`, models.NewMoney(1000, models.DefaultCurrency), false},
+		{"other currency", `{"Amount": "10", "Currency": "EUR"}`, models.NewMoney(1000, "EUR"), true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			row, err := newNDJSONRowSource(strings.NewReader(`{` + book + `, "Price": ` + c.price + `}`)).Next()
+			if err != nil || len(row.errs) != 0 {
+				t.Fatalf("Next: %v %+v", err, row.errs)
+			}
+			if row.book.Price != c.want {
+				t.Errorf("price = %v, want %v", row.book.Price, c.want)
+			}
+			errs := validateImportedBook(row.line, row.book)
+			if c.wantErr {
+				if len(errs) != 1 || errs[0].Field != "price" || errs[0].Row != 1 {
+					t.Errorf("errors = %+v, want one price error on row 1", errs)
+				}
+			} else if len(errs) != 0 {
+				t.Errorf("errors = %+v, want none", errs)
+			}
+		})
+	}
+}
@@ -3,24 +3,50 @@ package services
 import (
 	"FinalProject/models"
 	"FinalProject/repositories"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
 )
 
 type BookService struct {
-	store       repositories.BookStore
-	authorStore repositories.AuthorStore
+	store          repositories.BookStore
+	authorStore    repositories.AuthorStore
+	publisherStore repositories.PublisherStore
+	seriesStore    repositories.SeriesStore
+	genreStore     repositories.GenreStore
+	inventory      *InventoryService
+	audit          *AuditService
 }
 
-func NewBookService(bookStore repositories.BookStore, authorStore repositories.AuthorStore) *BookService {
-	if bookStore == nil || authorStore == nil {
-		log.Fatal("ERROR: BookStore or AuthorStore is nil in BookService")
+func NewBookService(bookStore repositories.BookStore, authorStore repositories.AuthorStore, publisherStore repositories.PublisherStore, seriesStore repositories.SeriesStore, genreStore repositories.GenreStore, inventory *InventoryService, audit *AuditService) *BookService {
+	if bookStore == nil || authorStore == nil || publisherStore == nil || seriesStore == nil || genreStore == nil {
+		log.Fatal("ERROR: BookStore, AuthorStore, PublisherStore, SeriesStore or GenreStore is nil in BookService")
 	}
-	return &BookService{store: bookStore, authorStore: authorStore}
+	return &BookService{store: bookStore, authorStore: authorStore, publisherStore: publisherStore, seriesStore: seriesStore, genreStore: genreStore, inventory: inventory, audit: audit}
+}
+
+// inTx runs fn on a copy of the service whose book store, inventory and
+// audit log work inside one transaction, so that a book's stock and the
+// ledger movements recording it are saved together or not at all
+func (bs *BookService) inTx(ctx context.Context, fn func(tx *BookService) error) error {
+	return bs.store.RunInTx(ctx, func(ctx context.Context, tx bun.IDB) error {
+		c := *bs
+		c.store = bs.store.WithTx(tx)
+		c.inventory = bs.inventory.withTx(tx)
+		c.audit = bs.audit.withTx(tx)
+		return fn(&c)
+	})
 }
 
-// CreateBook inserts a new book and ensures author exists
+// CreateBook inserts a new book and ensures its authors exist. A book may be
+// sent either with a Contributors list or, as before, with a single
+// AuthorID / Author.
 func (bs *BookService) CreateBook(ctx context.Context, book models.Book) (models.Book, error) {
 
 	select {
@@ -30,39 +56,139 @@ func (bs *BookService) CreateBook(ctx context.Context, book models.Book) (models
 	default:
 	}
 
-	if book.Author == nil {
-		book.Author = &models.Author{}
+	if err := bs.checkPublication(&book); err != nil {
+		return models.Book{}, err
+	}
+	genres, err := resolveGenres(bs.genreStore, book.Genres)
+	if err != nil {
+		return models.Book{}, err
 	}
+	book.Genres = genres
 
-	if book.AuthorID > 0 {
-		author, err := bs.authorStore.GetAuthor(book.AuthorID)
+	if len(book.Contributors) > 0 {
+		contributors, err := bs.resolveContributors(ctx, book.Contributors)
 		if err != nil {
-			return models.Book{}, fmt.Errorf("author with ID %d does not exist: %w", book.AuthorID, err)
+			return models.Book{}, err
 		}
-		book.Author = &author
+		lead := contributors[leadAuthorIndex(contributors)]
+		book.Contributors = contributors
+		book.AuthorID = lead.AuthorID
+		book.Author = lead.Author
 	} else {
+		author, err := bs.resolveAuthor(ctx, book.AuthorID, book.Author)
+		if err != nil {
+			return models.Book{}, err
+		}
+		book.AuthorID = author.ID
+		book.Author = &author
+		book.Contributors = []models.BookContributor{{AuthorID: author.ID, Author: &author, Role: models.RoleAuthor, Position: 1}}
+	}
 
-		if book.Author.FirstName == "" || book.Author.LastName == "" {
-			return models.Book{}, fmt.Errorf("author first name and last name cannot be empty")
+	book.Version = 0
+	book.CostPrice = models.Money{Currency: book.Price.Currency}
+	var createdBook models.Book
+	err = bs.inTx(ctx, func(tx *BookService) error {
+		var err error
+		if createdBook, err = tx.store.CreateBook(book); err != nil {
+			return err
+		}
+		tx.audit.Record(ctx, models.AuditBook, createdBook.ID, models.AuditCreate, nil, createdBook)
+		// The initial stock is received at the first location
+		if createdBook.Stock > 0 {
+			receipt := models.StockMovement{Kind: models.MovementReceipt, Reason: "initial stock"}
+			createdBook.StockLevels, err = tx.inventory.adjust(ctx, models.Book{ID: createdBook.ID}, createdBook.Stock, receipt)
 		}
+		return err
+	})
+	if err != nil {
+		return models.Book{}, err
+	}
+
+	log.Println("Book successfully created:", createdBook)
+	return createdBook, nil
+}
 
-		newAuthor, err := bs.authorStore.CreateAuthor(*book.Author)
+// resolveAuthor loads an existing author by ID or creates a new one from its names
+func (bs *BookService) resolveAuthor(ctx context.Context, authorID int, author *models.Author) (models.Author, error) {
+	if authorID > 0 {
+		existing, err := bs.authorStore.GetAuthor(authorID)
 		if err != nil {
-			return models.Book{}, fmt.Errorf("failed to create author: %w", err)
+			return models.Author{}, invalidf("author with ID %d does not exist: %w", authorID, err)
 		}
+		return existing, nil
+	}
 
-		// Assign correct AuthorID
-		book.AuthorID = newAuthor.ID
-		book.Author = &newAuthor
+	if author == nil || author.FirstName == "" || author.LastName == "" {
+		return models.Author{}, invalidf("author first name and last name cannot be empty")
 	}
 
-	createdBook, err := bs.store.CreateBook(book)
+	author.DeletedAt = time.Time{}
+	author.Version = 0
+	newAuthor, err := bs.authorStore.CreateAuthor(*author)
 	if err != nil {
-		return models.Book{}, err
+		return models.Author{}, fmt.Errorf("failed to create author: %w", err)
 	}
+	bs.audit.Record(ctx, models.AuditAuthor, newAuthor.ID, models.AuditCreate, nil, newAuthor)
+	return newAuthor, nil
+}
 
-	log.Println("Book successfully created:", createdBook)
-	return createdBook, nil
+// resolveContributors validates a contributor list and makes sure every
+// contributor points at an existing author
+func (bs *BookService) resolveContributors(ctx context.Context, contributors []models.BookContributor) ([]models.BookContributor, error) {
+	normalized, err := normalizeContributors(contributors)
+	if err != nil {
+		return nil, err
+	}
+	for i := range normalized {
+		author, err := bs.resolveAuthor(ctx, normalized[i].AuthorID, normalized[i].Author)
+		if err != nil {
+			return nil, fmt.Errorf("contributor %d: %w", i+1, err)
+		}
+		normalized[i].AuthorID = author.ID
+		normalized[i].Author = &author
+	}
+	if err := checkDuplicateContributors(normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// checkPublication makes sure the publisher and series a book points at exist,
+// that its backorder policy is known and that its price is in the store
+// currency
+func (bs *BookService) checkPublication(book *models.Book) error {
+	if err := storeAmount("price", &book.Price); err != nil {
+		return err
+	}
+	if book.BackorderPolicy == "" {
+		book.BackorderPolicy = models.BackorderNone
+	} else if !containsString(models.BackorderPolicies, book.BackorderPolicy) {
+		return invalidf("backorder policy must be one of %s", strings.Join(models.BackorderPolicies, ", "))
+	}
+	if book.PublisherID > 0 {
+		if _, err := bs.publisherStore.GetPublisher(book.PublisherID); err != nil {
+			return asInvalid(err)
+		}
+	}
+	if book.SeriesID > 0 {
+		if _, err := bs.seriesStore.GetSeries(book.SeriesID); err != nil {
+			return asInvalid(err)
+		}
+	} else if book.SeriesPosition != 0 {
+		return invalidf("series position requires a series")
+	}
+	if book.SeriesPosition < 0 {
+		return invalidf("series position cannot be negative")
+	}
+	book.Publisher = nil
+	book.Series = nil
+	book.Editions = nil
+	book.StockLevels = nil
+	// Covers are only set through the cover upload endpoint, and deletion
+	// only through DELETE and restore
+	book.Cover = models.BookCover{}
+	book.DeletedAt = time.Time{}
+	return nil
 }
 
 func (bs *BookService) GetBook(ctx context.Context, id int) (models.Book, error) {
@@ -74,6 +200,9 @@ func (bs *BookService) GetBook(ctx context.Context, id int) (models.Book, error)
 	return bs.store.GetBook(id)
 }
 
+// UpdateBook replaces a book if it is still at book.Version (0 for any). A
+// changed Stock, the movements recording it and the book are saved in one
+// transaction.
 func (bs *BookService) UpdateBook(ctx context.Context, id int, book models.Book) (models.Book, error) {
 	select {
 	case <-ctx.Done():
@@ -81,32 +210,162 @@ func (bs *BookService) UpdateBook(ctx context.Context, id int, book models.Book)
 	default:
 	}
 
+	// Checked again under lock; a stale request should not create authors
 	existingBook, err := bs.store.GetBook(id)
 	if err != nil {
-		return models.Book{}, fmt.Errorf("book with ID %d not found", id)
+		return models.Book{}, notFoundf("book with ID %d not found", id)
 	}
-	if book.AuthorID > 0 && book.AuthorID != existingBook.AuthorID {
-		_, err := bs.authorStore.GetAuthor(book.AuthorID)
+	if _, err := matchVersion(book.Version, existingBook.Version); err != nil {
+		return models.Book{}, err
+	}
+
+	if err := bs.checkPublication(&book); err != nil {
+		return models.Book{}, err
+	}
+	genres, err := resolveGenres(bs.genreStore, book.Genres)
+	if err != nil {
+		return models.Book{}, err
+	}
+	book.Genres = genres
+	if book.Contributors != nil {
+		// An explicit list replaces every contributor of the book
+		if book.Contributors, err = bs.resolveContributors(ctx, book.Contributors); err != nil {
+			return models.Book{}, err
+		}
+	}
+
+	var updatedBook models.Book
+	var delta int
+	err = bs.inTx(ctx, func(tx *BookService) error {
+		var err error
+		updatedBook, delta, err = tx.updateBook(ctx, id, book)
+		return err
+	})
+	if err != nil {
+		return models.Book{}, err
+	}
+	// Whoever waits for the book hears of new stock once it is committed
+	if delta > 0 {
+		updatedBook = bs.inventory.restocked(ctx, updatedBook)
+	}
+	return updatedBook, nil
+}
+
+// updateBook does the work of UpdateBook inside its transaction and returns
+// the updated book with the change to its stock
+func (bs *BookService) updateBook(ctx context.Context, id int, book models.Book) (models.Book, int, error) {
+	existingBook, err := bs.store.GetBookForUpdate(id)
+	if err != nil {
+		return models.Book{}, 0, notFoundf("book with ID %d not found", id)
+	}
+	if book.Version, err = matchVersion(book.Version, existingBook.Version); err != nil {
+		return models.Book{}, 0, err
+	}
+
+	switch {
+	case book.Contributors != nil:
+		book.AuthorID = book.Contributors[leadAuthorIndex(book.Contributors)].AuthorID
+	case book.AuthorID > 0 && book.AuthorID != existingBook.AuthorID:
+		author, err := bs.authorStore.GetAuthor(book.AuthorID)
 		if err != nil {
-			return models.Book{}, fmt.Errorf("author with ID %d does not exist", book.AuthorID)
+			return models.Book{}, 0, invalidf("author with ID %d does not exist", book.AuthorID)
 		}
+		// A merged author's old ID resolves to the surviving author
+		book.AuthorID = author.ID
+		book.Contributors = replaceLeadAuthor(existingBook.Contributors, book.AuthorID)
+	case book.AuthorID <= 0:
+		book.AuthorID = existingBook.AuthorID
 	}
 	book.ID = id
+	// The cost price only changes through goods receipts
+	book.CostPrice = existingBook.CostPrice
 	updatedBook, err := bs.store.UpdateBook(id, book)
 	if err != nil {
-		return models.Book{}, fmt.Errorf("error updating book: %w", err)
+		return models.Book{}, 0, fmt.Errorf("error updating book: %w", err)
 	}
+	bs.audit.Record(ctx, models.AuditBook, id, models.AuditUpdate, existingBook, updatedBook)
+	// A changed Stock is spread over the locations like a receipt or a sale
+	delta := updatedBook.Stock - existingBook.Stock
+	if delta != 0 {
+		adjustment := models.StockMovement{Kind: models.MovementAdjustment, Reason: "stock edited"}
+		updatedBook.StockLevels, err = bs.inventory.adjust(ctx, existingBook, delta, adjustment)
+		if err != nil {
+			return models.Book{}, 0, err
+		}
+	}
+	return updatedBook, delta, nil
+}
 
-	return updatedBook, nil
+// PatchBook applies a merge patch or JSON Patch (see contentType) to a book
+// and saves the result if it is still a valid book. version is the version
+// the patch was written against (0 for any).
+func (bs *BookService) PatchBook(ctx context.Context, id int, version int, contentType string, patch []byte) (models.Book, error) {
+	select {
+	case <-ctx.Done():
+		return models.Book{}, ctx.Err()
+	default:
+	}
+
+	existingBook, err := bs.store.GetBook(id)
+	if err != nil {
+		return models.Book{}, notFoundf("book with ID %d not found", id)
+	}
+	var book models.Book
+	if err := applyPatch(existingBook, contentType, patch, &book); err != nil {
+		return models.Book{}, err
+	}
+	if err := validatePatched(book, &models.BookRequest{}); err != nil {
+		return models.Book{}, err
+	}
+
+	// Contributors are only rewritten when the patch changed them, so that
+	// patching AuthorID alone still swaps the lead author
+	before, _ := json.Marshal(existingBook.Contributors)
+	after, _ := json.Marshal(book.Contributors)
+	if bytes.Equal(before, after) {
+		book.Contributors = nil
+	}
+
+	book.Version = version
+	return bs.UpdateBook(ctx, id, book)
 }
 
-func (bs *BookService) DeleteBook(ctx context.Context, id int) error {
+// DeleteBook soft-deletes a book if it is still at the given version (0 for any)
+func (bs *BookService) DeleteBook(ctx context.Context, id int, version int) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
-	return bs.store.DeleteBook(id)
+
+	existingBook, err := bs.store.GetBook(id)
+	if err != nil {
+		return notFoundf("book with ID %d not found", id)
+	}
+	if version, err = matchVersion(version, existingBook.Version); err != nil {
+		return err
+	}
+	if err := bs.store.DeleteBook(id, version); err != nil {
+		return err
+	}
+	bs.audit.Record(ctx, models.AuditBook, id, models.AuditDelete, existingBook, nil)
+	return nil
+}
+
+// RestoreBook brings back a soft-deleted book
+func (bs *BookService) RestoreBook(ctx context.Context, id int) (models.Book, error) {
+	select {
+	case <-ctx.Done():
+		return models.Book{}, ctx.Err()
+	default:
+	}
+
+	book, err := bs.store.RestoreBook(id)
+	if err != nil {
+		return models.Book{}, err
+	}
+	bs.audit.Record(ctx, models.AuditBook, id, models.AuditRestore, nil, book)
+	return book, nil
 }
 
 func (bs *BookService) SearchBooks(ctx context.Context, criteria models.SearchCriteria) ([]models.Book, error) {
@@ -117,3 +376,24 @@ func (bs *BookService) SearchBooks(ctx context.Context, criteria models.SearchCr
 	}
 	return bs.store.SearchBooks(criteria)
 }
+
+// replaceLeadAuthor swaps the lead author of an existing contributor list,
+// dropping any other author-role credit of the new lead
+func replaceLeadAuthor(existing []models.BookContributor, authorID int) []models.BookContributor {
+	contributors := make([]models.BookContributor, 0, len(existing)+1)
+	replaced := false
+	for _, c := range existing {
+		if c.Role == models.RoleAuthor && !replaced {
+			c.AuthorID = authorID
+			c.Author = nil
+			replaced = true
+		} else if c.Role == models.RoleAuthor && c.AuthorID == authorID {
+			continue
+		}
+		contributors = append(contributors, c)
+	}
+	if !replaced {
+		contributors = append([]models.BookContributor{{AuthorID: authorID, Role: models.RoleAuthor, Position: 0}}, contributors...)
+	}
+	return contributors
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// CouponService manages coupon codes and redeems them for orders
+type CouponService struct {
+	store repositories.CouponStore
+	audit *AuditService
+}
+
+func NewCouponService(store repositories.CouponStore, audit *AuditService) *CouponService {
+	return &CouponService{store: store, audit: audit}
+}
+
+// withTx returns a copy of the service whose store works inside tx
+func (s *CouponService) withTx(tx bun.IDB) *CouponService {
+	c := *s
+	c.store = s.store.WithTx(tx)
+	c.audit = s.audit.withTx(tx)
+	return &c
+}
+
+// CreateCoupon inserts a new coupon
+func (s *CouponService) CreateCoupon(ctx context.Context, coupon models.Coupon) (models.Coupon, error) {
+	select {
+	case <-ctx.Done():
+		return models.Coupon{}, ctx.Err()
+	default:
+	}
+
+	if err := normalizeCoupon(&coupon); err != nil {
+		return models.Coupon{}, err
+	}
+	coupon.ID = 0
+	coupon.CreatedAt = time.Time{}
+	created, err := s.store.CreateCoupon(coupon)
+	if err != nil {
+		return models.Coupon{}, err
+	}
+	s.audit.Record(ctx, models.AuditCoupon, created.ID, models.AuditCreate, nil, created)
+	return created, nil
+}
+
+// GetCoupon retrieves a coupon by ID
+func (s *CouponService) GetCoupon(ctx context.Context, id int) (models.Coupon, error) {
+	select {
+	case <-ctx.Done():
+		return models.Coupon{}, ctx.Err()
+	default:
+	}
+	return s.store.GetCoupon(id)
+}
+
+// UpdateCoupon modifies an existing coupon. Lowering a limit below the
+// redemptions already made only stops further ones.
+func (s *CouponService) UpdateCoupon(ctx context.Context, id int, coupon models.Coupon) (models.Coupon, error) {
+	select {
+	case <-ctx.Done():
+		return models.Coupon{}, ctx.Err()
+	default:
+	}
+
+	if err := normalizeCoupon(&coupon); err != nil {
+		return models.Coupon{}, err
+	}
+	existing, err := s.store.GetCoupon(id)
+	if err != nil {
+		return models.Coupon{}, err
+	}
+	updated, err := s.store.UpdateCoupon(id, coupon)
+	if err != nil {
+		return models.Coupon{}, err
+	}
+	s.audit.Record(ctx, models.AuditCoupon, id, models.AuditUpdate, existing, updated)
+	return updated, nil
+}
+
+// DeleteCoupon removes a coupon that was never redeemed
+func (s *CouponService) DeleteCoupon(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetCoupon(id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteCoupon(id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditCoupon, id, models.AuditDelete, existing, nil)
+	return nil
+}
+
+// ListCoupons retrieves every coupon
+func (s *CouponService) ListCoupons(ctx context.Context) ([]models.Coupon, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	return s.store.ListCoupons()
+}
+
+// ListRedemptions retrieves the redemptions of a coupon, newest first
+func (s *CouponService) ListRedemptions(ctx context.Context, couponID int) ([]models.CouponRedemption, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if _, err := s.store.GetCoupon(couponID); err != nil {
+		return nil, err
+	}
+	return s.store.ListRedemptions(couponID)
+}
+
+// CouponUsage reports how often each coupon was redeemed, by how many
+// customers and for how much, on the orders placed between from and to
+func (s *CouponService) CouponUsage(ctx context.Context, from, to time.Time) ([]models.CouponUsage, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if to.Before(from) {
+		return nil, invalidf("'to' must not be before 'from'")
+	}
+	return s.store.CouponUsage(from, to)
+}
+
+// find looks up the coupon an order gives. It must not have expired.
+func (s *CouponService) find(code string) (models.Coupon, error) {
+	code = normalizeCouponCode(code)
+	coupon, err := s.store.FindCoupon(code)
+	if err != nil {
+		return models.Coupon{}, asInvalid(err)
+	}
+	if !coupon.ExpiresAt.IsZero() && !time.Now().Before(coupon.ExpiresAt) {
+		return models.Coupon{}, invalidf("coupon %q has expired", coupon.Code)
+	}
+	if err := inStoreCurrency(fmt.Sprintf("coupon %q", coupon.Code), coupon.Amount, coupon.MinOrderValue); err != nil {
+		return models.Coupon{}, err
+	}
+	return coupon, nil
+}
+
+// redeem takes one use of a coupon for a user's order worth subtotal
+// after promotions, and returns the redemption and the amount taken off.
+// The order is in a currency that rate units of buy one unit of the store
+// currency.
+func (s *CouponService) redeem(coupon models.Coupon, userID int, subtotal models.Money, rate float64) (models.CouponRedemption, models.Money, error) {
+	minimum := coupon.MinOrderValue.Convert(subtotal.Currency, rate)
+	if subtotal.Less(minimum) {
+		return models.CouponRedemption{}, models.Money{}, invalidf("coupon %q needs an order of at least %s", coupon.Code, minimum)
+	}
+	redemption, err := s.store.Redeem(coupon.ID, userID)
+	if err != nil {
+		return models.CouponRedemption{}, models.Money{}, err
+	}
+	return redemption, couponAmount(coupon, subtotal, rate), nil
+}
+
+// attach records the order a redemption was used for
+func (s *CouponService) attach(redemption models.CouponRedemption, orderID int) error {
+	return s.store.AttachRedemption(redemption.ID, orderID)
+}
+
+// reprice works out the coupon discount of an order that changed. The
+// coupon was redeemed when the order was placed, so only the minimum order
+// value still applies; a coupon deleted since keeps its earlier amount.
+func (s *CouponService) reprice(code string, previous, subtotal models.Money, rate float64) (models.Money, error) {
+	if err := inCurrency("the coupon discount of the order", subtotal.Currency, previous); err != nil {
+		return models.Money{}, err
+	}
+	coupon, err := s.store.FindCoupon(code)
+	if err != nil {
+		return previous.Min(subtotal), nil
+	}
+	if err := inStoreCurrency(fmt.Sprintf("coupon %q", coupon.Code), coupon.Amount, coupon.MinOrderValue); err != nil {
+		return models.Money{}, err
+	}
+	if subtotal.Less(coupon.MinOrderValue.Convert(subtotal.Currency, rate)) {
+		return models.Money{Currency: subtotal.Currency}, nil
+	}
+	return couponAmount(coupon, subtotal, rate), nil
+}
+
+// couponAmount is what a coupon takes off an order worth subtotal. The
+// amount of a fixed coupon is in the store currency and is converted at
+// rate.
+func couponAmount(coupon models.Coupon, subtotal models.Money, rate float64) models.Money {
+	switch coupon.Kind {
+	case models.CouponPercentage:
+		return subtotal.Percent(coupon.Value)
+	case models.CouponFixed:
+		return coupon.Amount.Convert(subtotal.Currency, rate).Min(subtotal)
+	}
+	return models.Money{Currency: subtotal.Currency}
+}
+
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// normalizeCoupon upper-cases a coupon's code and checks its rule
+func normalizeCoupon(coupon *models.Coupon) error {
+	coupon.Code = normalizeCouponCode(coupon.Code)
+	coupon.Description = strings.TrimSpace(coupon.Description)
+	if coupon.Code == "" || strings.ContainsAny(coupon.Code, " \t\n") {
+		return invalidf("coupon code must be a single word")
+	}
+	switch coupon.Kind {
+	case models.CouponPercentage:
+		if coupon.Value <= 0 || coupon.Value > 100 {
+			return invalidf("a percentage coupon must take between 0 and 100 percent off")
+		}
+		coupon.Amount = models.Money{Currency: models.DefaultCurrency}
+	case models.CouponFixed:
+		if err := storeAmount("coupon amount", &coupon.Amount); err != nil {
+			return err
+		}
+		if coupon.Amount.Sign() <= 0 {
+			return invalidf("a fixed coupon must take a positive amount off")
+		}
+		coupon.Value = 0
+	default:
+		return invalidf("coupon kind must be one of %s", strings.Join(models.CouponKinds, ", "))
+	}
+	if err := storeAmount("minimum order value", &coupon.MinOrderValue); err != nil {
+		return err
+	}
+	if coupon.MaxRedemptions < 0 || coupon.PerCustomerLimit < 0 {
+		return invalidf("coupon limits cannot be negative")
+	}
+	return nil
+}
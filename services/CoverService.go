@@ -0,0 +1,195 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // register PNG decoding
+	"log"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register WebP decoding
+)
+
+// CoverService validates uploaded cover images, renders the medium and
+// thumbnail sizes and stores all three in the blob store
+type CoverService struct {
+	bookStore repositories.BookStore
+	blobs     repositories.BlobStore
+	audit     *AuditService
+}
+
+func NewCoverService(bookStore repositories.BookStore, blobs repositories.BlobStore, audit *AuditService) *CoverService {
+	return &CoverService{bookStore: bookStore, blobs: blobs, audit: audit}
+}
+
+// coverExtensions maps accepted content types to the original's file extension
+var coverExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/webp": "webp",
+}
+
+// SetCover replaces the cover of a book with the uploaded image. The content
+// type is sniffed from the data; the client-declared type is ignored.
+func (s *CoverService) SetCover(ctx context.Context, bookID int, data []byte) (models.Book, error) {
+	select {
+	case <-ctx.Done():
+		return models.Book{}, ctx.Err()
+	default:
+	}
+
+	book, err := s.bookStore.GetBook(bookID)
+	if err != nil {
+		return models.Book{}, notFoundf("book with ID %d not found", bookID)
+	}
+
+	if len(data) == 0 {
+		return models.Book{}, invalidf("cover image is empty")
+	}
+	if len(data) > models.MaxCoverBytes {
+		return models.Book{}, invalidf("cover image exceeds %d bytes", models.MaxCoverBytes)
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := coverExtensions[contentType]
+	if !ok {
+		return models.Book{}, invalidf("unsupported cover type %s, expected JPEG, PNG or WebP", contentType)
+	}
+
+	// Check the dimensions before decoding the pixels
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return models.Book{}, invalidf("invalid cover image: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || float64(cfg.Width)*float64(cfg.Height) > models.MaxCoverPixels {
+		return models.Book{}, invalidf("cover image is %dx%d, larger than %.0f pixels", cfg.Width, cfg.Height, float64(models.MaxCoverPixels))
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return models.Book{}, invalidf("invalid cover image: %w", err)
+	}
+
+	medium, err := renderCover(img, models.CoverMediumSize)
+	if err != nil {
+		return models.Book{}, err
+	}
+	thumbnail, err := renderCover(img, models.CoverThumbnailSize)
+	if err != nil {
+		return models.Book{}, err
+	}
+
+	// Every upload gets a fresh key so cached URLs never show a stale cover
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return models.Book{}, err
+	}
+	prefix := fmt.Sprintf("covers/%d/%s", bookID, hex.EncodeToString(token))
+	cover := models.BookCover{
+		Key:          prefix,
+		URL:          s.blobs.URL(prefix + "/original." + ext),
+		MediumURL:    s.blobs.URL(prefix + "/medium.jpg"),
+		ThumbnailURL: s.blobs.URL(prefix + "/thumbnail.jpg"),
+	}
+
+	blobs := []struct {
+		key, contentType string
+		data             []byte
+	}{
+		{prefix + "/original." + ext, contentType, data},
+		{prefix + "/medium.jpg", "image/jpeg", medium},
+		{prefix + "/thumbnail.jpg", "image/jpeg", thumbnail},
+	}
+	for i, b := range blobs {
+		if err := s.blobs.Put(ctx, b.key, b.contentType, b.data); err != nil {
+			for _, stored := range blobs[:i] {
+				s.blobs.Delete(ctx, stored.key)
+			}
+			return models.Book{}, err
+		}
+	}
+
+	updated, err := s.bookStore.SetBookCover(bookID, cover)
+	if err != nil {
+		s.deleteCoverBlobs(ctx, cover)
+		return models.Book{}, err
+	}
+	s.deleteCoverBlobs(ctx, book.Cover)
+	s.audit.Record(ctx, models.AuditBook, bookID, models.AuditUpdate, book, updated)
+	return updated, nil
+}
+
+// DeleteCover removes the cover of a book
+func (s *CoverService) DeleteCover(ctx context.Context, bookID int) (models.Book, error) {
+	select {
+	case <-ctx.Done():
+		return models.Book{}, ctx.Err()
+	default:
+	}
+
+	book, err := s.bookStore.GetBook(bookID)
+	if err != nil {
+		return models.Book{}, notFoundf("book with ID %d not found", bookID)
+	}
+	if book.Cover.Key == "" {
+		return models.Book{}, notFoundf("book with ID %d has no cover", bookID)
+	}
+
+	updated, err := s.bookStore.SetBookCover(bookID, models.BookCover{})
+	if err != nil {
+		return models.Book{}, err
+	}
+	s.deleteCoverBlobs(ctx, book.Cover)
+	s.audit.Record(ctx, models.AuditBook, bookID, models.AuditUpdate, book, updated)
+	return updated, nil
+}
+
+// deleteCoverBlobs removes the renditions of a replaced cover. Failures
+// only leave orphaned files behind, so they are logged and not returned.
+func (s *CoverService) deleteCoverBlobs(ctx context.Context, cover models.BookCover) {
+	if cover.Key == "" {
+		return
+	}
+	keys := []string{cover.Key + "/medium.jpg", cover.Key + "/thumbnail.jpg"}
+	for _, ext := range coverExtensions {
+		keys = append(keys, cover.Key+"/original."+ext)
+	}
+	for _, key := range keys {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			log.Println("Failed to delete old cover blob:", err)
+		}
+	}
+}
+
+// renderCover scales img so its longest side is at most size pixels and
+// encodes it as JPEG. Transparent areas are flattened onto white.
+func renderCover(img image.Image, size int) ([]byte, error) {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > size || h > size {
+		if w >= h {
+			h = max(1, h*size/w)
+			w = size
+		} else {
+			w = max(1, w*size/h)
+			h = size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, fmt.Errorf("error encoding cover rendition: %w", err)
+	}
+	return buf.Bytes(), nil
+}
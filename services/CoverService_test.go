@@ -0,0 +1,197 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+// coverBookStore keeps one book in memory; the methods SetCover does not
+// use are left to the embedded nil interface
+type coverBookStore struct {
+	repositories.BookStore
+	book models.Book
+}
+
+func (s *coverBookStore) GetBook(id int) (models.Book, error) {
+	if id != s.book.ID {
+		return models.Book{}, repositories.NotFoundf("book with ID %d not found", id)
+	}
+	return s.book, nil
+}
+
+func (s *coverBookStore) SetBookCover(id int, cover models.BookCover) (models.Book, error) {
+	s.book.Cover = cover
+	return s.book, nil
+}
+
+type memoryBlob struct {
+	contentType string
+	data        []byte
+}
+
+// memoryBlobStore is a BlobStore in a map
+type memoryBlobStore struct {
+	blobs map[string]memoryBlob
+}
+
+func (s *memoryBlobStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	s.blobs[key] = memoryBlob{contentType: contentType, data: data}
+	return nil
+}
+
+func (s *memoryBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	b, ok := s.blobs[key]
+	if !ok {
+		return nil, repositories.ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(b.data)), nil
+}
+
+func (s *memoryBlobStore) Delete(ctx context.Context, key string) error {
+	delete(s.blobs, key)
+	return nil
+}
+
+func (s *memoryBlobStore) URL(key string) string {
+	return "https://cdn.example.com/" + key
+}
+
+func newTestCoverService(book models.Book) (*CoverService, *coverBookStore, *memoryBlobStore) {
+	books := &coverBookStore{book: book}
+	blobs := &memoryBlobStore{blobs: map[string]memoryBlob{}}
+	return NewCoverService(books, blobs, nil), books, blobs
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func jpegSize(t *testing.T, data []byte) (int, int) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("rendition is not a JPEG: %v", err)
+	}
+	return cfg.Width, cfg.Height
+}
+
+func TestSetCoverStoresRenditions(t *testing.T) {
+	old := models.BookCover{Key: "covers/1/old"}
+	service, _, blobs := newTestCoverService(models.Book{ID: 1, Title: "Dune", Cover: old})
+	blobs.blobs[old.Key+"/original.jpg"] = memoryBlob{}
+	blobs.blobs[old.Key+"/medium.jpg"] = memoryBlob{}
+	blobs.blobs[old.Key+"/thumbnail.jpg"] = memoryBlob{}
+
+	book, err := service.SetCover(context.Background(), 1, testPNG(t, 1200, 800))
+	if err != nil {
+		t.Fatalf("SetCover: %v", err)
+	}
+
+	cover := book.Cover
+	if !strings.HasPrefix(cover.Key, "covers/1/") || cover.Key == old.Key {
+		t.Fatalf("cover key = %q, want a fresh key under covers/1/", cover.Key)
+	}
+	if cover.URL != "https://cdn.example.com/"+cover.Key+"/original.png" ||
+		cover.MediumURL != "https://cdn.example.com/"+cover.Key+"/medium.jpg" ||
+		cover.ThumbnailURL != "https://cdn.example.com/"+cover.Key+"/thumbnail.jpg" {
+		t.Errorf("cover URLs = %+v", cover)
+	}
+
+	if len(blobs.blobs) != 3 {
+		t.Errorf("stored %d blobs, want the 3 new renditions only", len(blobs.blobs))
+	}
+	if original := blobs.blobs[cover.Key+"/original.png"]; original.contentType != "image/png" {
+		t.Errorf("original stored as %q, want image/png", original.contentType)
+	}
+	sizes := map[string][2]int{
+		"medium.jpg":    {models.CoverMediumSize, models.CoverMediumSize * 800 / 1200},
+		"thumbnail.jpg": {models.CoverThumbnailSize, models.CoverThumbnailSize * 800 / 1200},
+	}
+	for name, want := range sizes {
+		b := blobs.blobs[cover.Key+"/"+name]
+		if b.contentType != "image/jpeg" {
+			t.Errorf("%s stored as %q, want image/jpeg", name, b.contentType)
+		}
+		if w, h := jpegSize(t, b.data); w != want[0] || h != want[1] {
+			t.Errorf("%s is %dx%d, want %dx%d", name, w, h, want[0], want[1])
+		}
+	}
+}
+
+func TestSetCoverKeepsSmallImagesAndPortraitShape(t *testing.T) {
+	service, _, blobs := newTestCoverService(models.Book{ID: 1})
+
+	book, err := service.SetCover(context.Background(), 1, testPNG(t, 150, 300))
+	if err != nil {
+		t.Fatalf("SetCover: %v", err)
+	}
+	if w, h := jpegSize(t, blobs.blobs[book.Cover.Key+"/medium.jpg"].data); w != 150 || h != 300 {
+		t.Errorf("medium is %dx%d, want the original 150x300", w, h)
+	}
+	if w, h := jpegSize(t, blobs.blobs[book.Cover.Key+"/thumbnail.jpg"].data); w != 100 || h != 200 {
+		t.Errorf("thumbnail is %dx%d, want 100x200", w, h)
+	}
+}
+
+func TestSetCoverRejectsUploads(t *testing.T) {
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	tooLarge := append(testPNG(t, 10, 10), make([]byte, models.MaxCoverBytes)...)
+	// A PNG whose header claims 10000x10000, more than MaxCoverPixels
+	bomb := testPNG(t, 1, 1)
+	binary.BigEndian.PutUint32(bomb[16:], 10000)
+	binary.BigEndian.PutUint32(bomb[20:], 10000)
+	binary.BigEndian.PutUint32(bomb[29:], crc32.ChecksumIEEE(bomb[12:29]))
+
+	cases := []struct {
+		name   string
+		data   []byte
+		reason string
+	}{
+		{"empty", nil, "empty"},
+		{"too large", tooLarge, "exceeds"},
+		{"gif sniffed", gif, "unsupported cover type image/gif"},
+		{"text", []byte("<html>not an image</html>"), "unsupported cover type text/html"},
+		{"truncated png", testPNG(t, 10, 10)[:40], "invalid cover image"},
+		{"too many pixels", bomb, "10000x10000"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			service, books, blobs := newTestCoverService(models.Book{ID: 1})
+			_, err := service.SetCover(context.Background(), 1, c.data)
+			if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), c.reason) {
+				t.Fatalf("got %v, want a validation error about %q", err, c.reason)
+			}
+			if len(blobs.blobs) != 0 || books.book.Cover.Key != "" {
+				t.Errorf("rejected upload left %d blobs and cover %+v", len(blobs.blobs), books.book.Cover)
+			}
+		})
+	}
+}
+
+func TestSetCoverUnknownBook(t *testing.T) {
+	service, _, _ := newTestCoverService(models.Book{ID: 1})
+	if _, err := service.SetCover(context.Background(), 2, testPNG(t, 10, 10)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want a not found error", err)
+	}
+}
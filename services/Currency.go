@@ -0,0 +1,54 @@
+package services
+
+import (
+	"FinalProject/models"
+	"log"
+	"os"
+	"strings"
+)
+
+// CurrencyFromEnv reads the store currency from STORE_CURRENCY
+func CurrencyFromEnv() string {
+	currency := strings.ToUpper(strings.TrimSpace(os.Getenv("STORE_CURRENCY")))
+	if currency == "" {
+		return models.DefaultCurrency
+	}
+	if !models.ValidCurrency(currency) {
+		log.Printf("Invalid STORE_CURRENCY %q, using %s", currency, models.DefaultCurrency)
+		return models.DefaultCurrency
+	}
+	return currency
+}
+
+// storeAmount checks that an amount given by a client is not negative and
+// is in the store currency, which it takes when it has none
+func storeAmount(what string, amount *models.Money) error {
+	if amount.Currency == "" {
+		amount.Currency = models.DefaultCurrency
+	}
+	if amount.Currency != models.DefaultCurrency {
+		return invalidf("%s must be in %s", what, models.DefaultCurrency)
+	}
+	if amount.Sign() < 0 {
+		return invalidf("%s cannot be negative", what)
+	}
+	return nil
+}
+
+// inStoreCurrency checks that amounts read back from the database are in
+// the store currency before they are combined with others in it. Amounts
+// saved before STORE_CURRENCY was changed are not, and cannot be added to
+// or converted like amounts in the new currency.
+func inStoreCurrency(what string, amounts ...models.Money) error {
+	return inCurrency(what, models.DefaultCurrency, amounts...)
+}
+
+// inCurrency checks that amounts read back from the database are in
+// currency. A mismatch is a conflict between the stored data and the
+// request, matching both ErrConflict and models.ErrCurrencyMismatch.
+func inCurrency(what, currency string, amounts ...models.Money) error {
+	if err := models.InCurrency(currency, amounts...); err != nil {
+		return conflictf("%s: %w", what, err)
+	}
+	return nil
+}
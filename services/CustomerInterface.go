@@ -8,10 +8,11 @@ import (
 
 type CustomerService struct {
 	store repositories.CustomerStore
+	audit *AuditService
 }
 
-func NewCustomerService(store repositories.CustomerStore) *CustomerService {
-	return &CustomerService{store: store}
+func NewCustomerService(store repositories.CustomerStore, audit *AuditService) *CustomerService {
+	return &CustomerService{store: store, audit: audit}
 }
 
 
@@ -30,23 +31,92 @@ func (s *CustomerService) UpdateCustomer(ctx context.Context, id int, c models.U
 		return models.User{}, ctx.Err()
 	default:
 	}
-	return s.store.UpdateCustomer(id, c)
+
+	existing, err := s.store.GetCustomer(id)
+	if err != nil {
+		return models.User{}, err
+	}
+	if c.Version, err = matchVersion(c.Version, existing.Version); err != nil {
+		return models.User{}, err
+	}
+	updated, err := s.store.UpdateCustomer(id, c)
+	if err != nil {
+		return models.User{}, err
+	}
+	// Only some columns are written, so the stored row is what gets audited
+	if current, err := s.store.GetCustomer(id); err == nil {
+		s.audit.Record(ctx, models.AuditCustomer, id, models.AuditUpdate, existing, current)
+	}
+	return updated, nil
+}
+
+// PatchCustomer applies a merge patch or JSON Patch to a customer's profile
+func (s *CustomerService) PatchCustomer(ctx context.Context, id int, version int, contentType string, patch []byte) (models.User, error) {
+	select {
+	case <-ctx.Done():
+		return models.User{}, ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetCustomer(id)
+	if err != nil {
+		return models.User{}, err
+	}
+	var c models.User
+	if err := applyPatch(existing, contentType, patch, &c); err != nil {
+		return models.User{}, err
+	}
+	if err := validatePatched(c, &models.CustomerRequest{}); err != nil {
+		return models.User{}, err
+	}
+
+	c.Version = version
+	return s.UpdateCustomer(ctx, id, c)
 }
 
-func (s *CustomerService) DeleteCustomer(ctx context.Context, id int) error {
+// DeleteCustomer soft-deletes a customer if they are still at the given version (0 for any)
+func (s *CustomerService) DeleteCustomer(ctx context.Context, id int, version int) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
-	return s.store.DeleteCustomer(id)
+
+	existing, err := s.store.GetCustomer(id)
+	if err != nil {
+		return err
+	}
+	if version, err = matchVersion(version, existing.Version); err != nil {
+		return err
+	}
+	if err := s.store.DeleteCustomer(id, version); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditCustomer, id, models.AuditDelete, existing, nil)
+	return nil
 }
 
-func (s *CustomerService) ListCustomers(ctx context.Context) ([]models.User, error) {
+func (s *CustomerService) ListCustomers(ctx context.Context, includeDeleted bool) ([]models.User, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	default:
 	}
-	return s.store.ListCustomers()
+	return s.store.ListCustomers(includeDeleted)
+}
+
+// RestoreCustomer brings back a soft-deleted customer
+func (s *CustomerService) RestoreCustomer(ctx context.Context, id int) (models.User, error) {
+	select {
+	case <-ctx.Done():
+		return models.User{}, ctx.Err()
+	default:
+	}
+
+	customer, err := s.store.RestoreCustomer(id)
+	if err != nil {
+		return models.User{}, err
+	}
+	s.audit.Record(ctx, models.AuditCustomer, id, models.AuditRestore, nil, customer)
+	return customer, nil
 }
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"context"
+	"strings"
+)
+
+type EditionService struct {
+	store     repositories.EditionStore
+	bookStore repositories.BookStore
+	inventory *InventoryService
+	audit     *AuditService
+}
+
+func NewEditionService(store repositories.EditionStore, bookStore repositories.BookStore, inventory *InventoryService, audit *AuditService) *EditionService {
+	return &EditionService{store: store, bookStore: bookStore, inventory: inventory, audit: audit}
+}
+
+// CreateEdition adds a new format of an existing book
+func (s *EditionService) CreateEdition(ctx context.Context, bookID int, edition models.Edition) (models.Edition, error) {
+	select {
+	case <-ctx.Done():
+		return models.Edition{}, ctx.Err()
+	default:
+	}
+
+	if _, err := s.bookStore.GetBook(bookID); err != nil {
+		return models.Edition{}, notFoundf("book with ID %d not found", bookID)
+	}
+	edition.BookID = bookID
+	if err := validateEdition(&edition); err != nil {
+		return models.Edition{}, err
+	}
+
+	created, err := s.store.CreateEdition(edition)
+	if err != nil {
+		return models.Edition{}, err
+	}
+	s.audit.Record(ctx, models.AuditEdition, created.ID, models.AuditCreate, nil, created)
+	if created.Stock > 0 {
+		err := s.inventory.record(ctx, models.StockMovement{
+			BookID:    bookID,
+			EditionID: created.ID,
+			Kind:      models.MovementReceipt,
+			Quantity:  created.Stock,
+			Reason:    "initial stock",
+		})
+		if err != nil {
+			return models.Edition{}, err
+		}
+	}
+	return created, nil
+}
+
+// GetEdition retrieves an edition by ID
+func (s *EditionService) GetEdition(ctx context.Context, id int) (models.Edition, error) {
+	select {
+	case <-ctx.Done():
+		return models.Edition{}, ctx.Err()
+	default:
+	}
+	return s.store.GetEdition(id)
+}
+
+// GetEditionByISBN retrieves an edition by ISBN-10 or ISBN-13
+func (s *EditionService) GetEditionByISBN(ctx context.Context, isbn string) (models.Edition, error) {
+	select {
+	case <-ctx.Done():
+		return models.Edition{}, ctx.Err()
+	default:
+	}
+
+	normalized, err := NormalizeISBN(isbn)
+	if err != nil {
+		return models.Edition{}, err
+	}
+	return s.store.GetEditionByISBN(normalized)
+}
+
+// UpdateEdition modifies an edition; the owning book cannot be changed
+func (s *EditionService) UpdateEdition(ctx context.Context, id int, edition models.Edition) (models.Edition, error) {
+	select {
+	case <-ctx.Done():
+		return models.Edition{}, ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetEdition(id)
+	if err != nil {
+		return models.Edition{}, err
+	}
+	edition.BookID = existing.BookID
+	if err := validateEdition(&edition); err != nil {
+		return models.Edition{}, err
+	}
+
+	updated, err := s.store.UpdateEdition(id, edition)
+	if err != nil {
+		return models.Edition{}, err
+	}
+	s.audit.Record(ctx, models.AuditEdition, id, models.AuditUpdate, existing, updated)
+	if delta := updated.Stock - existing.Stock; delta != 0 {
+		err := s.inventory.record(ctx, models.StockMovement{
+			BookID:    updated.BookID,
+			EditionID: id,
+			Kind:      models.MovementAdjustment,
+			Quantity:  delta,
+			Reason:    "stock edited",
+		})
+		if err != nil {
+			return models.Edition{}, err
+		}
+	}
+	return updated, nil
+}
+
+// DeleteEdition removes an edition that has never been ordered
+func (s *EditionService) DeleteEdition(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetEdition(id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteEdition(id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditEdition, id, models.AuditDelete, existing, nil)
+	return nil
+}
+
+// ListEditions retrieves every edition of a book
+func (s *EditionService) ListEditions(ctx context.Context, bookID int) ([]models.Edition, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if _, err := s.bookStore.GetBook(bookID); err != nil {
+		return nil, notFoundf("book with ID %d not found", bookID)
+	}
+	return s.store.ListEditions(bookID)
+}
+
+func validateEdition(edition *models.Edition) error {
+	edition.Format = strings.ToLower(strings.TrimSpace(edition.Format))
+	if !models.IsEditionFormat(edition.Format) {
+		return invalidf("edition format must be one of %s", strings.Join(models.EditionFormats, ", "))
+	}
+	if err := storeAmount("edition price", &edition.Price); err != nil {
+		return err
+	}
+	if edition.Stock < 0 {
+		return invalidf("edition stock cannot be negative")
+	}
+	if edition.ISBN != "" {
+		isbn, err := NormalizeISBN(edition.ISBN)
+		if err != nil {
+			return err
+		}
+		edition.ISBN = isbn
+	}
+	return nil
+}
+
+// NormalizeISBN strips separators, checks the check digit and converts
+// ISBN-10 to ISBN-13 so every edition is stored in the same form
+func NormalizeISBN(isbn string) (string, error) {
+	digits := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(isbn)))
+
+	switch len(digits) {
+	case 10:
+		sum := 0
+		for i, c := range digits {
+			var v int
+			switch {
+			case c >= '0' && c <= '9':
+				v = int(c - '0')
+			case c == 'X' && i == 9:
+				v = 10
+			default:
+				return "", invalidf("invalid ISBN %q", isbn)
+			}
+			sum += (10 - i) * v
+		}
+		if sum%11 != 0 {
+			return "", invalidf("invalid ISBN %q: wrong check digit", isbn)
+		}
+		return isbn13FromBody("978" + digits[:9]), nil
+	case 13:
+		for _, c := range digits {
+			if c < '0' || c > '9' {
+				return "", invalidf("invalid ISBN %q", isbn)
+			}
+		}
+		if isbn13FromBody(digits[:12]) != digits {
+			return "", invalidf("invalid ISBN %q: wrong check digit", isbn)
+		}
+		return digits, nil
+	}
+	return "", invalidf("invalid ISBN %q: expected 10 or 13 digits", isbn)
+}
+
+// isbn13FromBody appends the ISBN-13 check digit to a 12-digit body
+func isbn13FromBody(body string) string {
+	sum := 0
+	for i, c := range body {
+		v := int(c - '0')
+		if i%2 == 1 {
+			v *= 3
+		}
+		sum += v
+	}
+	return body + string(rune('0'+(10-sum%10)%10))
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"FinalProject/repositories"
+	"errors"
+)
+
+// Error kinds of the services; see repositories.ErrNotFound and friends.
+// Match them with errors.Is.
+var (
+	ErrNotFound     = repositories.ErrNotFound
+	ErrConflict     = repositories.ErrConflict
+	ErrValidation   = repositories.ErrValidation
+	ErrForbidden    = repositories.ErrForbidden
+	ErrUnauthorized = repositories.ErrUnauthorized
+)
+
+var (
+	notFoundf     = repositories.NotFoundf
+	conflictf     = repositories.Conflictf
+	invalidf      = repositories.Invalidf
+	unauthorizedf = repositories.Unauthorizedf
+)
+
+// asInvalid reports a record that a request refers to, e.g. the publisher of
+// a book, and that does not exist as an invalid request rather than as a
+// missing resource
+func asInvalid(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return invalidf("%w", err)
+	}
+	return err
+}
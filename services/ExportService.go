@@ -0,0 +1,268 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrExportJobNotFound = notFoundf("export job not found")
+
+// exportJobTimeout bounds how long a single background export may run
+const exportJobTimeout = time.Hour
+
+// DefaultExportRetention is how long a finished background export and its
+// file are kept
+const DefaultExportRetention = 24 * time.Hour
+
+// ExportService streams catalog, customer and order data in CSV, NDJSON or
+// XLSX, either directly into a response or as a background job writing to
+// disk. Finished jobs and their files are kept for retention, then removed
+// by RemoveExpiredJobs.
+type ExportService struct {
+	store     repositories.ExportStore
+	outputDir string
+	retention time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*models.ExportJob
+}
+
+func NewExportService(store repositories.ExportStore, outputDir string, retention time.Duration) *ExportService {
+	if store == nil {
+		log.Fatal("ERROR: ExportStore is nil in ExportService")
+	}
+	if retention <= 0 {
+		retention = DefaultExportRetention
+	}
+	return &ExportService{store: store, outputDir: outputDir, retention: retention, jobs: make(map[string]*models.ExportJob)}
+}
+
+// Export writes every matching row of entity to w in the given format and
+// returns the number of data rows written
+func (s *ExportService) Export(ctx context.Context, entity, format string, filter models.ExportFilter, w io.Writer) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+	}
+
+	stream, header, err := s.streamFor(entity)
+	if err != nil {
+		return 0, err
+	}
+	writer, err := newRecordWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+	if err := writer.WriteHeader(header); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = stream(ctx, filter, func(rec models.ExportRecord) error {
+		count++
+		return writer.WriteRow(rec.ExportValues())
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, writer.Close()
+}
+
+func (s *ExportService) streamFor(entity string) (func(context.Context, models.ExportFilter, func(models.ExportRecord) error) error, []string, error) {
+	switch entity {
+	case "books":
+		return s.store.StreamBooks, models.BookExportRow{}.ExportHeader(), nil
+	case "authors":
+		return s.store.StreamAuthors, models.AuthorExportRow{}.ExportHeader(), nil
+	case "customers":
+		return s.store.StreamCustomers, models.CustomerExportRow{}.ExportHeader(), nil
+	case "orders":
+		return s.store.StreamOrders, models.OrderExportRow{}.ExportHeader(), nil
+	}
+	return nil, nil, invalidf("unknown export entity %q", entity)
+}
+
+// StartExportJob queues an export that runs in the background and writes
+// its output under the service's output directory
+func (s *ExportService) StartExportJob(entity, format string, filter models.ExportFilter) (models.ExportJob, error) {
+	if _, _, err := s.streamFor(entity); err != nil {
+		return models.ExportJob{}, err
+	}
+	_, ext, err := ExportContentType(format)
+	if err != nil {
+		return models.ExportJob{}, err
+	}
+
+	id, err := newExportJobID()
+	if err != nil {
+		return models.ExportJob{}, err
+	}
+
+	job := &models.ExportJob{
+		ID:        id,
+		Entity:    entity,
+		Format:    format,
+		Filter:    filter,
+		Status:    models.ExportJobQueued,
+		CreatedAt: time.Now().UTC(),
+		FilePath:  filepath.Join(s.outputDir, fmt.Sprintf("%s_%s.%s", entity, id, ext)),
+	}
+
+	s.mu.Lock()
+	s.jobs[id] = job
+	snapshot := *job
+	s.mu.Unlock()
+
+	go s.runExportJob(job)
+	return snapshot, nil
+}
+
+func (s *ExportService) runExportJob(job *models.ExportJob) {
+	s.updateJob(job.ID, func(j *models.ExportJob) { j.Status = models.ExportJobRunning })
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportJobTimeout)
+	defer cancel()
+
+	rows, err := s.writeExportFile(ctx, job)
+	now := time.Now().UTC()
+	expiresAt := now.Add(s.retention)
+	s.updateJob(job.ID, func(j *models.ExportJob) {
+		j.Rows = rows
+		j.CompletedAt = &now
+		j.ExpiresAt = &expiresAt
+		if err != nil {
+			j.Status = models.ExportJobFailed
+			j.Error = err.Error()
+			return
+		}
+		j.Status = models.ExportJobCompleted
+	})
+
+	if err != nil {
+		log.Printf("Export job %s failed: %v", job.ID, err)
+		return
+	}
+	log.Printf("Export job %s completed: %d %s rows written to %s", job.ID, rows, job.Entity, job.FilePath)
+}
+
+func (s *ExportService) writeExportFile(ctx context.Context, job *models.ExportJob) (int, error) {
+	if err := os.MkdirAll(s.outputDir, os.ModePerm); err != nil {
+		return 0, err
+	}
+	file, err := os.Create(job.FilePath)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := s.Export(ctx, job.Entity, job.Format, job.Filter, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(job.FilePath)
+	}
+	return rows, err
+}
+
+func (s *ExportService) updateJob(id string, fn func(*models.ExportJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job, ok := s.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+// GetExportJob returns a snapshot of a background export
+func (s *ExportService) GetExportJob(id string) (models.ExportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return models.ExportJob{}, ErrExportJobNotFound
+	}
+	return *job, nil
+}
+
+// ListExportJobs returns all known background exports, newest first
+func (s *ExportService) ListExportJobs() []models.ExportJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]models.ExportJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+	return jobs
+}
+
+// RemoveExpiredJobs forgets the background exports that finished longer ago
+// than the retention window and deletes their files, along with any file in
+// the output directory that old that no job knows of, such as those written
+// before a restart. It returns how many jobs were removed.
+func (s *ExportService) RemoveExpiredJobs(ctx context.Context) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+	}
+
+	now := time.Now().UTC()
+	cutoff := now.Add(-s.retention)
+	var files []string
+	known := make(map[string]bool)
+
+	s.mu.Lock()
+	for id, job := range s.jobs {
+		if job.ExpiresAt != nil && !job.ExpiresAt.After(now) {
+			delete(s.jobs, id)
+			files = append(files, job.FilePath)
+			continue
+		}
+		known[filepath.Clean(job.FilePath)] = true
+	}
+	removed := len(files)
+	s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.outputDir)
+	if err != nil && !os.IsNotExist(err) {
+		return removed, fmt.Errorf("error reading export directory: %w", err)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(s.outputDir, entry.Name())
+		if !entry.Type().IsRegular() || known[path] {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && info.ModTime().Before(cutoff) {
+			files = append(files, path)
+		}
+	}
+
+	var errs []error
+	for _, path := range files {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, fmt.Errorf("error removing export file: %w", err))
+		}
+	}
+	return removed, errors.Join(errs...)
+}
+
+func newExportJobID() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating export job ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
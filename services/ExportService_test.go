@@ -0,0 +1,71 @@
+package services
+
+import (
+	"FinalProject/models"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRemoveExpiredJobs(t *testing.T) {
+	dir := t.TempDir()
+	s := &ExportService{outputDir: dir, retention: time.Hour, jobs: map[string]*models.ExportJob{}}
+	now := time.Now().UTC()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+	old := now.Add(-2 * time.Hour)
+
+	write := func(name string, modified time.Time) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("id\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	jobs := map[string]*models.ExportJob{
+		"expired": {ID: "expired", Status: models.ExportJobCompleted, ExpiresAt: &past, FilePath: write("books_expired.csv", old)},
+		"failed":  {ID: "failed", Status: models.ExportJobFailed, ExpiresAt: &past, FilePath: filepath.Join(dir, "books_failed.csv")},
+		"kept":    {ID: "kept", Status: models.ExportJobCompleted, ExpiresAt: &future, FilePath: write("books_kept.csv", old)},
+		"running": {ID: "running", Status: models.ExportJobRunning, FilePath: write("orders_running.csv", old)},
+	}
+	for id, job := range jobs {
+		s.jobs[id] = job
+	}
+	orphan := write("books_before_restart.csv", old)
+	recent := write("books_recent.csv", now)
+
+	removed, err := s.RemoveExpiredJobs(context.Background())
+	if err != nil {
+		t.Fatalf("RemoveExpiredJobs: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed %d jobs, want 2", removed)
+	}
+	for _, id := range []string{"expired", "failed"} {
+		if _, err := s.GetExportJob(id); err != ErrExportJobNotFound {
+			t.Errorf("job %s: got %v, want ErrExportJobNotFound", id, err)
+		}
+	}
+	for _, id := range []string{"kept", "running"} {
+		if _, err := s.GetExportJob(id); err != nil {
+			t.Errorf("job %s was removed: %v", id, err)
+		}
+	}
+
+	exists := map[string]bool{
+		jobs["expired"].FilePath: false,
+		orphan:                   false,
+		jobs["kept"].FilePath:    true,
+		jobs["running"].FilePath: true,
+		recent:                   true,
+	}
+	for path, want := range exists {
+		if _, err := os.Stat(path); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", filepath.Base(path), err == nil, want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"FinalProject/models"
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// recordWriter serializes export rows one at a time
+type recordWriter interface {
+	WriteHeader(header []string) error
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+// ExportContentType returns the MIME type and file extension for a format
+func ExportContentType(format string) (string, string, error) {
+	switch format {
+	case "csv":
+		return "text/csv; charset=utf-8", "csv", nil
+	case "ndjson":
+		return "application/x-ndjson", "ndjson", nil
+	case "xlsx":
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx", nil
+	}
+	return "", "", invalidf("unsupported export format %q", format)
+}
+
+func newRecordWriter(format string, w io.Writer) (recordWriter, error) {
+	switch format {
+	case "csv":
+		return &csvRecordWriter{w: csv.NewWriter(w)}, nil
+	case "ndjson":
+		return &ndjsonRecordWriter{enc: json.NewEncoder(w)}, nil
+	case "xlsx":
+		return newXLSXRecordWriter(w)
+	}
+	return nil, invalidf("unsupported export format %q", format)
+}
+
+// formatExportValue renders a value as text for CSV and XLSX cells
+func formatExportValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case int:
+		return strconv.Itoa(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', 2, 64)
+	case models.Money:
+		return val.Decimal()
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+		return val.UTC().Format(time.RFC3339)
+	case []string:
+		return strings.Join(val, "|")
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+type csvRecordWriter struct {
+	w   *csv.Writer
+	buf []string
+}
+
+func (c *csvRecordWriter) WriteHeader(header []string) error {
+	return c.w.Write(header)
+}
+
+func (c *csvRecordWriter) WriteRow(values []interface{}) error {
+	c.buf = c.buf[:0]
+	for _, v := range values {
+		c.buf = append(c.buf, formatExportValue(v))
+	}
+	return c.w.Write(c.buf)
+}
+
+func (c *csvRecordWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonRecordWriter struct {
+	enc    *json.Encoder
+	header []string
+}
+
+func (n *ndjsonRecordWriter) WriteHeader(header []string) error {
+	n.header = header
+	return nil
+}
+
+func (n *ndjsonRecordWriter) WriteRow(values []interface{}) error {
+	obj := make(map[string]interface{}, len(values))
+	for i, v := range values {
+		if i < len(n.header) {
+			obj[n.header[i]] = v
+		}
+	}
+	return n.enc.Encode(obj)
+}
+
+func (n *ndjsonRecordWriter) Close() error {
+	return nil
+}
+
+// xlsxRecordWriter writes a single-sheet workbook as a streamed zip so rows
+// never have to be held in memory. Strings are stored inline to avoid a
+// shared-strings table.
+type xlsxRecordWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	row   int
+}
+
+func newXLSXRecordWriter(w io.Writer) (*xlsxRecordWriter, error) {
+	zw := zip.NewWriter(w)
+	static := []struct{ name, body string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+	}
+	for _, f := range static {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(fw, f.body); err != nil {
+			return nil, err
+		}
+	}
+
+	// The worksheet must be the last entry since it stays open while rows stream in.
+	fw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(fw)
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return &xlsxRecordWriter{zw: zw, sheet: sheet}, nil
+}
+
+func (x *xlsxRecordWriter) WriteHeader(header []string) error {
+	values := make([]interface{}, len(header))
+	for i, h := range header {
+		values[i] = h
+	}
+	return x.WriteRow(values)
+}
+
+func (x *xlsxRecordWriter) WriteRow(values []interface{}) error {
+	x.row++
+	fmt.Fprintf(x.sheet, `<row r="%d">`, x.row)
+	for _, v := range values {
+		switch val := v.(type) {
+		case int:
+			fmt.Fprintf(x.sheet, `<c t="n"><v>%d</v></c>`, val)
+		case float64:
+			fmt.Fprintf(x.sheet, `<c t="n"><v>%s</v></c>`, strconv.FormatFloat(val, 'f', -1, 64))
+		case models.Money:
+			fmt.Fprintf(x.sheet, `<c t="n"><v>%s</v></c>`, val.Decimal())
+		default:
+			x.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(x.sheet, []byte(formatExportValue(v))); err != nil {
+				return err
+			}
+			x.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxRecordWriter) Close() error {
+	x.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := x.sheet.Flush(); err != nil {
+		return err
+	}
+	return x.zw.Close()
+}
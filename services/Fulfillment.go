@@ -0,0 +1,92 @@
+package services
+
+import (
+	"FinalProject/models"
+	"log"
+	"os"
+	"sort"
+)
+
+// DefaultFulfillmentStrategy is used when FULFILLMENT_STRATEGY is not set
+const DefaultFulfillmentStrategy = "priority"
+
+// FulfillmentStrategy decides which locations an order item ships from
+type FulfillmentStrategy interface {
+	// Pick splits quantity over levels, which come in location priority
+	// order, and returns how much to take from each location, or nil when
+	// the levels do not hold enough stock
+	Pick(levels []models.StockLevel, quantity int) []models.StockLevel
+}
+
+// FulfillmentStrategies are the strategies FULFILLMENT_STRATEGY can name
+var FulfillmentStrategies = map[string]FulfillmentStrategy{
+	"priority":   PriorityFulfillment{},
+	"single":     SingleLocationFulfillment{},
+	"most_stock": MostStockFulfillment{},
+}
+
+// FulfillmentStrategyFromEnv reads the strategy from FULFILLMENT_STRATEGY
+func FulfillmentStrategyFromEnv() FulfillmentStrategy {
+	name := os.Getenv("FULFILLMENT_STRATEGY")
+	if name == "" {
+		name = DefaultFulfillmentStrategy
+	}
+	strategy, ok := FulfillmentStrategies[name]
+	if !ok {
+		log.Printf("Invalid FULFILLMENT_STRATEGY %q, using %s", name, DefaultFulfillmentStrategy)
+		strategy = FulfillmentStrategies[DefaultFulfillmentStrategy]
+	}
+	return strategy
+}
+
+// PriorityFulfillment takes stock from the first location in priority order,
+// then from the next ones while the quantity is not covered
+type PriorityFulfillment struct{}
+
+func (PriorityFulfillment) Pick(levels []models.StockLevel, quantity int) []models.StockLevel {
+	return fill(levels, quantity)
+}
+
+// SingleLocationFulfillment ships from the first location that can cover the
+// whole quantity, so the item arrives in one parcel, and splits like
+// PriorityFulfillment only when no location can
+type SingleLocationFulfillment struct{}
+
+func (SingleLocationFulfillment) Pick(levels []models.StockLevel, quantity int) []models.StockLevel {
+	for _, level := range levels {
+		if level.Quantity >= quantity {
+			return []models.StockLevel{{BookID: level.BookID, LocationID: level.LocationID, Location: level.Location, Quantity: quantity}}
+		}
+	}
+	return fill(levels, quantity)
+}
+
+// MostStockFulfillment takes stock from the location that holds the most
+// first, which keeps stock spread evenly
+type MostStockFulfillment struct{}
+
+func (MostStockFulfillment) Pick(levels []models.StockLevel, quantity int) []models.StockLevel {
+	sorted := append([]models.StockLevel(nil), levels...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Quantity > sorted[j].Quantity })
+	return fill(sorted, quantity)
+}
+
+// fill takes quantity from levels in the order given
+func fill(levels []models.StockLevel, quantity int) []models.StockLevel {
+	var picks []models.StockLevel
+	for _, level := range levels {
+		if quantity == 0 {
+			break
+		}
+		if level.Quantity <= 0 {
+			continue
+		}
+		take := min(level.Quantity, quantity)
+		picks = append(picks, models.StockLevel{BookID: level.BookID, LocationID: level.LocationID, Location: level.Location, Quantity: take})
+		quantity -= take
+	}
+	if quantity > 0 {
+		return nil
+	}
+	return picks
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"context"
+	"errors"
+	"strings"
+)
+
+type GenreService struct {
+	store repositories.GenreStore
+	audit *AuditService
+}
+
+func NewGenreService(store repositories.GenreStore, audit *AuditService) *GenreService {
+	return &GenreService{store: store, audit: audit}
+}
+
+// CreateGenre inserts a new genre; its slug defaults to the slugified name
+func (s *GenreService) CreateGenre(ctx context.Context, genre models.Genre) (models.Genre, error) {
+	select {
+	case <-ctx.Done():
+		return models.Genre{}, ctx.Err()
+	default:
+	}
+
+	if err := s.validateGenre(0, &genre); err != nil {
+		return models.Genre{}, err
+	}
+	created, err := s.store.CreateGenre(genre)
+	if err != nil {
+		return models.Genre{}, err
+	}
+	s.audit.Record(ctx, models.AuditGenre, created.ID, models.AuditCreate, nil, created)
+	return s.store.GetGenre(created.ID)
+}
+
+// GetGenre retrieves a genre by ID
+func (s *GenreService) GetGenre(ctx context.Context, id int) (models.Genre, error) {
+	select {
+	case <-ctx.Done():
+		return models.Genre{}, ctx.Err()
+	default:
+	}
+	return s.store.GetGenre(id)
+}
+
+// UpdateGenre modifies a genre, retagging its books if the slug changes
+func (s *GenreService) UpdateGenre(ctx context.Context, id int, genre models.Genre) (models.Genre, error) {
+	select {
+	case <-ctx.Done():
+		return models.Genre{}, ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetGenre(id)
+	if err != nil {
+		return models.Genre{}, err
+	}
+	if err := s.validateGenre(id, &genre); err != nil {
+		return models.Genre{}, err
+	}
+
+	updated, err := s.store.UpdateGenre(id, genre)
+	if err != nil {
+		return models.Genre{}, err
+	}
+	s.audit.Record(ctx, models.AuditGenre, id, models.AuditUpdate, existing, updated)
+	return updated, nil
+}
+
+// DeleteGenre removes an unused leaf genre
+func (s *GenreService) DeleteGenre(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetGenre(id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteGenre(id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditGenre, id, models.AuditDelete, existing, nil)
+	return nil
+}
+
+// MergeGenres folds the source genre into the target genre
+func (s *GenreService) MergeGenres(ctx context.Context, targetID, sourceID int) (models.Genre, error) {
+	select {
+	case <-ctx.Done():
+		return models.Genre{}, ctx.Err()
+	default:
+	}
+
+	if targetID == sourceID {
+		return models.Genre{}, invalidf("a genre cannot be merged into itself")
+	}
+	target, err := s.store.GetGenre(targetID)
+	if err != nil {
+		return models.Genre{}, err
+	}
+	// The target may not sit below the source, or moving the source's
+	// sub-genres would create a cycle
+	for parentID := targetID; parentID > 0; {
+		genre, err := s.store.GetGenre(parentID)
+		if err != nil {
+			return models.Genre{}, err
+		}
+		if genre.ParentID == sourceID {
+			return models.Genre{}, invalidf("cannot merge genre %d into its own sub-genre", sourceID)
+		}
+		parentID = genre.ParentID
+	}
+	source, err := s.store.GetGenre(sourceID)
+	if err != nil {
+		return models.Genre{}, err
+	}
+
+	merged, err := s.store.MergeGenres(targetID, sourceID)
+	if err != nil {
+		return models.Genre{}, err
+	}
+	s.audit.Record(ctx, models.AuditGenre, sourceID, models.AuditMerge, source, nil)
+	s.audit.Record(ctx, models.AuditGenre, targetID, models.AuditMerge, target, merged)
+	return merged, nil
+}
+
+// ListGenres retrieves every genre as a flat list
+func (s *GenreService) ListGenres(ctx context.Context) ([]models.Genre, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	return s.store.ListGenres()
+}
+
+// GenreTree retrieves every genre nested under its parent
+func (s *GenreService) GenreTree(ctx context.Context) ([]models.GenreNode, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	genres, err := s.store.ListGenres()
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[int][]models.Genre)
+	for _, g := range genres {
+		children[g.ParentID] = append(children[g.ParentID], g)
+	}
+	var build func(parentID int) []models.GenreNode
+	build = func(parentID int) []models.GenreNode {
+		nodes := make([]models.GenreNode, 0, len(children[parentID]))
+		for _, g := range children[parentID] {
+			nodes = append(nodes, models.GenreNode{Genre: g, Children: build(g.ID)})
+		}
+		return nodes
+	}
+	return build(0), nil
+}
+
+// validateGenre normalizes the slug and aliases of a genre and checks that
+// they are unique across the taxonomy and that the parent forms no cycle
+func (s *GenreService) validateGenre(id int, genre *models.Genre) error {
+	genre.Name = strings.TrimSpace(genre.Name)
+	if genre.Name == "" {
+		return invalidf("genre name cannot be empty")
+	}
+	if genre.Slug == "" {
+		genre.Slug = genre.Name
+	}
+	genre.Slug = models.Slugify(genre.Slug)
+	if genre.Slug == "" {
+		return invalidf("genre slug must contain letters or digits")
+	}
+
+	aliases := make([]string, 0, len(genre.Aliases))
+	for _, alias := range genre.Aliases {
+		alias = models.Slugify(alias)
+		if alias != "" && alias != genre.Slug && !containsString(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+	genre.Aliases = aliases
+
+	for _, slug := range append([]string{genre.Slug}, aliases...) {
+		existing, err := s.store.FindGenre(slug)
+		if err == nil && existing.ID != id {
+			return conflictf("%q is already used by genre %s", slug, existing.Slug)
+		}
+		if err != nil && !errors.Is(err, repositories.ErrGenreNotFound) {
+			return err
+		}
+	}
+
+	for parentID := genre.ParentID; parentID > 0; {
+		if parentID == id {
+			return invalidf("a genre cannot be its own ancestor")
+		}
+		parent, err := s.store.GetGenre(parentID)
+		if err != nil {
+			return asInvalid(err)
+		}
+		parentID = parent.ParentID
+	}
+	genre.Parent = nil
+	return nil
+}
+
+// resolveGenres maps genre names, slugs or aliases to canonical slugs,
+// dropping duplicates. Unknown genres are rejected.
+func resolveGenres(store repositories.GenreStore, genres []string) ([]string, error) {
+	slugs := make([]string, 0, len(genres))
+	for _, g := range genres {
+		slug := models.Slugify(g)
+		if slug == "" {
+			continue
+		}
+		genre, err := store.FindGenre(slug)
+		if errors.Is(err, repositories.ErrGenreNotFound) {
+			return nil, invalidf("unknown genre %q", g)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !containsString(slugs, genre.Slug) {
+			slugs = append(slugs, genre.Slug)
+		}
+	}
+	return slugs, nil
+}
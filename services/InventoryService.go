@@ -0,0 +1,468 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// InventoryService manages stock locations, the stock of each book at each
+// location and the stock ledger. Book.Stock stays the total over all
+// locations; while no location exists stock is only tracked as that total,
+// as before. Every change to stock is recorded as a stock movement.
+type InventoryService struct {
+	store     repositories.InventoryStore
+	bookStore repositories.BookStore
+	strategy  FulfillmentStrategy
+	audit     *AuditService
+
+	// onRestock is called with the ID of a book whose stock went up
+	onRestock []func(ctx context.Context, bookID int)
+}
+
+func NewInventoryService(store repositories.InventoryStore, bookStore repositories.BookStore, strategy FulfillmentStrategy, audit *AuditService) *InventoryService {
+	return &InventoryService{store: store, bookStore: bookStore, strategy: strategy, audit: audit}
+}
+
+// withTx returns a copy of the service whose stores work inside tx
+func (s *InventoryService) withTx(tx bun.IDB) *InventoryService {
+	c := *s
+	c.store = s.store.WithTx(tx)
+	c.bookStore = s.bookStore.WithTx(tx)
+	c.audit = s.audit.withTx(tx)
+	return &c
+}
+
+// CreateLocation inserts a new location
+func (s *InventoryService) CreateLocation(ctx context.Context, location models.Location) (models.Location, error) {
+	select {
+	case <-ctx.Done():
+		return models.Location{}, ctx.Err()
+	default:
+	}
+
+	if err := normalizeLocation(&location); err != nil {
+		return models.Location{}, err
+	}
+	created, err := s.store.CreateLocation(location)
+	if err != nil {
+		return models.Location{}, err
+	}
+	s.audit.Record(ctx, models.AuditLocation, created.ID, models.AuditCreate, nil, created)
+	return created, nil
+}
+
+// GetLocation retrieves a location by ID
+func (s *InventoryService) GetLocation(ctx context.Context, id int) (models.Location, error) {
+	select {
+	case <-ctx.Done():
+		return models.Location{}, ctx.Err()
+	default:
+	}
+	return s.store.GetLocation(id)
+}
+
+// UpdateLocation modifies an existing location
+func (s *InventoryService) UpdateLocation(ctx context.Context, id int, location models.Location) (models.Location, error) {
+	select {
+	case <-ctx.Done():
+		return models.Location{}, ctx.Err()
+	default:
+	}
+
+	if err := normalizeLocation(&location); err != nil {
+		return models.Location{}, err
+	}
+	existing, err := s.store.GetLocation(id)
+	if err != nil {
+		return models.Location{}, err
+	}
+	updated, err := s.store.UpdateLocation(id, location)
+	if err != nil {
+		return models.Location{}, err
+	}
+	s.audit.Record(ctx, models.AuditLocation, id, models.AuditUpdate, existing, updated)
+	return updated, nil
+}
+
+// DeleteLocation removes a location that holds no stock
+func (s *InventoryService) DeleteLocation(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetLocation(id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteLocation(id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditLocation, id, models.AuditDelete, existing, nil)
+	return nil
+}
+
+// ListLocations retrieves every location in fulfillment order
+func (s *InventoryService) ListLocations(ctx context.Context) ([]models.Location, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	return s.store.ListLocations()
+}
+
+// BookStock retrieves the stock of a book at each location
+func (s *InventoryService) BookStock(ctx context.Context, bookID int) ([]models.StockLevel, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	book, err := s.bookStore.GetBook(bookID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reconcile(ctx, &book); err != nil {
+		return nil, err
+	}
+	return book.StockLevels, nil
+}
+
+// TransferStock moves stock of a book between two locations. The total
+// stock of the book does not change.
+func (s *InventoryService) TransferStock(ctx context.Context, transfer models.StockTransfer) (models.StockTransfer, error) {
+	select {
+	case <-ctx.Done():
+		return models.StockTransfer{}, ctx.Err()
+	default:
+	}
+
+	if transfer.FromLocationID == transfer.ToLocationID {
+		return models.StockTransfer{}, invalidf("a transfer needs two different locations")
+	}
+	for _, id := range []int{transfer.FromLocationID, transfer.ToLocationID} {
+		if _, err := s.store.GetLocation(id); err != nil {
+			return models.StockTransfer{}, asInvalid(err)
+		}
+	}
+	book, err := s.bookStore.GetBook(transfer.BookID)
+	if err != nil {
+		return models.StockTransfer{}, asInvalid(err)
+	}
+	if err := s.reconcile(ctx, &book); err != nil {
+		return models.StockTransfer{}, err
+	}
+
+	transfer.ActorID = ActorFromContext(ctx)
+	return s.store.TransferStock(transfer)
+}
+
+// ListTransfers retrieves stock transfers, newest first; bookID 0 lists all
+func (s *InventoryService) ListTransfers(ctx context.Context, bookID int) ([]models.StockTransfer, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	return s.store.ListTransfers(bookID)
+}
+
+// RecordMovement receives, returns or adjusts stock of a book by hand. A
+// movement with a LocationID changes the stock at that location only;
+// otherwise copies are placed or taken like any other change. Book.Stock
+// follows the movement, and a receipt with a UnitCost updates the average
+// Book.CostPrice; the updated book is returned. The stock levels, the
+// ledger and the book change in one transaction, so a book changed by
+// someone else in the meantime leaves all three as they were.
+func (s *InventoryService) RecordMovement(ctx context.Context, bookID int, movement models.StockMovement) (models.Book, error) {
+	select {
+	case <-ctx.Done():
+		return models.Book{}, ctx.Err()
+	default:
+	}
+
+	var updated models.Book
+	err := s.store.RunInTx(ctx, func(ctx context.Context, tx bun.IDB) error {
+		var err error
+		updated, err = s.withTx(tx).recordMovement(ctx, bookID, movement)
+		return err
+	})
+	if err != nil {
+		return models.Book{}, err
+	}
+	if movement.Quantity > 0 {
+		updated = s.restocked(ctx, updated)
+	}
+	return updated, nil
+}
+
+// recordMovement does the work of RecordMovement inside its transaction
+func (s *InventoryService) recordMovement(ctx context.Context, bookID int, movement models.StockMovement) (models.Book, error) {
+
+	movement.Reason = strings.TrimSpace(movement.Reason)
+	if !models.IsManualMovementKind(movement.Kind) {
+		return models.Book{}, invalidf("movement kind must be one of %s", strings.Join(models.ManualMovementKinds, ", "))
+	}
+	if movement.Reason == "" {
+		return models.Book{}, invalidf("a stock movement needs a reason")
+	}
+	if movement.Quantity == 0 {
+		return models.Book{}, invalidf("movement quantity cannot be zero")
+	}
+	if movement.Kind != models.MovementAdjustment && movement.Quantity < 0 {
+		return models.Book{}, invalidf("a %s must add stock; use an adjustment to remove it", movement.Kind)
+	}
+	if err := storeAmount("unit cost", &movement.UnitCost); err != nil {
+		return models.Book{}, err
+	}
+
+	book, err := s.bookStore.GetBook(bookID)
+	if err != nil {
+		return models.Book{}, err
+	}
+	if book.Stock+movement.Quantity < 0 {
+		return models.Book{}, conflictf("insufficient stock for book ID %d", bookID)
+	}
+	if movement.LocationID > 0 {
+		if _, err := s.store.GetLocation(movement.LocationID); err != nil {
+			return models.Book{}, asInvalid(err)
+		}
+		if err := s.reconcile(ctx, &book); err != nil {
+			return models.Book{}, err
+		}
+		if _, err := s.store.AdjustStock(bookID, movement.LocationID, movement.Quantity); err != nil {
+			return models.Book{}, err
+		}
+		movement.BookID = bookID
+		if err := s.record(ctx, movement); err != nil {
+			return models.Book{}, err
+		}
+	} else if _, err := s.adjust(ctx, book, movement.Quantity, movement); err != nil {
+		return models.Book{}, err
+	}
+
+	updated := book
+	updated.Stock += movement.Quantity
+	updated.StockLevels = nil
+	if movement.Kind == models.MovementReceipt && movement.UnitCost.Sign() > 0 {
+		if err := inStoreCurrency(fmt.Sprintf("the cost price of book %d", bookID), book.CostPrice); err != nil {
+			return models.Book{}, err
+		}
+		updated.CostPrice = averageCost(book, movement.Quantity, movement.UnitCost)
+	}
+	if updated, err = s.bookStore.UpdateBook(bookID, updated); err != nil {
+		return models.Book{}, err
+	}
+	s.audit.Record(ctx, models.AuditBook, bookID, models.AuditUpdate, book, updated)
+	return updated, nil
+}
+
+// StockHistory retrieves the latest limit movements of a book and its
+// editions, newest first
+func (s *InventoryService) StockHistory(ctx context.Context, bookID, limit int) ([]models.StockMovement, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if _, err := s.bookStore.GetBook(bookID); err != nil {
+		return nil, err
+	}
+	return s.store.ListMovements(bookID, limit)
+}
+
+// StockDrift compares every stock figure with the sum of its ledger and
+// returns those that no longer match
+func (s *InventoryService) StockDrift(ctx context.Context) ([]models.StockDrift, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	return s.store.StockDrift()
+}
+
+// allocate takes quantity copies of a book from the locations the strategy
+// picks and returns how much came from each. Without locations a single
+// pick with LocationID 0 is returned and only Book.Stock is left to change.
+// It runs inside the caller's transaction, which undoes any copies already
+// taken if a later pick fails.
+func (s *InventoryService) allocate(ctx context.Context, book models.Book, quantity int) ([]models.StockLevel, error) {
+	locations, err := s.store.ListLocations()
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) == 0 {
+		return []models.StockLevel{{BookID: book.ID, Quantity: quantity}}, nil
+	}
+
+	if err := s.reconcile(ctx, &book); err != nil {
+		return nil, err
+	}
+	picks := s.strategy.Pick(book.StockLevels, quantity)
+	if picks == nil {
+		return nil, conflictf("insufficient stock for book ID %d", book.ID)
+	}
+	for _, pick := range picks {
+		if _, err := s.store.AdjustStock(book.ID, pick.LocationID, -pick.Quantity); err != nil {
+			return nil, err
+		}
+	}
+	return picks, nil
+}
+
+// release puts quantity copies of a book back at the location they were
+// taken from. Items without a location need nothing here: their copies are
+// placed by the next reconcile, once Book.Stock has gone up.
+func (s *InventoryService) release(ctx context.Context, bookID, locationID, quantity int) error {
+	if locationID == 0 {
+		return nil
+	}
+	_, err := s.store.AdjustStock(bookID, locationID, quantity)
+	return err
+}
+
+// reconcile makes the stock levels of a book add up to Book.Stock for
+// stock counted before locations existed. Missing copies are moved to the
+// first location; surplus copies are taken off the locations the way an
+// order would take them. Both are recorded as transfers between the
+// location and no location, so the total in the ledger is unchanged.
+// book.StockLevels is refreshed.
+func (s *InventoryService) reconcile(ctx context.Context, book *models.Book) error {
+	locations, err := s.store.ListLocations()
+	if err != nil || len(locations) == 0 {
+		return err
+	}
+	levels, err := s.store.StockLevels(book.ID)
+	if err != nil {
+		return err
+	}
+
+	placed := 0
+	for _, level := range levels {
+		placed += level.Quantity
+	}
+	var moves []models.StockLevel
+	switch diff := book.Stock - placed; {
+	case diff > 0:
+		moves = []models.StockLevel{{LocationID: locations[0].ID, Quantity: diff}}
+	case diff < 0:
+		for _, pick := range s.strategy.Pick(levels, -diff) {
+			moves = append(moves, models.StockLevel{LocationID: pick.LocationID, Quantity: -pick.Quantity})
+		}
+	default:
+		book.StockLevels = levels
+		return nil
+	}
+
+	var movements []models.StockMovement
+	for _, move := range moves {
+		if _, err := s.store.AdjustStock(book.ID, move.LocationID, move.Quantity); err != nil {
+			return err
+		}
+		movements = append(movements,
+			models.StockMovement{BookID: book.ID, Kind: models.MovementTransfer, Quantity: -move.Quantity, Reason: "stock levels reconciled"},
+			models.StockMovement{BookID: book.ID, LocationID: move.LocationID, Kind: models.MovementTransfer, Quantity: move.Quantity, Reason: "stock levels reconciled"})
+	}
+	if err := s.record(ctx, movements...); err != nil {
+		return err
+	}
+
+	book.StockLevels, err = s.store.StockLevels(book.ID)
+	return err
+}
+
+// adjust changes the stock of a book by delta at its locations and records
+// the change in the ledger as movements like template, one per location.
+// Copies are received at the first location and taken the way an order
+// would take them; without locations only the total is recorded. book is
+// the book before the change and Book.Stock is left to the caller. The
+// refreshed stock levels are returned.
+func (s *InventoryService) adjust(ctx context.Context, book models.Book, delta int, template models.StockMovement) ([]models.StockLevel, error) {
+	template.BookID = book.ID
+	locations, err := s.store.ListLocations()
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) == 0 {
+		template.Quantity = delta
+		return nil, s.record(ctx, template)
+	}
+
+	var picks []models.StockLevel
+	if delta > 0 {
+		if _, err := s.store.AdjustStock(book.ID, locations[0].ID, delta); err != nil {
+			return nil, err
+		}
+		picks = []models.StockLevel{{LocationID: locations[0].ID, Quantity: -delta}}
+	} else if picks, err = s.allocate(ctx, book, -delta); err != nil {
+		return nil, err
+	}
+
+	movements := make([]models.StockMovement, len(picks))
+	for i, pick := range picks {
+		movements[i] = template
+		movements[i].LocationID = pick.LocationID
+		movements[i].Quantity = -pick.Quantity
+	}
+	if err := s.record(ctx, movements...); err != nil {
+		return nil, err
+	}
+	return s.store.StockLevels(book.ID)
+}
+
+// restocked tells whoever waits for stock of a book that it went up, and
+// returns the book as they left it
+func (s *InventoryService) restocked(ctx context.Context, book models.Book) models.Book {
+	for _, fn := range s.onRestock {
+		fn(ctx, book.ID)
+	}
+	if refreshed, err := s.bookStore.GetBook(book.ID); err == nil {
+		return refreshed
+	}
+	return book
+}
+
+// record appends movements to the ledger on behalf of the caller
+func (s *InventoryService) record(ctx context.Context, movements ...models.StockMovement) error {
+	actor := ActorFromContext(ctx)
+	for i := range movements {
+		movements[i].ActorID = actor
+	}
+	return s.store.RecordMovements(movements)
+}
+
+// averageCost is the cost price of a book after receiving quantity copies
+// at unitCost: the average over the copies in stock and the new ones
+func averageCost(book models.Book, quantity int, unitCost models.Money) models.Money {
+	if book.Stock <= 0 {
+		return unitCost
+	}
+	total := book.CostPrice.Times(book.Stock).Add(unitCost.Times(quantity))
+	return total.Divide(book.Stock + quantity)
+}
+
+// normalizeLocation trims a location and checks its code, name and kind
+func normalizeLocation(location *models.Location) error {
+	location.Code = strings.ToUpper(strings.TrimSpace(location.Code))
+	location.Name = strings.TrimSpace(location.Name)
+	if location.Code == "" || location.Name == "" {
+		return invalidf("location code and name cannot be empty")
+	}
+	if !models.IsLocationKind(location.Kind) {
+		return invalidf("location kind must be one of %s", strings.Join(models.LocationKinds, ", "))
+	}
+	if location.Priority < 0 {
+		return invalidf("location priority cannot be negative")
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"FinalProject/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Notifier tells staff about stock that needs attention
+type Notifier interface {
+	NotifyLowStock(ctx context.Context, alerts []models.StockAlert) error
+}
+
+// NotifierFromEnv builds the notifier selected by LOW_STOCK_NOTIFIER: "log"
+// (default) writes alerts to the server log, "webhook" posts them as JSON to
+// LOW_STOCK_WEBHOOK_URL
+func NotifierFromEnv() Notifier {
+	switch name := os.Getenv("LOW_STOCK_NOTIFIER"); name {
+	case "", "log":
+		return LogNotifier{}
+	case "webhook":
+		url := os.Getenv("LOW_STOCK_WEBHOOK_URL")
+		if url == "" {
+			log.Println("LOW_STOCK_WEBHOOK_URL is not set, logging low-stock alerts instead")
+			return LogNotifier{}
+		}
+		return NewWebhookNotifier(url)
+	default:
+		log.Printf("Invalid LOW_STOCK_NOTIFIER %q, logging low-stock alerts instead", name)
+		return LogNotifier{}
+	}
+}
+
+// LogNotifier writes alerts to the server log
+type LogNotifier struct{}
+
+func (LogNotifier) NotifyLowStock(ctx context.Context, alerts []models.StockAlert) error {
+	for _, a := range alerts {
+		log.Printf("⚠ Low stock: book %d %q has %d left (threshold %d, %.1f sold per day); reorder %d",
+			a.BookID, a.Title, a.Stock, a.Threshold, a.DailySales, a.SuggestedQuantity)
+	}
+	return nil
+}
+
+// WebhookNotifier posts alerts to a URL as {"event": "low_stock", "alerts": [...]}
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *WebhookNotifier) NotifyLowStock(ctx context.Context, alerts []models.StockAlert) error {
+	body, err := json.Marshal(map[string]any{"event": "low_stock", "alerts": alerts})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending low-stock alerts: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("error sending low-stock alerts: webhook answered %s", resp.Status)
+	}
+	return nil
+}
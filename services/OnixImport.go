@@ -0,0 +1,387 @@
+package services
+
+import (
+	"FinalProject/models"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ONIX 3.0 reference-tag structures. Only the composites that map onto
+// models.Book and models.Author are decoded; every other element is caught
+// by the ",any" fields and reported as unmapped.
+
+type onixElement struct {
+	XMLName xml.Name
+}
+
+type onixProduct struct {
+	RecordReference    string                  `xml:"RecordReference"`
+	NotificationType   string                  `xml:"NotificationType"`
+	ProductIdentifiers []onixProductIdentifier `xml:"ProductIdentifier"`
+	DescriptiveDetail  onixDescriptiveDetail   `xml:"DescriptiveDetail"`
+	PublishingDetail   onixPublishingDetail    `xml:"PublishingDetail"`
+	ProductSupply      onixProductSupply       `xml:"ProductSupply"`
+	Unmapped           []onixElement           `xml:",any"`
+}
+
+type onixProductIdentifier struct {
+	ProductIDType string `xml:"ProductIDType"`
+	IDValue       string `xml:"IDValue"`
+}
+
+type onixDescriptiveDetail struct {
+	ProductForm  string            `xml:"ProductForm"`
+	TitleDetails []onixTitleDetail `xml:"TitleDetail"`
+	Contributors []onixContributor `xml:"Contributor"`
+	Subjects     []onixSubject     `xml:"Subject"`
+	Unmapped     []onixElement     `xml:",any"`
+}
+
+type onixTitleDetail struct {
+	TitleType     string             `xml:"TitleType"`
+	TitleElements []onixTitleElement `xml:"TitleElement"`
+}
+
+type onixTitleElement struct {
+	TitleElementLevel  string `xml:"TitleElementLevel"`
+	TitlePrefix        string `xml:"TitlePrefix"`
+	TitleWithoutPrefix string `xml:"TitleWithoutPrefix"`
+	TitleText          string `xml:"TitleText"`
+	Subtitle           string `xml:"Subtitle"`
+}
+
+type onixContributor struct {
+	SequenceNumber   int           `xml:"SequenceNumber"`
+	ContributorRoles []string      `xml:"ContributorRole"`
+	PersonName       string        `xml:"PersonName"`
+	NamesBeforeKey   string        `xml:"NamesBeforeKey"`
+	KeyNames         string        `xml:"KeyNames"`
+	CorporateName    string        `xml:"CorporateName"`
+	BiographicalNote string        `xml:"BiographicalNote"`
+	Unmapped         []onixElement `xml:",any"`
+}
+
+type onixSubject struct {
+	MainSubject             *struct{} `xml:"MainSubject"`
+	SubjectSchemeIdentifier string    `xml:"SubjectSchemeIdentifier"`
+	SubjectCode             string    `xml:"SubjectCode"`
+	SubjectHeadingText      string    `xml:"SubjectHeadingText"`
+}
+
+type onixPublishingDetail struct {
+	PublishingDates []onixPublishingDate `xml:"PublishingDate"`
+	Unmapped        []onixElement        `xml:",any"`
+}
+
+type onixPublishingDate struct {
+	PublishingDateRole string   `xml:"PublishingDateRole"`
+	Date               onixDate `xml:"Date"`
+}
+
+type onixDate struct {
+	Format string `xml:"dateformat,attr"`
+	Value  string `xml:",chardata"`
+}
+
+type onixProductSupply struct {
+	SupplyDetails []onixSupplyDetail `xml:"SupplyDetail"`
+	Unmapped      []onixElement      `xml:",any"`
+}
+
+type onixSupplyDetail struct {
+	ProductAvailability string        `xml:"ProductAvailability"`
+	Stock               []onixStock   `xml:"Stock"`
+	Prices              []onixPrice   `xml:"Price"`
+	Unmapped            []onixElement `xml:",any"`
+}
+
+type onixStock struct {
+	OnHand string `xml:"OnHand"`
+}
+
+type onixPrice struct {
+	PriceType    string `xml:"PriceType"`
+	PriceAmount  string `xml:"PriceAmount"`
+	CurrencyCode string `xml:"CurrencyCode"`
+}
+
+// ONIX code list values used by the mapping
+const (
+	onixNotificationDelete = "05" // code list 1: delete
+	onixTitleDistinctive   = "01" // code list 15: distinctive title
+	onixTitleLevelProduct  = "01" // code list 149: product level
+	onixDatePublication    = "01" // code list 163: publication date
+)
+
+// onixRowSource streams <Product> records from an ONIX 3.0 message and maps
+// each one to a book row. Fields that have no home in the catalog model are
+// counted in unmapped so the caller can see what was dropped.
+type onixRowSource struct {
+	decoder  *xml.Decoder
+	index    int
+	unmapped map[string]int
+}
+
+func newONIXRowSource(r io.Reader) *onixRowSource {
+	return &onixRowSource{decoder: xml.NewDecoder(r), unmapped: map[string]int{}}
+}
+
+func (o *onixRowSource) Unmapped() map[string]int {
+	return o.unmapped
+}
+
+func (o *onixRowSource) Next() (importRow, error) {
+	for {
+		tok, err := o.decoder.Token()
+		if err == io.EOF {
+			return importRow{}, io.EOF
+		}
+		if err != nil {
+			return importRow{}, invalidf("invalid ONIX XML: %w", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "Product":
+		case "ONIXMessage":
+			continue
+		case "ONIXmessage", "product":
+			return importRow{}, invalidf("ONIX short tags are not supported, send the reference-tag form")
+		default:
+			o.decoder.Skip()
+			continue
+		}
+
+		o.index++
+		var product onixProduct
+		if err := o.decoder.DecodeElement(&product, &start); err != nil {
+			return importRow{}, invalidf("invalid ONIX product #%d: %w", o.index, err)
+		}
+		return o.mapProduct(product), nil
+	}
+}
+
+func (o *onixRowSource) note(path string) {
+	o.unmapped[path]++
+}
+
+func (o *onixRowSource) noteAll(prefix string, elems []onixElement) {
+	for _, e := range elems {
+		o.note(prefix + "/" + e.XMLName.Local)
+	}
+}
+
+// mapProduct converts one ONIX product into a book row
+func (o *onixRowSource) mapProduct(p onixProduct) importRow {
+	row := importRow{line: o.index, book: models.Book{Author: &models.Author{}}}
+	fail := func(field, msg string) {
+		row.errs = append(row.errs, models.ImportRowError{Row: o.index, Field: field, Message: msg})
+	}
+
+	if p.NotificationType == onixNotificationDelete {
+		fail("NotificationType", fmt.Sprintf("product %s is a delete notification and was not imported", p.RecordReference))
+		return row
+	}
+
+	o.noteAll("Product", p.Unmapped)
+	o.noteAll("Product/DescriptiveDetail", p.DescriptiveDetail.Unmapped)
+	o.noteAll("Product/PublishingDetail", p.PublishingDetail.Unmapped)
+	o.noteAll("Product/ProductSupply", p.ProductSupply.Unmapped)
+	for _, id := range p.ProductIdentifiers {
+		o.note("Product/ProductIdentifier[" + id.ProductIDType + "]")
+	}
+	if p.DescriptiveDetail.ProductForm != "" {
+		o.note("Product/DescriptiveDetail/ProductForm")
+	}
+
+	book := models.Book{Author: &models.Author{}}
+	book.Title = onixTitle(p.DescriptiveDetail.TitleDetails)
+
+	book.Contributors = o.mapContributors(p.DescriptiveDetail.Contributors)
+	if len(book.Contributors) == 0 {
+		fail("Contributor", "no contributor with a personal name and a supported role was found")
+	}
+
+	for _, subject := range p.DescriptiveDetail.Subjects {
+		genre := strings.TrimSpace(subject.SubjectHeadingText)
+		if genre == "" {
+			genre = strings.TrimSpace(subject.SubjectCode)
+		}
+		if genre != "" && !containsString(book.Genres, genre) {
+			book.Genres = append(book.Genres, genre)
+		}
+	}
+
+	for _, d := range p.PublishingDetail.PublishingDates {
+		if d.PublishingDateRole != onixDatePublication {
+			o.note("Product/PublishingDetail/PublishingDate[" + d.PublishingDateRole + "]")
+			continue
+		}
+		t, err := parseONIXDate(d.Date)
+		if err != nil {
+			fail("PublishingDate", err.Error())
+			continue
+		}
+		book.PublishedAt = t
+	}
+
+	o.mapSupply(p.ProductSupply.SupplyDetails, &book, fail)
+
+	row.book = book
+	return row
+}
+
+// onixContributorRoles maps ONIX code list 17 contributor roles onto ours
+var onixContributorRoles = map[string]string{
+	"A01": models.RoleAuthor,
+	"A12": models.RoleIllustrator,
+	"A23": models.RoleForeword,
+	"B01": models.RoleEditor,
+	"B06": models.RoleTranslator,
+	"E07": models.RoleNarrator,
+}
+
+// mapContributors turns every personal-name contributor with a known role
+// into a book contributor. Corporate contributors and unknown roles have no
+// place in the model and are reported as unmapped.
+func (o *onixRowSource) mapContributors(contributors []onixContributor) []models.BookContributor {
+	var mapped []models.BookContributor
+	for i, c := range contributors {
+		o.noteAll("Product/DescriptiveDetail/Contributor", c.Unmapped)
+
+		role := ""
+		for _, code := range c.ContributorRoles {
+			if r, ok := onixContributorRoles[code]; ok {
+				role = r
+				break
+			}
+		}
+		if role == "" || !onixHasPersonName(c) {
+			o.note("Product/DescriptiveDetail/Contributor[" + strings.Join(c.ContributorRoles, ",") + "]")
+			continue
+		}
+
+		first, last := strings.TrimSpace(c.NamesBeforeKey), strings.TrimSpace(c.KeyNames)
+		if last == "" {
+			first, last = splitPersonName(c.PersonName)
+		}
+		position := c.SequenceNumber
+		if position <= 0 {
+			position = i + 1
+		}
+		mapped = append(mapped, models.BookContributor{
+			Author:   &models.Author{FirstName: first, LastName: last, Bio: strings.TrimSpace(c.BiographicalNote)},
+			Role:     role,
+			Position: position,
+		})
+	}
+	return mapped
+}
+
+// mapSupply takes the first usable price in the store currency and the
+// on-hand stock from the supply details
+func (o *onixRowSource) mapSupply(details []onixSupplyDetail, book *models.Book, fail func(field, msg string)) {
+	priced, stocked := false, false
+	for _, sd := range details {
+		o.noteAll("Product/ProductSupply/SupplyDetail", sd.Unmapped)
+
+		for _, price := range sd.Prices {
+			currency := strings.ToUpper(strings.TrimSpace(price.CurrencyCode))
+			if currency == "" {
+				currency = models.DefaultCurrency
+			}
+			if priced || currency != models.DefaultCurrency {
+				o.note("Product/ProductSupply/SupplyDetail/Price[" + price.CurrencyCode + "]")
+				continue
+			}
+			amount, err := models.ParseMoney(price.PriceAmount, currency)
+			if err != nil {
+				fail("PriceAmount", fmt.Sprintf("invalid price amount %q", price.PriceAmount))
+				continue
+			}
+			book.Price = amount
+			priced = true
+		}
+
+		for _, stock := range sd.Stock {
+			onHand, err := strconv.Atoi(strings.TrimSpace(stock.OnHand))
+			if err != nil {
+				continue
+			}
+			book.Stock += onHand
+			stocked = true
+		}
+		if !stocked && sd.ProductAvailability != "" {
+			o.note("Product/ProductSupply/SupplyDetail/ProductAvailability")
+		}
+	}
+	if !priced {
+		fail("Price", fmt.Sprintf("no %s price found in ProductSupply", models.DefaultCurrency))
+	}
+}
+
+func onixTitle(details []onixTitleDetail) string {
+	for _, td := range details {
+		if td.TitleType != onixTitleDistinctive {
+			continue
+		}
+		for _, te := range td.TitleElements {
+			if te.TitleElementLevel != "" && te.TitleElementLevel != onixTitleLevelProduct {
+				continue
+			}
+			title := strings.TrimSpace(te.TitleText)
+			if title == "" {
+				title = strings.TrimSpace(strings.TrimSpace(te.TitlePrefix) + " " + strings.TrimSpace(te.TitleWithoutPrefix))
+			}
+			if sub := strings.TrimSpace(te.Subtitle); sub != "" {
+				title += ": " + sub
+			}
+			return title
+		}
+	}
+	return ""
+}
+
+func onixHasPersonName(c onixContributor) bool {
+	return strings.TrimSpace(c.KeyNames) != "" || strings.TrimSpace(c.PersonName) != ""
+}
+
+func splitPersonName(name string) (string, string) {
+	parts := strings.Fields(name)
+	if len(parts) < 2 {
+		return "", strings.Join(parts, " ")
+	}
+	return strings.Join(parts[:len(parts)-1], " "), parts[len(parts)-1]
+}
+
+// parseONIXDate understands the date formats of code list 55 that carry a
+// full or partial calendar date
+func parseONIXDate(d onixDate) (time.Time, error) {
+	value := strings.TrimSpace(d.Value)
+	layouts := map[string]string{"": "20060102", "00": "20060102", "01": "200601", "05": "2006"}
+	layout, ok := layouts[d.Format]
+	if !ok {
+		return time.Time{}, invalidf("unsupported ONIX date format %q", d.Format)
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, invalidf("invalid publication date %q", value)
+	}
+	return t, nil
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
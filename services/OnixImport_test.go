@@ -0,0 +1,134 @@
+package services
+
+import (
+	"FinalProject/models"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestONIXRowSourceSampleFeed(t *testing.T) {
+	f, err := os.Open("../samples/onix/sample_feed.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	source := newONIXRowSource(f)
+	var rows []importRow
+	for {
+		row, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(rows))
+	}
+
+	harbour := rows[0]
+	if len(harbour.errs) != 0 {
+		t.Errorf("row 1 errors: %+v", harbour.errs)
+	}
+	if harbour.book.Title != "The Quiet Harbour: A Novel" {
+		t.Errorf("row 1 title = %q", harbour.book.Title)
+	}
+	wantContributors := []models.BookContributor{
+		{Author: &models.Author{FirstName: "Maria", LastName: "Lindqvist", Bio: "Maria Lindqvist lives on the west coast of Sweden."}, Role: models.RoleAuthor, Position: 1},
+		{Author: &models.Author{FirstName: "Thomas", LastName: "Reed"}, Role: models.RoleTranslator, Position: 2},
+	}
+	if !reflect.DeepEqual(harbour.book.Contributors, wantContributors) {
+		t.Errorf("row 1 contributors = %+v, want %+v", harbour.book.Contributors, wantContributors)
+	}
+	if want := []string{"Literary Fiction", "Translated Fiction"}; !reflect.DeepEqual(harbour.book.Genres, want) {
+		t.Errorf("row 1 genres = %v, want %v", harbour.book.Genres, want)
+	}
+	if want := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC); !harbour.book.PublishedAt.Equal(want) {
+		t.Errorf("row 1 published = %v, want %v", harbour.book.PublishedAt, want)
+	}
+	if want := models.NewMoney(1699, "USD"); harbour.book.Price != want {
+		t.Errorf("row 1 price = %v, want %v", harbour.book.Price, want)
+	}
+	if harbour.book.Stock != 40 {
+		t.Errorf("row 1 stock = %d, want 40", harbour.book.Stock)
+	}
+
+	atlas := rows[1]
+	if len(atlas.errs) != 0 {
+		t.Errorf("row 2 errors: %+v", atlas.errs)
+	}
+	if atlas.book.Title != "The Northern Lights Atlas" {
+		t.Errorf("row 2 title = %q", atlas.book.Title)
+	}
+	wantContributors = []models.BookContributor{
+		{Author: &models.Author{FirstName: "Erik", LastName: "Solberg"}, Role: models.RoleAuthor, Position: 1},
+	}
+	if !reflect.DeepEqual(atlas.book.Contributors, wantContributors) {
+		t.Errorf("row 2 contributors = %+v, want %+v", atlas.book.Contributors, wantContributors)
+	}
+	if want := []string{"SCI004000"}; !reflect.DeepEqual(atlas.book.Genres, want) {
+		t.Errorf("row 2 genres = %v, want %v", atlas.book.Genres, want)
+	}
+	if want := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC); !atlas.book.PublishedAt.Equal(want) {
+		t.Errorf("row 2 published = %v, want %v", atlas.book.PublishedAt, want)
+	}
+	if want := models.NewMoney(4500, "USD"); atlas.book.Price != want {
+		t.Errorf("row 2 price = %v, want %v", atlas.book.Price, want)
+	}
+	if atlas.book.Stock != 0 {
+		t.Errorf("row 2 stock = %d, want 0", atlas.book.Stock)
+	}
+
+	deleted := rows[2]
+	if len(deleted.errs) != 1 || deleted.errs[0].Field != "NotificationType" || deleted.errs[0].Row != 3 {
+		t.Errorf("row 3 errors = %+v, want one NotificationType error", deleted.errs)
+	}
+
+	wantUnmapped := map[string]int{
+		"Product/ProductIdentifier[15]":                          1,
+		"Product/CollateralDetail":                               1,
+		"Product/DescriptiveDetail/ProductComposition":           1,
+		"Product/DescriptiveDetail/ProductForm":                  2,
+		"Product/PublishingDetail/Publisher":                     1,
+		"Product/ProductSupply/SupplyDetail/Supplier":            1,
+		"Product/ProductSupply/SupplyDetail/Price[EUR]":          1,
+		"Product/ProductSupply/SupplyDetail/ProductAvailability": 1,
+	}
+	if !reflect.DeepEqual(source.Unmapped(), wantUnmapped) {
+		t.Errorf("unmapped = %v, want %v", source.Unmapped(), wantUnmapped)
+	}
+}
+
+func TestONIXRowSourceMissingPrice(t *testing.T) {
+	feed := `<ONIXMessage release="3.0"><Product>
+		<RecordReference>x</RecordReference>
+		<NotificationType>03</NotificationType>
+		<DescriptiveDetail>
+			<TitleDetail><TitleType>01</TitleType><TitleElement><TitleText>Priced Elsewhere</TitleText></TitleElement></TitleDetail>
+			<Contributor><ContributorRole>A01</ContributorRole><PersonName>Ann Lee</PersonName></Contributor>
+		</DescriptiveDetail>
+		<ProductSupply><SupplyDetail><Price><PriceAmount>9.00</PriceAmount><CurrencyCode>GBP</CurrencyCode></Price></SupplyDetail></ProductSupply>
+	</Product></ONIXMessage>`
+
+	row, err := newONIXRowSource(strings.NewReader(feed)).Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if len(row.errs) != 1 || row.errs[0].Field != "Price" {
+		t.Errorf("errors = %+v, want one Price error", row.errs)
+	}
+}
+
+func TestONIXRowSourceShortTags(t *testing.T) {
+	_, err := newONIXRowSource(strings.NewReader(`<ONIXmessage><product/></ONIXmessage>`)).Next()
+	if err == nil {
+		t.Fatal("short-tag feed was accepted")
+	}
+}
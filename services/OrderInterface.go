@@ -5,20 +5,63 @@ import (
 	"FinalProject/repositories"
 	"context"
 	"fmt"
+	"log"
 	"time"
+
+	"github.com/uptrace/bun"
 )
 
 type OrderService struct {
 	store         repositories.OrderStore
 	bookstore     repositories.BookStore
 	customerstore repositories.CustomerStore
+	editionstore  repositories.EditionStore
+	inventory     *InventoryService
+	reservations  *ReservationService
+	promotions    *PromotionService
+	coupons       *CouponService
+	pricing       *PricingService
+	taxes         *TaxService
+	audit         *AuditService
+}
+
+func NewOrderService(store repositories.OrderStore, bookstore repositories.BookStore, customerstore repositories.CustomerStore, editionstore repositories.EditionStore, inventory *InventoryService, reservations *ReservationService, promotions *PromotionService, coupons *CouponService, pricing *PricingService, taxes *TaxService, audit *AuditService) *OrderService {
+	s := &OrderService{store: store, bookstore: bookstore, customerstore: customerstore, editionstore: editionstore, inventory: inventory, reservations: reservations, promotions: promotions, coupons: coupons, pricing: pricing, taxes: taxes, audit: audit}
+	// Backorders are filled as soon as stock arrives
+	inventory.onRestock = append(inventory.onRestock, func(ctx context.Context, bookID int) {
+		if _, err := s.FillBackorders(ctx, bookID); err != nil {
+			log.Printf("Error filling backorders of book %d: %v", bookID, err)
+		}
+	})
+	return s
 }
 
-func NewOrderService(store repositories.OrderStore, bookstore repositories.BookStore, customerstore repositories.CustomerStore) *OrderService {
-	return &OrderService{store: store, bookstore: bookstore, customerstore: customerstore}
+// withTx returns a copy of the service whose stores, and those of the
+// services it takes stock, reservations and coupons through, work inside tx
+func (s *OrderService) withTx(tx bun.IDB) *OrderService {
+	c := *s
+	c.store = s.store.WithTx(tx)
+	c.bookstore = s.bookstore.WithTx(tx)
+	c.editionstore = s.editionstore.WithTx(tx)
+	c.inventory = s.inventory.withTx(tx)
+	c.reservations = s.reservations.withTx(tx)
+	c.coupons = s.coupons.withTx(tx)
+	c.audit = s.audit.withTx(tx)
+	return &c
 }
 
-// CreateOrder processes an order with stock updates
+// inTx runs fn on a copy of the service that works inside one transaction,
+// so that an order, the stock it moves and the ledger movements recording
+// it are saved together or not at all
+func (s *OrderService) inTx(ctx context.Context, fn func(tx *OrderService) error) error {
+	return s.store.RunInTx(ctx, func(ctx context.Context, tx bun.IDB) error {
+		return fn(s.withTx(tx))
+	})
+}
+
+// CreateOrder processes an order with stock updates. The stock it takes,
+// the movements recording it and the order are saved in one transaction,
+// so an order that fails on the way leaves the stock as it was.
 func (s *OrderService) CreateOrder(ctx context.Context, order models.Order) (models.Order, error) {
 	select {
 	case <-ctx.Done():
@@ -26,43 +69,308 @@ func (s *OrderService) CreateOrder(ctx context.Context, order models.Order) (mod
 	default:
 	}
 
+	var created models.Order
+	err := s.inTx(ctx, func(tx *OrderService) error {
+		var err error
+		created, err = tx.createOrder(ctx, order)
+		return err
+	})
+	if err != nil {
+		return models.Order{}, err
+	}
+	return created, nil
+}
+
+// createOrder does the work of CreateOrder inside its transaction
+func (s *OrderService) createOrder(ctx context.Context, order models.Order) (models.Order, error) {
 	User, err := s.customerstore.GetCustomer(order.UserID)
 	if err != nil {
-		return models.Order{}, fmt.Errorf("User with ID %d not found .", order.UserID)
+		return models.Order{}, invalidf("User with ID %d not found .", order.UserID)
 	}
 	order.User = &User
 
-	var total float64
-	for i, item := range order.Items {
-		book, err := s.bookstore.GetBook(item.BookID)
-		if err != nil {
-			return models.Order{}, fmt.Errorf("book with ID %d not found .", item.BookID)
+	// The order is priced in the currency asked for, or the customer's
+	if order.Currency, order.ExchangeRate, err = s.pricing.quote(order.Currency, order.UserID); err != nil {
+		return models.Order{}, err
+	}
+	// and taxed at the rates of the customer's address
+	taxRates, err := s.taxes.ratesFor(User.Address)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	var coupon models.Coupon
+	if order.CouponCode != "" {
+		if coupon, err = s.coupons.find(order.CouponCode); err != nil {
+			return models.Order{}, err
+		}
+		order.CouponCode = coupon.Code
+	}
+
+	var items []models.OrderItem
+	taken := make(map[int]int)
+	for _, item := range order.Items {
+		if item.EditionID > 0 {
+			edition, err := s.takeEditionStock(ctx, item)
+			if err != nil {
+				return models.Order{}, err
+			}
+			item.BookID = edition.BookID
+			item.Edition = &edition
+			items = append(items, item)
+			continue
 		}
 
-		if book.Stock < item.Quantity {
-			return models.Order{}, fmt.Errorf("insufficient stock for book ID %d", item.BookID)
+		book, err := s.bookstore.GetBookForUpdate(item.BookID)
+		if err != nil {
+			return models.Order{}, invalidf("book with ID %d not found .", item.BookID)
 		}
 
-		book.Stock -= item.Quantity
-		if _, err := s.bookstore.UpdateBook(book.ID, book); err != nil {
+		item.Book = &book
+		bookItems, err := s.takeBookStock(ctx, item, book, order.UserID)
+		if err != nil {
 			return models.Order{}, err
 		}
+		for _, bookItem := range bookItems {
+			if !bookItem.Backordered {
+				taken[book.ID] += bookItem.Quantity
+			}
+		}
+		items = append(items, bookItems...)
+	}
 
-		total += float64(item.Quantity) * book.Price
+	order.Items = items
+	if err := s.price(ctx, &order); err != nil {
+		return models.Order{}, err
+	}
 
-		order.Items[i].Book = &book
+	// The coupon is redeemed last, once the order is known to be valid. The
+	// redemption is part of the order's transaction: an order that is not
+	// saved does not use up the coupon.
+	var redemption models.CouponRedemption
+	order.CouponDiscount = models.Money{Currency: order.TotalPrice.Currency}
+	if coupon.ID > 0 {
+		var amount models.Money
+		if redemption, amount, err = s.coupons.redeem(coupon, order.UserID, order.TotalPrice, order.ExchangeRate); err != nil {
+			return models.Order{}, err
+		}
+		order.CouponDiscount = amount
+		order.TotalPrice = order.TotalPrice.Sub(amount)
 	}
+	s.taxes.apply(&order, taxRates)
 
-	order.TotalPrice = total
 	order.Status = "Created"
+	order.Version = 0
 	createdOrder, err := s.store.CreateOrder(order)
 	if err != nil {
 		return models.Order{}, err
 	}
+	if redemption.ID > 0 {
+		if err := s.coupons.attach(redemption, createdOrder.ID); err != nil {
+			return models.Order{}, err
+		}
+	}
 
+	s.audit.Record(ctx, models.AuditOrder, createdOrder.ID, models.AuditCreate, nil, createdOrder)
+	if err := s.reservations.convert(order.UserID, taken); err != nil {
+		return models.Order{}, err
+	}
+	reason := fmt.Sprintf("order %d", createdOrder.ID)
+	if err := s.recordMovements(ctx, createdOrder.ID, createdOrder.Items, models.MovementSale, reason); err != nil {
+		return models.Order{}, err
+	}
 	return createdOrder, nil
 }
 
+// recordMovements records the stock each item of an order took (a sale) or
+// gave back (a cancellation) in the stock ledger. Backordered items have
+// not taken any stock yet.
+func (s *OrderService) recordMovements(ctx context.Context, orderID int, items []models.OrderItem, kind, reason string) error {
+	var movements []models.StockMovement
+	for _, item := range items {
+		if item.Backordered {
+			continue
+		}
+		quantity := item.Quantity
+		if kind == models.MovementSale {
+			quantity = -quantity
+		}
+		movements = append(movements, models.StockMovement{
+			BookID:     item.BookID,
+			EditionID:  item.EditionID,
+			LocationID: item.LocationID,
+			Kind:       kind,
+			Quantity:   quantity,
+			Reason:     reason,
+			OrderID:    orderID,
+		})
+	}
+	return s.inventory.record(ctx, movements...)
+}
+
+// restoreStock puts the stock taken by the items of an order back
+func (s *OrderService) restoreStock(ctx context.Context, items []models.OrderItem) error {
+	for _, item := range items {
+		if item.Backordered {
+			continue
+		}
+		if item.EditionID > 0 {
+			if _, err := s.adjustEditionStock(ctx, item.EditionID, item.Quantity); err != nil {
+				return err
+			}
+			continue
+		}
+
+		book, err := s.bookstore.GetBook(item.BookID)
+		if err != nil {
+			return err
+		}
+		if err := s.inventory.release(ctx, item.BookID, item.LocationID, item.Quantity); err != nil {
+			return err
+		}
+		if err := s.adjustBookStock(ctx, book, item.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shipFrom splits an item into one item per location its copies are taken
+// from; its discount is shared out by quantity
+func shipFrom(item models.OrderItem, picks []models.StockLevel) []models.OrderItem {
+	items := make([]models.OrderItem, len(picks))
+	quantities := make([]int, len(picks))
+	for i, pick := range picks {
+		quantities[i] = pick.Quantity
+	}
+	discounts := item.Discount.Allocate(quantities)
+	for i, pick := range picks {
+		items[i] = item
+		items[i].LocationID = pick.LocationID
+		items[i].Location = pick.Location
+		items[i].Quantity = pick.Quantity
+		items[i].Discount = discounts[i]
+	}
+	return items
+}
+
+// price prices the items of an order in its currency, applies the running
+// promotions to them and sets its discounts and total
+func (s *OrderService) price(ctx context.Context, order *models.Order) error {
+	if err := s.pricing.priceItems(order.Items, order.Currency, order.ExchangeRate); err != nil {
+		return err
+	}
+	discounts, err := s.promotions.apply(ctx, order.Items, order.ExchangeRate)
+	if err != nil {
+		return err
+	}
+
+	gross := models.Money{Currency: order.Currency}
+	discount := gross
+	for _, item := range order.Items {
+		gross = gross.Add(item.UnitPrice.Times(item.Quantity))
+		discount = discount.Add(item.Discount)
+	}
+	order.Discounts = discounts
+	order.Discount = discount
+	order.TotalPrice = gross.Sub(discount)
+	return nil
+}
+
+// takeBookStock takes the copies of an item from the stock of its book and
+// returns the items they ship as. Copies reserved by anyone but the ordering
+// user are not for sale; book must have been read with GetBookForUpdate, so
+// that no one reserves copies between the count and the sale. What the stock cannot cover is backordered if the
+// book's policy allows it, and so is every copy of an unreleased book that
+// can be pre-ordered.
+func (s *OrderService) takeBookStock(ctx context.Context, item models.OrderItem, book models.Book, userID int) ([]models.OrderItem, error) {
+	item.BookID = book.ID
+	item.UnitCost = book.CostPrice
+	if book.PublishedAt.After(time.Now()) {
+		if book.BackorderPolicy != models.BackorderPreorder {
+			return nil, conflictf("book with ID %d is not released until %s", book.ID, book.PublishedAt.Format("2006-01-02"))
+		}
+		return []models.OrderItem{backordered(item, book)}, nil
+	}
+
+	held, err := s.reservations.heldByOthers(book.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	inStock := min(max(book.Stock-held, 0), item.Quantity)
+	if inStock < item.Quantity && book.BackorderPolicy == models.BackorderNone {
+		return nil, conflictf("insufficient stock for book ID %d", book.ID)
+	}
+
+	var items []models.OrderItem
+	if inStock > 0 {
+		picks, err := s.inventory.allocate(ctx, book, inStock)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.adjustBookStock(ctx, book, -inStock); err != nil {
+			return nil, err
+		}
+		items = shipFrom(item, picks)
+	}
+	if rest := item.Quantity - inStock; rest > 0 {
+		item.Quantity = rest
+		items = append(items, backordered(item, book))
+	}
+	return items, nil
+}
+
+// backordered marks an item as waiting for stock of its book
+func backordered(item models.OrderItem, book models.Book) models.OrderItem {
+	item.Backordered = true
+	item.ExpectedAt = book.RestockExpectedAt
+	if book.PublishedAt.After(time.Now()) {
+		item.ExpectedAt = book.PublishedAt
+	}
+	item.LocationID, item.Location = 0, nil
+	return item
+}
+
+// adjustBookStock changes the stock of a book by delta and audits the change
+func (s *OrderService) adjustBookStock(ctx context.Context, book models.Book, delta int) error {
+	before := book
+	book.Stock += delta
+	updated, err := s.bookstore.UpdateBook(book.ID, book)
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditBook, book.ID, models.AuditUpdate, before, updated)
+	return nil
+}
+
+// adjustEditionStock changes the stock of an edition by delta and audits
+// the change. The store changes the stock in place, so concurrent orders
+// for the same edition cannot oversell it.
+func (s *OrderService) adjustEditionStock(ctx context.Context, editionID int, delta int) (models.Edition, error) {
+	updated, err := s.editionstore.AdjustEditionStock(editionID, delta)
+	if err != nil {
+		return models.Edition{}, err
+	}
+	before := updated
+	before.Stock -= delta
+	s.audit.Record(ctx, models.AuditEdition, editionID, models.AuditUpdate, before, updated)
+	return updated, nil
+}
+
+// takeEditionStock checks that an item's edition belongs to the ordered book
+// (when one is given) and removes the ordered quantity from its stock
+func (s *OrderService) takeEditionStock(ctx context.Context, item models.OrderItem) (models.Edition, error) {
+	edition, err := s.editionstore.GetEdition(item.EditionID)
+	if err != nil {
+		return models.Edition{}, err
+	}
+	if item.BookID > 0 && item.BookID != edition.BookID {
+		return models.Edition{}, invalidf("edition with ID %d is not an edition of book ID %d", edition.ID, item.BookID)
+	}
+
+	return s.adjustEditionStock(ctx, edition.ID, -item.Quantity)
+}
+
 // GetOrder retrieves an order
 func (s *OrderService) GetOrder(ctx context.Context, id int) (models.Order, error) {
 	select {
@@ -73,7 +381,8 @@ func (s *OrderService) GetOrder(ctx context.Context, id int) (models.Order, erro
 	return s.store.GetOrder(id)
 }
 
-// UpdateOrder modifies an existing order and updates stock
+// UpdateOrder modifies an existing order and updates stock. The stock moves,
+// the ledger and the order are changed in one transaction.
 func (s *OrderService) UpdateOrder(ctx context.Context, id int, updatedOrder models.Order) (models.Order, error) {
 	select {
 	case <-ctx.Done():
@@ -81,48 +390,91 @@ func (s *OrderService) UpdateOrder(ctx context.Context, id int, updatedOrder mod
 	default:
 	}
 
-	tx, err := repositories.DB.BeginTx(ctx, nil)
+	var updated models.Order
+	err := s.inTx(ctx, func(tx *OrderService) error {
+		var err error
+		updated, err = tx.updateOrder(ctx, id, updatedOrder)
+		return err
+	})
 	if err != nil {
 		return models.Order{}, err
 	}
-	defer tx.Rollback()
+	return updated, nil
+}
 
+// updateOrder does the work of UpdateOrder inside its transaction
+func (s *OrderService) updateOrder(ctx context.Context, id int, updatedOrder models.Order) (models.Order, error) {
 	// Fetch existing order
 	existingOrder, err := s.store.GetOrder(id)
 	if err != nil {
 		return models.Order{}, err
 	}
+	if updatedOrder.Version, err = matchVersion(updatedOrder.Version, existingOrder.Version); err != nil {
+		return models.Order{}, err
+	}
+
+	// The order stays in its currency, at today's rate
+	updatedOrder.Currency = existingOrder.Currency
+	if updatedOrder.ExchangeRate, err = s.pricing.rate(updatedOrder.Currency); err != nil {
+		return models.Order{}, err
+	}
+	var address models.Address
+	if existingOrder.User != nil {
+		address = existingOrder.User.Address
+	}
+	taxRates, err := s.taxes.ratesFor(address)
+	if err != nil {
+		return models.Order{}, err
+	}
 
 	// Restore stock for old order items
-	for _, item := range existingOrder.Items {
-		book, err := s.bookstore.GetBook(item.BookID)
-		if err != nil {
-			return models.Order{}, err
-		}
-		book.Stock += item.Quantity
-		if _, err := s.bookstore.UpdateBook(item.BookID, book); err != nil {
-			return models.Order{}, err
-		}
+	if err := s.restoreStock(ctx, existingOrder.Items); err != nil {
+		return models.Order{}, err
 	}
 
 	// Update stock for new order items
-	for i, item := range updatedOrder.Items {
-		book, err := s.bookstore.GetBook(item.BookID)
+	var items []models.OrderItem
+	for _, item := range updatedOrder.Items {
+		if item.EditionID > 0 {
+			edition, err := s.takeEditionStock(ctx, item)
+			if err != nil {
+				return models.Order{}, err
+			}
+			item.BookID = edition.BookID
+			item.Edition = &edition
+			item.LocationID, item.Location = 0, nil
+			item.UnitCost = models.Money{}
+			item.Backordered, item.ExpectedAt = false, time.Time{}
+			items = append(items, item)
+			continue
+		}
+
+		book, err := s.bookstore.GetBookForUpdate(item.BookID)
 		if err != nil {
 			return models.Order{}, err
 		}
 
-		if book.Stock < item.Quantity {
-			return models.Order{}, fmt.Errorf("insufficient stock for book ID %d", item.BookID)
+		item.Book = &book
+		bookItems, err := s.takeBookStock(ctx, item, book, existingOrder.UserID)
+		if err != nil {
+			return models.Order{}, err
 		}
-
-		book.Stock -= item.Quantity
-		if _, err := s.bookstore.UpdateBook(item.BookID, book); err != nil {
+		items = append(items, bookItems...)
+	}
+	updatedOrder.Items = items
+	if err := s.price(ctx, &updatedOrder); err != nil {
+		return models.Order{}, err
+	}
+	// The coupon stays with the order it was redeemed for
+	updatedOrder.CouponCode = existingOrder.CouponCode
+	updatedOrder.CouponDiscount = models.Money{Currency: updatedOrder.TotalPrice.Currency}
+	if updatedOrder.CouponCode != "" {
+		if updatedOrder.CouponDiscount, err = s.coupons.reprice(updatedOrder.CouponCode, existingOrder.CouponDiscount, updatedOrder.TotalPrice, updatedOrder.ExchangeRate); err != nil {
 			return models.Order{}, err
 		}
-
-		updatedOrder.Items[i].BookID = book.ID
+		updatedOrder.TotalPrice = updatedOrder.TotalPrice.Sub(updatedOrder.CouponDiscount)
 	}
+	s.taxes.apply(&updatedOrder, taxRates)
 
 	// Update order
 	updatedOrder, err = s.store.UpdateOrder(id, updatedOrder)
@@ -130,39 +482,80 @@ func (s *OrderService) UpdateOrder(ctx context.Context, id int, updatedOrder mod
 		return models.Order{}, err
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
+	s.audit.Record(ctx, models.AuditOrder, id, models.AuditUpdate, existingOrder, updatedOrder)
+	reason := fmt.Sprintf("order %d changed", id)
+	if err := s.recordMovements(ctx, id, existingOrder.Items, models.MovementCancellation, reason); err != nil {
+		return models.Order{}, err
+	}
+	if err := s.recordMovements(ctx, id, updatedOrder.Items, models.MovementSale, reason); err != nil {
 		return models.Order{}, err
 	}
-
 	return updatedOrder, nil
 }
 
-// DeleteOrder removes an order
-func (s *OrderService) DeleteOrder(ctx context.Context, id int) error {
+// PatchOrder applies a merge patch or JSON Patch to an order. Stock is moved
+// the same way as for a full update.
+func (s *OrderService) PatchOrder(ctx context.Context, id int, version int, contentType string, patch []byte) (models.Order, error) {
+	select {
+	case <-ctx.Done():
+		return models.Order{}, ctx.Err()
+	default:
+	}
+
+	existingOrder, err := s.store.GetOrder(id)
+	if err != nil {
+		return models.Order{}, err
+	}
+	var order models.Order
+	if err := applyPatch(existingOrder, contentType, patch, &order); err != nil {
+		return models.Order{}, err
+	}
+	if err := validatePatched(order, &models.OrderRequest{}); err != nil {
+		return models.Order{}, err
+	}
+
+	order.Version = version
+	return s.UpdateOrder(ctx, id, order)
+}
+
+// DeleteOrder removes an order if it is still at the given version (0 for
+// any) and restores the stock its items took, in one transaction
+func (s *OrderService) DeleteOrder(ctx context.Context, id int, version int) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
 	default:
 	}
 
-	tx, err := repositories.DB.BeginTx(ctx, nil)
+	return s.inTx(ctx, func(tx *OrderService) error {
+		return tx.deleteOrder(ctx, id, version)
+	})
+}
+
+// deleteOrder does the work of DeleteOrder inside its transaction
+func (s *OrderService) deleteOrder(ctx context.Context, id int, version int) error {
+	existingOrder, err := s.store.GetOrder(id)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
 
-	err = s.store.DeleteOrder(id)
-	if err != nil {
+	if version, err = matchVersion(version, existingOrder.Version); err != nil {
 		return err
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
+	err = s.store.DeleteOrder(id, version)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	s.audit.Record(ctx, models.AuditOrder, id, models.AuditDelete, existingOrder, nil)
+
+	// The stock of a deleted order goes back on the shelf
+	if err := s.restoreStock(ctx, existingOrder.Items); err != nil {
+		return err
+	}
+	reason := fmt.Sprintf("order %d deleted", id)
+	return s.recordMovements(ctx, id, existingOrder.Items, models.MovementCancellation, reason)
 }
 
 // ListOrders fetches all orders
@@ -193,3 +586,111 @@ func (s *OrderService) SearchOrdersByCustomerID(ctx context.Context, customerID
 	}
 	return s.store.SearchOrdersByUserID(customerID)
 }
+
+// FillBackorders ships the backordered copies of a book, oldest order first,
+// for as long as its stock covers them. It returns how many items it filled.
+func (s *OrderService) FillBackorders(ctx context.Context, bookID int) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+	}
+
+	items, err := s.store.BackorderedItems(bookID)
+	if err != nil {
+		return 0, err
+	}
+
+	filled := 0
+	for _, item := range items {
+		// Each item is filled in a transaction of its own
+		var shipped bool
+		err := s.inTx(ctx, func(tx *OrderService) error {
+			var err error
+			shipped, err = tx.fillBackorder(ctx, bookID, item)
+			return err
+		})
+		if err != nil {
+			return filled, err
+		}
+		if !shipped {
+			break
+		}
+		filled++
+	}
+	return filled, nil
+}
+
+// fillBackorder ships a backordered item of a book if its stock covers it
+// and tells whether it did
+func (s *OrderService) fillBackorder(ctx context.Context, bookID int, item models.OrderItem) (bool, error) {
+	// The book is fetched again for every item: each one changes its stock
+	// and version
+	book, err := s.bookstore.GetBookForUpdate(bookID)
+	if err != nil {
+		return false, err
+	}
+	before, err := s.store.GetOrder(item.OrderID)
+	if err != nil {
+		return false, err
+	}
+	held, err := s.reservations.heldByOthers(bookID, before.UserID)
+	if err != nil {
+		return false, err
+	}
+	if book.PublishedAt.After(time.Now()) || book.Stock-held < item.Quantity {
+		return false, nil
+	}
+	picks, err := s.inventory.allocate(ctx, book, item.Quantity)
+	if err != nil {
+		return false, err
+	}
+	if err := s.adjustBookStock(ctx, book, -item.Quantity); err != nil {
+		return false, err
+	}
+
+	item.Backordered, item.ExpectedAt = false, time.Time{}
+	item.UnitCost = book.CostPrice
+	shipped := shipFrom(item, picks)
+	// Fails if someone else filled or cancelled the item in the meantime
+	if err := s.store.FulfillBackorder(item.ID, shipped); err != nil {
+		return false, err
+	}
+
+	reason := fmt.Sprintf("order %d backorder filled", item.OrderID)
+	if err := s.recordMovements(ctx, item.OrderID, shipped, models.MovementSale, reason); err != nil {
+		return false, err
+	}
+	if after, err := s.store.GetOrder(item.OrderID); err == nil {
+		s.audit.Record(ctx, models.AuditOrder, item.OrderID, models.AuditUpdate, before, after)
+	}
+	return true, nil
+}
+
+// FillAllBackorders refreshes the expected dates of backordered items and
+// fills the backorders of every book whose stock allows it
+func (s *OrderService) FillAllBackorders(ctx context.Context) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+	}
+
+	if err := s.store.RefreshExpectedDates(); err != nil {
+		return 0, err
+	}
+	bookIDs, err := s.store.BackorderedBookIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	filled := 0
+	for _, bookID := range bookIDs {
+		n, err := s.FillBackorders(ctx, bookID)
+		filled += n
+		if err != nil {
+			return filled, err
+		}
+	}
+	return filled, nil
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"FinalProject/validation"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types accepted by the PATCH endpoints. A plain application/json body
+// is treated as a merge patch.
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	ErrUnsupportedPatch = errors.New("unsupported patch type; use " + MergePatchType + " or " + JSONPatchType)
+	ErrInvalidPatch     = errors.New("invalid patch")
+	ErrInvalidPatched   = errors.New("patched record is invalid")
+)
+
+// applyPatch applies a patch document to the JSON form of current and decodes
+// the result into patched. Field names match case-insensitively, like the
+// JSON decoding of a full request body, and unknown fields are rejected.
+func applyPatch(current any, contentType string, patch []byte, patched any) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ErrUnsupportedPatch
+	}
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	switch mediaType {
+	case MergePatchType, "application/json":
+		var p any
+		if err := json.Unmarshal(patch, &p); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		doc = mergePatch(doc, p)
+	case JSONPatchType:
+		var ops []patchOperation
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		for i, op := range ops {
+			if doc, err = op.apply(doc); err != nil {
+				return fmt.Errorf("%w: operation %d: %v", ErrInvalidPatch, i+1, err)
+			}
+		}
+	default:
+		return ErrUnsupportedPatch
+	}
+
+	if data, err = json.Marshal(doc); err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(patched); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatched, err)
+	}
+	return nil
+}
+
+// mergePatch implements RFC 7396: objects are merged key by key, null removes
+// a key and any other value replaces the target outright
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		key := matchKey(t, k)
+		if v == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergePatch(t[key], v)
+		}
+	}
+	return t
+}
+
+// matchKey finds the key of obj that name refers to, ignoring case
+func matchKey(obj map[string]any, name string) string {
+	if _, ok := obj[name]; ok {
+		return name
+	}
+	for k := range obj {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return name
+}
+
+// patchOperation is one RFC 6902 JSON Patch operation
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+func (op patchOperation) apply(doc any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%s requires a value", op.Op)
+		}
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+		switch op.Op {
+		case "add":
+			return addAt(doc, path, value)
+		case "replace":
+			if _, err := getAt(doc, path); err != nil {
+				return nil, err
+			}
+			if doc, err = removeAt(doc, path); err != nil {
+				return nil, err
+			}
+			return addAt(doc, path, value)
+		default:
+			current, err := getAt(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("test failed at %q", op.Path)
+			}
+			return doc, nil
+		}
+	case "remove":
+		return removeAt(doc, path)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getAt(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if len(path) > len(from) && strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, fmt.Errorf("cannot move %q into itself", op.From)
+			}
+			if doc, err = removeAt(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+		return addAt(doc, path, value)
+	default:
+		return nil, fmt.Errorf("unknown op %q", op.Op)
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func getAt(doc any, path []string) (any, error) {
+	for _, token := range path {
+		child, err := childOf(doc, token)
+		if err != nil {
+			return nil, err
+		}
+		doc = child
+	}
+	return doc, nil
+}
+
+func addAt(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return patchAt(doc, path, func(parent any, token string) (any, error) {
+		switch p := parent.(type) {
+		case map[string]any:
+			p[matchKey(p, token)] = value
+			return p, nil
+		case []any:
+			if token == "-" {
+				return append(p, value), nil
+			}
+			i, err := arrayIndex(token, len(p)+1)
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		default:
+			return nil, fmt.Errorf("cannot add %q to a scalar", token)
+		}
+	})
+}
+
+func removeAt(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+	return patchAt(doc, path, func(parent any, token string) (any, error) {
+		switch p := parent.(type) {
+		case map[string]any:
+			key := matchKey(p, token)
+			if _, ok := p[key]; !ok {
+				return nil, fmt.Errorf("field %q not found", token)
+			}
+			delete(p, key)
+			return p, nil
+		case []any:
+			i, err := arrayIndex(token, len(p))
+			if err != nil {
+				return nil, err
+			}
+			return append(p[:i], p[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("cannot remove %q from a scalar", token)
+		}
+	})
+}
+
+// patchAt walks doc to the parent of the last path token, lets op change
+// that parent, and stores the result back into every container on the way
+func patchAt(doc any, path []string, op func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return op(doc, path[0])
+	}
+	child, err := childOf(doc, path[0])
+	if err != nil {
+		return nil, err
+	}
+	child, err = patchAt(child, path[1:], op)
+	if err != nil {
+		return nil, err
+	}
+	switch p := doc.(type) {
+	case map[string]any:
+		p[matchKey(p, path[0])] = child
+	case []any:
+		i, _ := arrayIndex(path[0], len(p))
+		p[i] = child
+	}
+	return doc, nil
+}
+
+func childOf(doc any, token string) (any, error) {
+	switch d := doc.(type) {
+	case map[string]any:
+		child, ok := d[matchKey(d, token)]
+		if !ok {
+			return nil, fmt.Errorf("field %q not found", token)
+		}
+		return child, nil
+	case []any:
+		i, err := arrayIndex(token, len(d))
+		if err != nil {
+			return nil, err
+		}
+		return d[i], nil
+	default:
+		return nil, fmt.Errorf("%q not found", token)
+	}
+}
+
+// arrayIndex parses an array index token that must be below limit
+func arrayIndex(token string, limit int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i >= limit {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func deepCopy(v any) any {
+	data, _ := json.Marshal(v)
+	var c any
+	json.Unmarshal(data, &c)
+	return c
+}
+
+// validatePatched checks a patched record against the rules of the request
+// type that creates it, e.g. a book against models.BookRequest
+func validatePatched(patched any, rules any) error {
+	data, err := json.Marshal(patched)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, rules); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatched, err)
+	}
+	return validation.Struct(rules)
+}
@@ -0,0 +1,513 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PricingService keeps the exchange rates and price lists the catalog is
+// sold in besides the store currency, and prices books and orders in them
+type PricingService struct {
+	store         repositories.PricingStore
+	bookStore     repositories.BookStore
+	customerStore repositories.CustomerStore
+	audit         *AuditService
+}
+
+func NewPricingService(store repositories.PricingStore, bookStore repositories.BookStore, customerStore repositories.CustomerStore, audit *AuditService) *PricingService {
+	return &PricingService{store: store, bookStore: bookStore, customerStore: customerStore, audit: audit}
+}
+
+// ListExchangeRates retrieves every exchange rate
+func (s *PricingService) ListExchangeRates(ctx context.Context) ([]models.ExchangeRate, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	return s.store.ListExchangeRates()
+}
+
+// SetExchangeRate adds or replaces the exchange rate of a currency
+func (s *PricingService) SetExchangeRate(ctx context.Context, currency string, rate float64) (models.ExchangeRate, error) {
+	select {
+	case <-ctx.Done():
+		return models.ExchangeRate{}, ctx.Err()
+	default:
+	}
+
+	exchangeRate, err := checkExchangeRate(currency, rate)
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+	saved, err := s.store.SetExchangeRates([]models.ExchangeRate{exchangeRate})
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+	return saved[0], nil
+}
+
+// ImportExchangeRates adds or replaces the exchange rates in a file: CSV
+// rows of currency and rate (a header row is skipped), or a JSON object of
+// rates by currency. Either every rate is taken or none is.
+func (s *PricingService) ImportExchangeRates(ctx context.Context, r io.Reader, format string) ([]models.ExchangeRate, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	var rates []models.ExchangeRate
+	switch format {
+	case "csv":
+		rows, err := csv.NewReader(r).ReadAll()
+		if err != nil {
+			return nil, invalidf("invalid CSV: %v", err)
+		}
+		for i, row := range rows {
+			if len(row) != 2 {
+				return nil, invalidf("line %d: expected currency and rate", i+1)
+			}
+			if i == 0 && strings.EqualFold(strings.TrimSpace(row[0]), "currency") {
+				continue
+			}
+			rate, err := strconv.ParseFloat(strings.TrimSpace(row[1]), 64)
+			if err != nil {
+				return nil, invalidf("line %d: invalid rate %q", i+1, row[1])
+			}
+			exchangeRate, err := checkExchangeRate(row[0], rate)
+			if err != nil {
+				return nil, invalidf("line %d: %w", i+1, err)
+			}
+			rates = append(rates, exchangeRate)
+		}
+	case "json":
+		var byCurrency map[string]float64
+		if err := json.NewDecoder(r).Decode(&byCurrency); err != nil {
+			return nil, invalidf("invalid JSON: %v", err)
+		}
+		for currency, rate := range byCurrency {
+			exchangeRate, err := checkExchangeRate(currency, rate)
+			if err != nil {
+				return nil, err
+			}
+			rates = append(rates, exchangeRate)
+		}
+	default:
+		return nil, invalidf("unsupported format %q; use csv or json", format)
+	}
+	if len(rates) == 0 {
+		return nil, invalidf("no exchange rates to import")
+	}
+
+	seen := make(map[string]bool)
+	for _, rate := range rates {
+		if seen[rate.Currency] {
+			return nil, invalidf("exchange rate for %s given twice", rate.Currency)
+		}
+		seen[rate.Currency] = true
+	}
+	return s.store.SetExchangeRates(rates)
+}
+
+// DeleteExchangeRate removes the exchange rate of a currency. Books in its
+// price list without a price of their own can no longer be sold in it.
+func (s *PricingService) DeleteExchangeRate(ctx context.Context, currency string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+	return s.store.DeleteExchangeRate(strings.ToUpper(currency))
+}
+
+// CreatePriceList inserts a new price list
+func (s *PricingService) CreatePriceList(ctx context.Context, list models.PriceList) (models.PriceList, error) {
+	select {
+	case <-ctx.Done():
+		return models.PriceList{}, ctx.Err()
+	default:
+	}
+
+	if err := normalizePriceList(&list); err != nil {
+		return models.PriceList{}, err
+	}
+	list.ID = 0
+	list.CreatedAt = time.Time{}
+	created, err := s.store.CreatePriceList(list)
+	if err != nil {
+		return models.PriceList{}, err
+	}
+	s.audit.Record(ctx, models.AuditPriceList, created.ID, models.AuditCreate, nil, created)
+	return created, nil
+}
+
+// GetPriceList retrieves a price list by ID
+func (s *PricingService) GetPriceList(ctx context.Context, id int) (models.PriceList, error) {
+	select {
+	case <-ctx.Done():
+		return models.PriceList{}, ctx.Err()
+	default:
+	}
+	return s.store.GetPriceList(id)
+}
+
+// UpdatePriceList modifies an existing price list. Its currency cannot
+// change, since its book prices are in it.
+func (s *PricingService) UpdatePriceList(ctx context.Context, id int, list models.PriceList) (models.PriceList, error) {
+	select {
+	case <-ctx.Done():
+		return models.PriceList{}, ctx.Err()
+	default:
+	}
+
+	if err := normalizePriceList(&list); err != nil {
+		return models.PriceList{}, err
+	}
+	existing, err := s.store.GetPriceList(id)
+	if err != nil {
+		return models.PriceList{}, err
+	}
+	if list.Currency != existing.Currency {
+		return models.PriceList{}, invalidf("the currency of a price list cannot change; create a new list")
+	}
+	updated, err := s.store.UpdatePriceList(id, list)
+	if err != nil {
+		return models.PriceList{}, err
+	}
+	s.audit.Record(ctx, models.AuditPriceList, id, models.AuditUpdate, existing, updated)
+	return updated, nil
+}
+
+// DeletePriceList removes a price list and its book prices
+func (s *PricingService) DeletePriceList(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetPriceList(id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeletePriceList(id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditPriceList, id, models.AuditDelete, existing, nil)
+	return nil
+}
+
+// ListPriceLists retrieves every price list
+func (s *PricingService) ListPriceLists(ctx context.Context) ([]models.PriceList, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	return s.store.ListPriceLists()
+}
+
+// ListBookPrices retrieves the books a price list has a price of their own for
+func (s *PricingService) ListBookPrices(ctx context.Context, listID int) ([]models.BookPrice, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if _, err := s.store.GetPriceList(listID); err != nil {
+		return nil, err
+	}
+	return s.store.ListBookPrices(listID)
+}
+
+// SetBookPrice gives a book its own price in a price list; amount is a
+// decimal in the currency of the list
+func (s *PricingService) SetBookPrice(ctx context.Context, listID, bookID int, amount string) (models.BookPrice, error) {
+	select {
+	case <-ctx.Done():
+		return models.BookPrice{}, ctx.Err()
+	default:
+	}
+
+	list, err := s.store.GetPriceList(listID)
+	if err != nil {
+		return models.BookPrice{}, err
+	}
+	if _, err := s.bookStore.GetBook(bookID); err != nil {
+		return models.BookPrice{}, err
+	}
+	price, err := models.ParseMoney(amount, list.Currency)
+	if err != nil {
+		return models.BookPrice{}, invalidf("%v", err)
+	}
+	if price.Sign() < 0 {
+		return models.BookPrice{}, invalidf("price cannot be negative")
+	}
+
+	before, _ := s.store.BookPrices([]int{bookID}, listID)
+	saved, err := s.store.SetBookPrice(models.BookPrice{PriceListID: listID, BookID: bookID, Price: price})
+	if err != nil {
+		return models.BookPrice{}, err
+	}
+	s.audit.Record(ctx, models.AuditPriceList, listID, models.AuditUpdate, before, map[int]models.Money{bookID: saved.Price})
+	return saved, nil
+}
+
+// DeleteBookPrice drops a book's own price from a price list; the book is
+// then sold at its converted store price
+func (s *PricingService) DeleteBookPrice(ctx context.Context, listID, bookID int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	before, err := s.store.BookPrices([]int{bookID}, listID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteBookPrice(listID, bookID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditPriceList, listID, models.AuditUpdate, before, map[int]models.Money{})
+	return nil
+}
+
+// BookPrices retrieves what a book costs in the store currency and in every
+// price list it can be sold in
+func (s *PricingService) BookPrices(ctx context.Context, bookID int) ([]models.Money, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	book, err := s.bookStore.GetBook(bookID)
+	if err != nil {
+		return nil, err
+	}
+	lists, err := s.store.ListPriceLists()
+	if err != nil {
+		return nil, err
+	}
+
+	prices := []models.Money{book.Price}
+	for _, list := range lists {
+		own, err := s.store.BookPrices([]int{bookID}, list.ID)
+		if err != nil {
+			return nil, err
+		}
+		if price, ok := own[bookID]; ok {
+			prices = append(prices, price)
+			continue
+		}
+		rate, err := s.rate(list.Currency)
+		if err != nil {
+			// Without a rate the book is not for sale in this currency
+			continue
+		}
+		prices = append(prices, book.Price.Convert(list.Currency, rate))
+	}
+	return prices, nil
+}
+
+// LocalizeBooks prices books, and their editions, in the currency a request
+// asks for, or else in that of the acting user's country
+func (s *PricingService) LocalizeBooks(ctx context.Context, books []models.Book, requested string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	currency, err := s.currencyFor(requested, ActorFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if currency == models.DefaultCurrency {
+		return nil
+	}
+	rate, err := s.rate(currency)
+	if err != nil {
+		return err
+	}
+	list, err := s.store.FindPriceList(currency)
+	if err != nil {
+		return err
+	}
+	bookIDs := make([]int, len(books))
+	for i, book := range books {
+		bookIDs[i] = book.ID
+	}
+	own, err := s.store.BookPrices(bookIDs, list.ID)
+	if err != nil {
+		return err
+	}
+
+	for i := range books {
+		price, ok := own[books[i].ID]
+		if !ok {
+			price = books[i].Price.Convert(currency, rate)
+		}
+		books[i].Price = price
+		for j := range books[i].Editions {
+			books[i].Editions[j].Price = books[i].Editions[j].Price.Convert(currency, rate)
+		}
+	}
+	return nil
+}
+
+// currencyFor is the currency a user is priced in: the one asked for, which
+// must be the store currency or have a price list, or else that of the
+// price list of the user's country, or else the store currency
+func (s *PricingService) currencyFor(requested string, userID int) (string, error) {
+	requested = strings.ToUpper(strings.TrimSpace(requested))
+	if requested == models.DefaultCurrency {
+		return requested, nil
+	}
+	if requested != "" {
+		list, err := s.store.FindPriceList(requested)
+		if err != nil {
+			return "", asInvalid(err)
+		}
+		return list.Currency, nil
+	}
+
+	if userID == 0 {
+		return models.DefaultCurrency, nil
+	}
+	user, err := s.customerStore.GetCustomer(userID)
+	if err != nil || strings.TrimSpace(user.Address.Country) == "" {
+		return models.DefaultCurrency, nil
+	}
+	list, err := s.store.PriceListForCountry(strings.TrimSpace(user.Address.Country))
+	if errors.Is(err, ErrNotFound) {
+		return models.DefaultCurrency, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return list.Currency, nil
+}
+
+// rate is the exchange rate of a currency; that of the store currency is 1
+func (s *PricingService) rate(currency string) (float64, error) {
+	if currency == models.DefaultCurrency {
+		return 1, nil
+	}
+	rate, err := s.store.GetExchangeRate(currency)
+	if err != nil {
+		return 0, asInvalid(err)
+	}
+	return rate.Rate, nil
+}
+
+// quote picks the currency of a user's order and its exchange rate
+func (s *PricingService) quote(requested string, userID int) (string, float64, error) {
+	currency, err := s.currencyFor(requested, userID)
+	if err != nil {
+		return "", 0, err
+	}
+	rate, err := s.rate(currency)
+	if err != nil {
+		return "", 0, err
+	}
+	return currency, rate, nil
+}
+
+// priceItems sets the UnitPrice of order items in currency: the book's own
+// price in the currency's price list, or else its store price (or that of
+// the edition ordered) converted at rate
+func (s *PricingService) priceItems(items []models.OrderItem, currency string, rate float64) error {
+	var own map[int]models.Money
+	if currency != models.DefaultCurrency {
+		list, err := s.store.FindPriceList(currency)
+		if err != nil {
+			return asInvalid(err)
+		}
+		bookIDs := make([]int, len(items))
+		for i, item := range items {
+			bookIDs[i] = item.BookID
+		}
+		if own, err = s.store.BookPrices(bookIDs, list.ID); err != nil {
+			return err
+		}
+	}
+
+	for i, item := range items {
+		if price, ok := own[item.BookID]; ok && item.EditionID == 0 {
+			if err := inCurrency(fmt.Sprintf("the %s list price of book %d", currency, item.BookID), currency, price); err != nil {
+				return err
+			}
+			items[i].UnitPrice = price
+			continue
+		}
+		price := storePrice(item)
+		if err := inStoreCurrency(fmt.Sprintf("the price of book %d", item.BookID), price); err != nil {
+			return err
+		}
+		items[i].UnitPrice = price.Convert(currency, rate)
+	}
+	return nil
+}
+
+// storePrice is what one copy of an item costs in the store currency
+func storePrice(item models.OrderItem) models.Money {
+	if item.EditionID > 0 && item.Edition != nil {
+		return item.Edition.Price
+	}
+	if item.Book != nil {
+		return item.Book.Price
+	}
+	return models.Money{Currency: models.DefaultCurrency}
+}
+
+// checkExchangeRate upper-cases a currency and checks its rate
+func checkExchangeRate(currency string, rate float64) (models.ExchangeRate, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !models.ValidCurrency(currency) {
+		return models.ExchangeRate{}, invalidf("invalid currency %q", currency)
+	}
+	if currency == models.DefaultCurrency {
+		return models.ExchangeRate{}, invalidf("%s is the store currency; its rate is always 1", currency)
+	}
+	if rate <= 0 {
+		return models.ExchangeRate{}, invalidf("exchange rate for %s must be positive", currency)
+	}
+	return models.ExchangeRate{Currency: currency, Rate: rate}, nil
+}
+
+// normalizePriceList upper-cases a price list's currency and trims its
+// countries
+func normalizePriceList(list *models.PriceList) error {
+	list.Currency = strings.ToUpper(strings.TrimSpace(list.Currency))
+	list.Name = strings.TrimSpace(list.Name)
+	if !models.ValidCurrency(list.Currency) {
+		return invalidf("invalid currency %q", list.Currency)
+	}
+	if list.Currency == models.DefaultCurrency {
+		return invalidf("%s is the store currency; books are priced in it already", list.Currency)
+	}
+	if list.Name == "" {
+		return invalidf("price list name is required")
+	}
+	countries := make([]string, 0, len(list.Countries))
+	for _, country := range list.Countries {
+		if country = strings.TrimSpace(country); country != "" {
+			countries = append(countries, country)
+		}
+	}
+	list.Countries = countries
+	return nil
+}
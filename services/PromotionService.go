@@ -0,0 +1,386 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PromotionService manages promotions and applies them when an order is
+// priced (see models.Promotion for how they combine)
+type PromotionService struct {
+	store       repositories.PromotionStore
+	bookStore   repositories.BookStore
+	authorStore repositories.AuthorStore
+	genreStore  repositories.GenreStore
+	audit       *AuditService
+}
+
+func NewPromotionService(store repositories.PromotionStore, bookStore repositories.BookStore, authorStore repositories.AuthorStore, genreStore repositories.GenreStore, audit *AuditService) *PromotionService {
+	return &PromotionService{store: store, bookStore: bookStore, authorStore: authorStore, genreStore: genreStore, audit: audit}
+}
+
+// CreatePromotion inserts a new promotion
+func (s *PromotionService) CreatePromotion(ctx context.Context, promotion models.Promotion) (models.Promotion, error) {
+	select {
+	case <-ctx.Done():
+		return models.Promotion{}, ctx.Err()
+	default:
+	}
+
+	if err := s.normalizePromotion(&promotion); err != nil {
+		return models.Promotion{}, err
+	}
+	promotion.ID = 0
+	promotion.CreatedAt = time.Time{}
+	created, err := s.store.CreatePromotion(promotion)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+	s.audit.Record(ctx, models.AuditPromotion, created.ID, models.AuditCreate, nil, created)
+	return created, nil
+}
+
+// GetPromotion retrieves a promotion by ID
+func (s *PromotionService) GetPromotion(ctx context.Context, id int) (models.Promotion, error) {
+	select {
+	case <-ctx.Done():
+		return models.Promotion{}, ctx.Err()
+	default:
+	}
+	return s.store.GetPromotion(id)
+}
+
+// UpdatePromotion modifies an existing promotion. Orders already placed keep
+// the discounts they got.
+func (s *PromotionService) UpdatePromotion(ctx context.Context, id int, promotion models.Promotion) (models.Promotion, error) {
+	select {
+	case <-ctx.Done():
+		return models.Promotion{}, ctx.Err()
+	default:
+	}
+
+	if err := s.normalizePromotion(&promotion); err != nil {
+		return models.Promotion{}, err
+	}
+	existing, err := s.store.GetPromotion(id)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+	updated, err := s.store.UpdatePromotion(id, promotion)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+	s.audit.Record(ctx, models.AuditPromotion, id, models.AuditUpdate, existing, updated)
+	return updated, nil
+}
+
+// DeletePromotion removes a promotion
+func (s *PromotionService) DeletePromotion(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetPromotion(id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeletePromotion(id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditPromotion, id, models.AuditDelete, existing, nil)
+	return nil
+}
+
+// ListPromotions retrieves every promotion, or only those running now
+func (s *PromotionService) ListPromotions(ctx context.Context, activeOnly bool) ([]models.Promotion, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	var activeAt time.Time
+	if activeOnly {
+		activeAt = time.Now()
+	}
+	return s.store.ListPromotions(activeAt)
+}
+
+// PromotionUsage reports what each promotion gave away on the orders placed
+// between from and to
+func (s *PromotionService) PromotionUsage(ctx context.Context, from, to time.Time) ([]models.PromotionUsage, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if to.Before(from) {
+		return nil, invalidf("'to' must not be before 'from'")
+	}
+	return s.store.PromotionUsage(from, to)
+}
+
+// orderLine is the copies of one book, or of one of its editions, in an
+// order; promotions apply per line
+type orderLine struct {
+	bookID    int
+	editionID int
+	quantity  int
+	unitPrice models.Money
+	items     []int // indexes of the order items of the line
+}
+
+// apply prices items with the promotions running now. It sets the Discount
+// of every item and returns the discounts applied to each line. Items are
+// priced in a currency that rate units of buy one unit of the store currency.
+func (s *PromotionService) apply(ctx context.Context, items []models.OrderItem, rate float64) ([]models.OrderDiscount, error) {
+	for i := range items {
+		items[i].Discount = models.Money{Currency: items[i].UnitPrice.Currency}
+	}
+	promotions, err := s.store.ListPromotions(time.Now())
+	if err != nil || len(promotions) == 0 {
+		return nil, err
+	}
+	for _, p := range promotions {
+		if err := inStoreCurrency(fmt.Sprintf("the amount of promotion %d", p.ID), p.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	var lines []*orderLine
+	byKey := make(map[[2]int]*orderLine)
+	for i, item := range items {
+		key := [2]int{item.BookID, item.EditionID}
+		line, ok := byKey[key]
+		if !ok {
+			line = &orderLine{bookID: item.BookID, editionID: item.EditionID, unitPrice: item.UnitPrice}
+			byKey[key] = line
+			lines = append(lines, line)
+		}
+		line.quantity += item.Quantity
+		line.items = append(line.items, i)
+	}
+
+	subtrees, err := s.genreSubtrees(promotions)
+	if err != nil {
+		return nil, err
+	}
+
+	var discounts []models.OrderDiscount
+	for _, line := range lines {
+		book := items[line.items[0]].Book
+		if book == nil || len(book.Contributors) == 0 {
+			fetched, err := s.bookStore.GetBook(line.bookID)
+			if err != nil {
+				return nil, err
+			}
+			book = &fetched
+		}
+
+		var applicable []models.Promotion
+		for _, p := range promotions {
+			if covers(p, *book, subtrees[p.Genre]) {
+				applicable = append(applicable, p)
+			}
+		}
+		lineDiscounts := bestDiscounts(applicable, *line, rate)
+		total := models.Money{Currency: line.unitPrice.Currency}
+		for i := range lineDiscounts {
+			lineDiscounts[i].BookID = line.bookID
+			lineDiscounts[i].EditionID = line.editionID
+			total = total.Add(lineDiscounts[i].Amount)
+		}
+		discounts = append(discounts, lineDiscounts...)
+
+		quantities := make([]int, len(line.items))
+		for i, idx := range line.items {
+			quantities[i] = items[idx].Quantity
+		}
+		for i, share := range total.Allocate(quantities) {
+			items[line.items[i]].Discount = share
+		}
+	}
+	return discounts, nil
+}
+
+// genreSubtrees maps the genre of each genre-scoped promotion to the slugs
+// of that genre and all its sub-genres
+func (s *PromotionService) genreSubtrees(promotions []models.Promotion) (map[string]map[string]bool, error) {
+	subtrees := make(map[string]map[string]bool)
+	var genres []models.Genre
+	for _, p := range promotions {
+		if p.Genre == "" || subtrees[p.Genre] != nil {
+			continue
+		}
+		if genres == nil {
+			var err error
+			if genres, err = s.genreStore.ListGenres(); err != nil {
+				return nil, err
+			}
+		}
+
+		subtree := map[string]bool{p.Genre: true}
+		// Walk down one level at a time until no genre is added
+		for added := true; added; {
+			added = false
+			for _, g := range genres {
+				if subtree[g.Slug] {
+					continue
+				}
+				for _, parent := range genres {
+					if parent.ID == g.ParentID && subtree[parent.Slug] {
+						subtree[g.Slug] = true
+						added = true
+						break
+					}
+				}
+			}
+		}
+		subtrees[p.Genre] = subtree
+	}
+	return subtrees, nil
+}
+
+// covers tells whether a promotion applies to a book; genres holds the
+// promotion's genre and its sub-genres
+func covers(p models.Promotion, book models.Book, genres map[string]bool) bool {
+	if p.Genre != "" {
+		found := false
+		for _, g := range book.Genres {
+			if genres[g] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if p.AuthorID > 0 && book.AuthorID != p.AuthorID {
+		found := false
+		for _, c := range book.Contributors {
+			if c.AuthorID == p.AuthorID && c.Role == models.RoleAuthor {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// bestDiscounts picks the promotions a line gets: the best one that is not
+// stackable, or every stackable one, whichever saves more. The discount
+// never exceeds the price of the line.
+func bestDiscounts(promotions []models.Promotion, line orderLine, rate float64) []models.OrderDiscount {
+	gross := line.unitPrice.Times(line.quantity)
+
+	var best models.OrderDiscount
+	var stacked []models.OrderDiscount
+	var stackedTotal models.Money
+	for _, p := range promotions {
+		amount := discountAmount(p, line, rate).Min(gross)
+		if amount.Sign() <= 0 {
+			continue
+		}
+		d := models.OrderDiscount{PromotionID: p.ID, Name: p.Name, Amount: amount}
+		if p.Stackable {
+			stacked = append(stacked, d)
+			stackedTotal = stackedTotal.Add(amount)
+		} else if best.Amount.Less(amount) {
+			best = d
+		}
+	}
+
+	if !best.Amount.Less(stackedTotal) {
+		if best.Amount.Sign() == 0 {
+			return nil
+		}
+		return []models.OrderDiscount{best}
+	}
+	// Stacked promotions cannot take more than the whole price
+	remaining := gross
+	for i := range stacked {
+		stacked[i].Amount = stacked[i].Amount.Min(remaining)
+		remaining = remaining.Sub(stacked[i].Amount)
+	}
+	return stacked
+}
+
+// discountAmount is what a promotion takes off a line on its own. The amount
+// of a fixed promotion is in the store currency and is converted at rate.
+func discountAmount(p models.Promotion, line orderLine, rate float64) models.Money {
+	switch p.Kind {
+	case models.PromotionPercentage:
+		return line.unitPrice.Times(line.quantity).Percent(p.Value)
+	case models.PromotionFixed:
+		return p.Amount.Convert(line.unitPrice.Currency, rate).Min(line.unitPrice).Times(line.quantity)
+	case models.PromotionBuyXGetY:
+		free := line.quantity / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+		return line.unitPrice.Times(free)
+	}
+	return models.Money{Currency: line.unitPrice.Currency}
+}
+
+// normalizePromotion trims a promotion and checks that its rule makes sense
+// and that its genre and author exist
+func (s *PromotionService) normalizePromotion(p *models.Promotion) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return invalidf("promotion name cannot be empty")
+	}
+	switch p.Kind {
+	case models.PromotionPercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return invalidf("a percentage promotion must take between 0 and 100 percent off")
+		}
+		p.Amount = models.Money{Currency: models.DefaultCurrency}
+	case models.PromotionFixed:
+		if err := storeAmount("promotion amount", &p.Amount); err != nil {
+			return err
+		}
+		if p.Amount.Sign() <= 0 {
+			return invalidf("a fixed promotion must take a positive amount off")
+		}
+		p.Value = 0
+	case models.PromotionBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return invalidf("a buy_x_get_y promotion needs a positive BuyQuantity and GetQuantity")
+		}
+		p.Value = 0
+		p.Amount = models.Money{Currency: models.DefaultCurrency}
+	default:
+		return invalidf("promotion kind must be one of %s", strings.Join(models.PromotionKinds, ", "))
+	}
+	if p.Kind != models.PromotionBuyXGetY {
+		p.BuyQuantity, p.GetQuantity = 0, 0
+	}
+	if !p.StartsAt.IsZero() && !p.EndsAt.IsZero() && !p.EndsAt.After(p.StartsAt) {
+		return invalidf("a promotion must end after it starts")
+	}
+
+	if p.Genre = strings.TrimSpace(p.Genre); p.Genre != "" {
+		genre, err := s.genreStore.FindGenre(models.Slugify(p.Genre))
+		if err != nil {
+			return asInvalid(err)
+		}
+		p.Genre = genre.Slug
+	}
+	if p.AuthorID > 0 {
+		author, err := s.authorStore.GetAuthor(p.AuthorID)
+		if err != nil {
+			return asInvalid(err)
+		}
+		p.AuthorID = author.ID
+	}
+	return nil
+}
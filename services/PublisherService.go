@@ -0,0 +1,102 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"context"
+	"strings"
+)
+
+type PublisherService struct {
+	store repositories.PublisherStore
+	audit *AuditService
+}
+
+func NewPublisherService(store repositories.PublisherStore, audit *AuditService) *PublisherService {
+	return &PublisherService{store: store, audit: audit}
+}
+
+// CreatePublisher inserts a new publisher
+func (s *PublisherService) CreatePublisher(ctx context.Context, publisher models.Publisher) (models.Publisher, error) {
+	select {
+	case <-ctx.Done():
+		return models.Publisher{}, ctx.Err()
+	default:
+	}
+
+	publisher.Name = strings.TrimSpace(publisher.Name)
+	if publisher.Name == "" {
+		return models.Publisher{}, invalidf("publisher name cannot be empty")
+	}
+
+	created, err := s.store.CreatePublisher(publisher)
+	if err != nil {
+		return models.Publisher{}, err
+	}
+	s.audit.Record(ctx, models.AuditPublisher, created.ID, models.AuditCreate, nil, created)
+	return created, nil
+}
+
+// GetPublisher retrieves a publisher by ID
+func (s *PublisherService) GetPublisher(ctx context.Context, id int) (models.Publisher, error) {
+	select {
+	case <-ctx.Done():
+		return models.Publisher{}, ctx.Err()
+	default:
+	}
+	return s.store.GetPublisher(id)
+}
+
+// UpdatePublisher modifies an existing publisher
+func (s *PublisherService) UpdatePublisher(ctx context.Context, id int, publisher models.Publisher) (models.Publisher, error) {
+	select {
+	case <-ctx.Done():
+		return models.Publisher{}, ctx.Err()
+	default:
+	}
+
+	publisher.Name = strings.TrimSpace(publisher.Name)
+	if publisher.Name == "" {
+		return models.Publisher{}, invalidf("publisher name cannot be empty")
+	}
+
+	existing, err := s.store.GetPublisher(id)
+	if err != nil {
+		return models.Publisher{}, err
+	}
+	updated, err := s.store.UpdatePublisher(id, publisher)
+	if err != nil {
+		return models.Publisher{}, err
+	}
+	s.audit.Record(ctx, models.AuditPublisher, id, models.AuditUpdate, existing, updated)
+	return updated, nil
+}
+
+// DeletePublisher removes a publisher that is no longer referenced
+func (s *PublisherService) DeletePublisher(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetPublisher(id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeletePublisher(id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditPublisher, id, models.AuditDelete, existing, nil)
+	return nil
+}
+
+// ListPublishers retrieves publishers, optionally filtered by name
+func (s *PublisherService) ListPublishers(ctx context.Context, name string) ([]models.Publisher, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	return s.store.ListPublishers(name)
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PurchasingService manages suppliers and the purchase orders placed with
+// them. Goods received against a purchase order are added to stock through
+// the stock ledger and update the cost price of the book.
+type PurchasingService struct {
+	store     repositories.PurchasingStore
+	bookStore repositories.BookStore
+	inventory *InventoryService
+	audit     *AuditService
+}
+
+func NewPurchasingService(store repositories.PurchasingStore, bookStore repositories.BookStore, inventory *InventoryService, audit *AuditService) *PurchasingService {
+	return &PurchasingService{store: store, bookStore: bookStore, inventory: inventory, audit: audit}
+}
+
+// CreateSupplier inserts a new supplier
+func (s *PurchasingService) CreateSupplier(ctx context.Context, supplier models.Supplier) (models.Supplier, error) {
+	select {
+	case <-ctx.Done():
+		return models.Supplier{}, ctx.Err()
+	default:
+	}
+
+	if err := normalizeSupplier(&supplier); err != nil {
+		return models.Supplier{}, err
+	}
+	created, err := s.store.CreateSupplier(supplier)
+	if err != nil {
+		return models.Supplier{}, err
+	}
+	s.audit.Record(ctx, models.AuditSupplier, created.ID, models.AuditCreate, nil, created)
+	return created, nil
+}
+
+// GetSupplier retrieves a supplier by ID
+func (s *PurchasingService) GetSupplier(ctx context.Context, id int) (models.Supplier, error) {
+	select {
+	case <-ctx.Done():
+		return models.Supplier{}, ctx.Err()
+	default:
+	}
+	return s.store.GetSupplier(id)
+}
+
+// UpdateSupplier modifies an existing supplier
+func (s *PurchasingService) UpdateSupplier(ctx context.Context, id int, supplier models.Supplier) (models.Supplier, error) {
+	select {
+	case <-ctx.Done():
+		return models.Supplier{}, ctx.Err()
+	default:
+	}
+
+	if err := normalizeSupplier(&supplier); err != nil {
+		return models.Supplier{}, err
+	}
+	existing, err := s.store.GetSupplier(id)
+	if err != nil {
+		return models.Supplier{}, err
+	}
+	updated, err := s.store.UpdateSupplier(id, supplier)
+	if err != nil {
+		return models.Supplier{}, err
+	}
+	s.audit.Record(ctx, models.AuditSupplier, id, models.AuditUpdate, existing, updated)
+	return updated, nil
+}
+
+// DeleteSupplier removes a supplier that has no purchase orders
+func (s *PurchasingService) DeleteSupplier(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetSupplier(id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteSupplier(id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditSupplier, id, models.AuditDelete, existing, nil)
+	return nil
+}
+
+// ListSuppliers retrieves all suppliers, optionally filtered by name
+func (s *PurchasingService) ListSuppliers(ctx context.Context, name string) ([]models.Supplier, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	return s.store.ListSuppliers(name)
+}
+
+// CreatePurchaseOrder drafts a purchase order
+func (s *PurchasingService) CreatePurchaseOrder(ctx context.Context, po models.PurchaseOrder) (models.PurchaseOrder, error) {
+	select {
+	case <-ctx.Done():
+		return models.PurchaseOrder{}, ctx.Err()
+	default:
+	}
+
+	if err := s.checkPurchaseOrder(po); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	po.Status = models.PurchaseOrderDraft
+	created, err := s.store.CreatePurchaseOrder(po)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	s.audit.Record(ctx, models.AuditPurchaseOrder, created.ID, models.AuditCreate, nil, created)
+	return created, nil
+}
+
+// GetPurchaseOrder retrieves a purchase order with its lines
+func (s *PurchasingService) GetPurchaseOrder(ctx context.Context, id int) (models.PurchaseOrder, error) {
+	select {
+	case <-ctx.Done():
+		return models.PurchaseOrder{}, ctx.Err()
+	default:
+	}
+	return s.store.GetPurchaseOrder(id)
+}
+
+// UpdatePurchaseOrder replaces the supplier and lines of a draft
+func (s *PurchasingService) UpdatePurchaseOrder(ctx context.Context, id int, po models.PurchaseOrder) (models.PurchaseOrder, error) {
+	select {
+	case <-ctx.Done():
+		return models.PurchaseOrder{}, ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetPurchaseOrder(id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	if err := s.checkPurchaseOrder(po); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	updated, err := s.store.UpdatePurchaseOrder(id, po)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	s.audit.Record(ctx, models.AuditPurchaseOrder, id, models.AuditUpdate, existing, updated)
+	return updated, nil
+}
+
+// DeletePurchaseOrder removes a draft
+func (s *PurchasingService) DeletePurchaseOrder(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetPurchaseOrder(id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeletePurchaseOrder(id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditPurchaseOrder, id, models.AuditDelete, existing, nil)
+	return nil
+}
+
+// ListPurchaseOrders retrieves purchase orders, newest first, optionally
+// filtered by status and supplier (0 for any)
+func (s *PurchasingService) ListPurchaseOrders(ctx context.Context, status string, supplierID int) ([]models.PurchaseOrder, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if status != "" && !containsString(models.PurchaseOrderStatuses, status) {
+		return nil, invalidf("status must be one of %s", strings.Join(models.PurchaseOrderStatuses, ", "))
+	}
+	return s.store.ListPurchaseOrders(status, supplierID)
+}
+
+// SendPurchaseOrder marks a draft as sent to the supplier; from then on it
+// can no longer be changed, only received
+func (s *PurchasingService) SendPurchaseOrder(ctx context.Context, id int) (models.PurchaseOrder, error) {
+	select {
+	case <-ctx.Done():
+		return models.PurchaseOrder{}, ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetPurchaseOrder(id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	if err := s.store.SendPurchaseOrder(id); err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	sent, err := s.store.GetPurchaseOrder(id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	s.audit.Record(ctx, models.AuditPurchaseOrder, id, models.AuditUpdate, existing, sent)
+	return sent, nil
+}
+
+// ReceiveGoods records a delivery against a sent purchase order. Each line
+// adds its quantity to the stock of the book, at the receipt's location or
+// the first one, and updates the book's cost price; a line without a unit
+// cost is received at the cost it was ordered at.
+func (s *PurchasingService) ReceiveGoods(ctx context.Context, id int, receipt models.GoodsReceipt) (models.PurchaseOrder, error) {
+	select {
+	case <-ctx.Done():
+		return models.PurchaseOrder{}, ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetPurchaseOrder(id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	switch existing.Status {
+	case models.PurchaseOrderDraft:
+		return models.PurchaseOrder{}, conflictf("purchase order with ID %d must be sent before goods can be received", id)
+	case models.PurchaseOrderReceived:
+		return models.PurchaseOrder{}, conflictf("purchase order with ID %d has already been received in full", id)
+	}
+	if receipt.LocationID > 0 {
+		if _, err := s.inventory.GetLocation(ctx, receipt.LocationID); err != nil {
+			return models.PurchaseOrder{}, asInvalid(err)
+		}
+	}
+
+	lines := make(map[int]models.PurchaseOrderLine, len(existing.Lines))
+	for _, line := range existing.Lines {
+		lines[line.ID] = line
+	}
+	seen := make(map[int]bool, len(receipt.Lines))
+	for i := range receipt.Lines {
+		received := &receipt.Lines[i]
+		line, ok := lines[received.PurchaseOrderLineID]
+		if !ok {
+			return models.PurchaseOrder{}, invalidf("line %d is not part of purchase order %d", received.PurchaseOrderLineID, id)
+		}
+		if seen[line.ID] {
+			return models.PurchaseOrder{}, invalidf("line %d appears more than once in the receipt", line.ID)
+		}
+		seen[line.ID] = true
+		if received.Quantity > line.Outstanding() {
+			return models.PurchaseOrder{}, invalidf("line %d has %d copies outstanding, not %d", line.ID, line.Outstanding(), received.Quantity)
+		}
+		if _, err := s.bookStore.GetBook(line.BookID); err != nil {
+			return models.PurchaseOrder{}, asInvalid(err)
+		}
+
+		received.BookID = line.BookID
+		if err := storeAmount("unit cost", &received.UnitCost); err != nil {
+			return models.PurchaseOrder{}, err
+		}
+		if received.UnitCost.Sign() == 0 {
+			received.UnitCost = line.UnitCost
+		}
+	}
+
+	receipt.ID = 0
+	receipt.PurchaseOrderID = id
+	receipt.ActorID = ActorFromContext(ctx)
+	created, err := s.store.ReceiveGoods(receipt)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+
+	reason := fmt.Sprintf("purchase order %d, receipt %d", id, created.ID)
+	for _, received := range created.Lines {
+		movement := models.StockMovement{
+			Kind:       models.MovementReceipt,
+			Quantity:   received.Quantity,
+			LocationID: receipt.LocationID,
+			Reason:     reason,
+			UnitCost:   received.UnitCost,
+		}
+		if _, err := s.inventory.RecordMovement(ctx, received.BookID, movement); err != nil {
+			return models.PurchaseOrder{}, err
+		}
+	}
+
+	updated, err := s.store.GetPurchaseOrder(id)
+	if err != nil {
+		return models.PurchaseOrder{}, err
+	}
+	s.audit.Record(ctx, models.AuditPurchaseOrder, id, models.AuditUpdate, existing, updated)
+	return updated, nil
+}
+
+// ListReceipts retrieves the goods receipts of a purchase order
+func (s *PurchasingService) ListReceipts(ctx context.Context, id int) ([]models.GoodsReceipt, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if _, err := s.store.GetPurchaseOrder(id); err != nil {
+		return nil, err
+	}
+	return s.store.ListReceipts(id)
+}
+
+// checkPurchaseOrder checks that the supplier and every book of a purchase
+// order exist and that no book is ordered twice. Unit costs without a
+// currency are taken to be in the store currency.
+func (s *PurchasingService) checkPurchaseOrder(po models.PurchaseOrder) error {
+	if len(po.Lines) == 0 {
+		return invalidf("a purchase order needs at least one line")
+	}
+	if _, err := s.store.GetSupplier(po.SupplierID); err != nil {
+		return asInvalid(err)
+	}
+	seen := make(map[int]bool, len(po.Lines))
+	for i := range po.Lines {
+		line := &po.Lines[i]
+		if line.Quantity <= 0 {
+			return invalidf("each line needs a positive quantity")
+		}
+		if err := storeAmount("unit cost", &line.UnitCost); err != nil {
+			return err
+		}
+		if seen[line.BookID] {
+			return invalidf("book with ID %d appears on more than one line", line.BookID)
+		}
+		seen[line.BookID] = true
+		if _, err := s.bookStore.GetBook(line.BookID); err != nil {
+			return asInvalid(err)
+		}
+	}
+	return nil
+}
+
+// normalizeSupplier trims a supplier and checks its name
+func normalizeSupplier(supplier *models.Supplier) error {
+	supplier.Name = strings.TrimSpace(supplier.Name)
+	supplier.Email = strings.TrimSpace(supplier.Email)
+	supplier.Phone = strings.TrimSpace(supplier.Phone)
+	if supplier.Name == "" {
+		return invalidf("supplier name cannot be empty")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"FinalProject/repositories"
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultRetentionDays is how long soft-deleted records are kept when
+// SOFT_DELETE_RETENTION_DAYS is not set
+const DefaultRetentionDays = 30
+
+// PurgeService permanently removes soft-deleted books, authors and customers
+// once they are past the retention window
+type PurgeService struct {
+	bookStore     repositories.BookStore
+	authorStore   repositories.AuthorStore
+	customerStore repositories.CustomerStore
+	retention     time.Duration
+}
+
+// PurgeResult counts the records removed by one purge run
+type PurgeResult struct {
+	Books     int
+	Authors   int
+	Customers int
+}
+
+func NewPurgeService(bookStore repositories.BookStore, authorStore repositories.AuthorStore, customerStore repositories.CustomerStore, retention time.Duration) *PurgeService {
+	if bookStore == nil || authorStore == nil || customerStore == nil {
+		log.Fatal("ERROR: BookStore, AuthorStore or CustomerStore is nil in PurgeService")
+	}
+	return &PurgeService{bookStore: bookStore, authorStore: authorStore, customerStore: customerStore, retention: retention}
+}
+
+// RetentionFromEnv reads the retention window from SOFT_DELETE_RETENTION_DAYS
+func RetentionFromEnv() time.Duration {
+	days := DefaultRetentionDays
+	if v := os.Getenv("SOFT_DELETE_RETENTION_DAYS"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			log.Printf("Invalid SOFT_DELETE_RETENTION_DAYS %q, using %d days", v, DefaultRetentionDays)
+		} else {
+			days = parsed
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// PurgeDeleted removes records deleted longer ago than the retention window.
+// Books go first since they may be the last thing crediting a deleted author;
+// records still referenced by an order are kept.
+func (s *PurgeService) PurgeDeleted(ctx context.Context) (PurgeResult, error) {
+	select {
+	case <-ctx.Done():
+		return PurgeResult{}, ctx.Err()
+	default:
+	}
+
+	before := time.Now().Add(-s.retention)
+	var result PurgeResult
+	var err error
+
+	if result.Books, err = s.bookStore.PurgeDeletedBooks(before); err != nil {
+		return result, err
+	}
+	if result.Customers, err = s.customerStore.PurgeDeletedCustomers(before); err != nil {
+		return result, err
+	}
+	if result.Authors, err = s.authorStore.PurgeDeletedAuthors(before); err != nil {
+		return result, err
+	}
+	return result, nil
+}
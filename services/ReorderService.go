@@ -0,0 +1,90 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"context"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Defaults for the reorder suggestions
+const (
+	DefaultSalesWindowDays  = 30
+	DefaultReorderCoverDays = 30
+)
+
+// ReorderService finds books that are running out and proposes how many
+// copies to reorder: enough to cover the next coverDays of sales at the rate
+// of the last windowDays, on top of the reorder threshold. A book that did
+// not sell is brought back to twice its threshold.
+type ReorderService struct {
+	store      repositories.InventoryStore
+	notifier   Notifier
+	windowDays int
+	coverDays  int
+}
+
+func NewReorderService(store repositories.InventoryStore, notifier Notifier, windowDays, coverDays int) *ReorderService {
+	if windowDays <= 0 {
+		windowDays = DefaultSalesWindowDays
+	}
+	if coverDays <= 0 {
+		coverDays = DefaultReorderCoverDays
+	}
+	return &ReorderService{store: store, notifier: notifier, windowDays: windowDays, coverDays: coverDays}
+}
+
+// DaysFromEnv reads a number of days from an environment variable, falling
+// back to def when it is unset or invalid
+func DaysFromEnv(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	days, err := strconv.Atoi(v)
+	if err != nil || days <= 0 {
+		log.Printf("Invalid %s %q, using %d days", name, v, def)
+		return def
+	}
+	return days
+}
+
+// LowStock lists the books at or below their reorder threshold with a
+// suggested reorder quantity, lowest stock first
+func (s *ReorderService) LowStock(ctx context.Context) ([]models.StockAlert, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -s.windowDays)
+	alerts, err := s.store.LowStock(since)
+	if err != nil {
+		return nil, err
+	}
+	for i := range alerts {
+		a := &alerts[i]
+		a.DailySales = float64(max(a.Sold, 0)) / float64(s.windowDays)
+		cover := int(math.Ceil(a.DailySales * float64(s.coverDays)))
+		a.SuggestedQuantity = a.Threshold + max(cover, a.Threshold) - a.Stock
+	}
+	return alerts, nil
+}
+
+// CheckLowStock sends an alert for every book at or below its reorder
+// threshold and returns the alerts sent
+func (s *ReorderService) CheckLowStock(ctx context.Context) ([]models.StockAlert, error) {
+	alerts, err := s.LowStock(ctx)
+	if err != nil || len(alerts) == 0 {
+		return alerts, err
+	}
+	if err := s.notifier.NotifyLowStock(ctx, alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
@@ -4,6 +4,7 @@ import (
 	"FinalProject/models"
 	"FinalProject/repositories"
 	"context"
+	"fmt"
 	"time"
 )
 
@@ -28,15 +29,52 @@ func (rs *ReportService) GenerateSalesReport(ctx context.Context, from, to time.
 		return models.SalesReport{}, err
 	}
 
-	totalRevenue := 0.0
+	// Totals are summed in minor units, so they are exact. Orders in
+	// another currency count at the rate they were priced at. Revenue comes
+	// from order totals rather than unit prices, which older items lack.
+	totalRevenue := models.Money{Currency: models.DefaultCurrency}
+	totalDiscount := totalRevenue
+	totalTax := totalRevenue
+	totalCost := totalRevenue
+	var taxTotals []models.TaxTotal
+	taxIndex := make(map[models.TaxTotal]int) // by rate, with no amounts
 	totalOrders := len(orders)
 	bookSalesMap := make(map[int]int)
 	bookMap := make(map[int]*models.Book) // ✅ Store pointers instead of values
 
 	for _, o := range orders {
-		totalRevenue += o.TotalPrice
+		amounts := []models.Money{o.TotalPrice, o.Discount, o.CouponDiscount, o.Tax}
+		for _, tax := range o.Taxes {
+			amounts = append(amounts, tax.Taxable, tax.Amount)
+		}
+		if err := inOrderCurrency(o, amounts...); err != nil {
+			return models.SalesReport{}, err
+		}
+		for _, item := range o.Items {
+			if err := inStoreCurrency(fmt.Sprintf("the unit cost of order item %d", item.ID), item.UnitCost); err != nil {
+				return models.SalesReport{}, err
+			}
+		}
+
+		totalRevenue = totalRevenue.Add(inBase(o, o.TotalPrice))
+		totalDiscount = totalDiscount.Add(inBase(o, o.Discount)).Add(inBase(o, o.CouponDiscount))
+		totalTax = totalTax.Add(inBase(o, o.Tax))
+		for _, tax := range o.Taxes {
+			key := models.TaxTotal{Name: tax.Name, Country: tax.Country, State: tax.State, Category: tax.Category, Rate: tax.Rate}
+			i, ok := taxIndex[key]
+			if !ok {
+				i = len(taxTotals)
+				taxIndex[key] = i
+				key.Taxable = models.Money{Currency: models.DefaultCurrency}
+				key.Amount = key.Taxable
+				taxTotals = append(taxTotals, key)
+			}
+			taxTotals[i].Taxable = taxTotals[i].Taxable.Add(inBase(o, tax.Taxable))
+			taxTotals[i].Amount = taxTotals[i].Amount.Add(inBase(o, tax.Amount))
+		}
 		for _, item := range o.Items {
 			bookSalesMap[item.BookID] += item.Quantity
+			totalCost = totalCost.Add(item.UnitCost.Times(item.Quantity))
 			bookMap[item.BookID] = item.Book // ✅ Store the pointer directly
 		}
 	}
@@ -64,7 +102,12 @@ func (rs *ReportService) GenerateSalesReport(ctx context.Context, from, to time.
 	report := models.SalesReport{
 		Timestamp:       time.Now(),
 		TotalRevenue:    totalRevenue,
+		TotalDiscount:   totalDiscount,
 		TotalOrders:     totalOrders,
+		TotalTax:        totalTax,
+		TotalCost:       totalCost,
+		GrossMargin:     totalRevenue.Sub(totalTax).Sub(totalCost),
+		TaxTotals:       taxTotals,
 		TopSellingBooks: topSelling,
 	}
 
@@ -76,3 +119,27 @@ func (rs *ReportService) GenerateSalesReport(ctx context.Context, from, to time.
 
 	return report, nil
 }
+
+// inOrderCurrency checks that amounts of an order are in its own currency
+// or the store currency, the two inBase can count
+func inOrderCurrency(o models.Order, amounts ...models.Money) error {
+	for _, amount := range amounts {
+		if models.InCurrency(o.Currency, amount) != nil && models.InCurrency(models.DefaultCurrency, amount) != nil {
+			return conflictf("order %d has an amount in %s, neither its currency %s nor the store currency %s: %w",
+				o.ID, amount.Currency, o.Currency, models.DefaultCurrency, models.ErrCurrencyMismatch)
+		}
+	}
+	return nil
+}
+
+// inBase is an amount of an order in the store currency
+func inBase(o models.Order, amount models.Money) models.Money {
+	if amount.Currency == "" || amount.Currency == models.DefaultCurrency {
+		return amount
+	}
+	rate := o.ExchangeRate
+	if rate == 0 {
+		rate = 1
+	}
+	return amount.Revert(models.DefaultCurrency, rate)
+}
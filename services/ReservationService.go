@@ -0,0 +1,143 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// DefaultReservationTTL is how long a reservation holds its copies
+const DefaultReservationTTL = 15 * time.Minute
+
+// ReservationService holds copies of a book for a customer during checkout.
+// A reservation lasts ttl; creating an order turns the copies it takes from
+// the customer's reservations into the sale, and expired ones are swept
+// away.
+type ReservationService struct {
+	store         repositories.ReservationStore
+	customerstore repositories.CustomerStore
+	ttl           time.Duration
+}
+
+func NewReservationService(store repositories.ReservationStore, customerstore repositories.CustomerStore, ttl time.Duration) *ReservationService {
+	if ttl <= 0 {
+		ttl = DefaultReservationTTL
+	}
+	return &ReservationService{store: store, customerstore: customerstore, ttl: ttl}
+}
+
+// withTx returns a copy of the service whose store works inside tx
+func (s *ReservationService) withTx(tx bun.IDB) *ReservationService {
+	c := *s
+	c.store = s.store.WithTx(tx)
+	return &c
+}
+
+// DurationFromEnv reads a duration such as "15m" from an environment
+// variable, falling back to def when it is unset or invalid
+func DurationFromEnv(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s %q, using %v", name, v, def)
+		return def
+	}
+	return d
+}
+
+// Reserve holds copies of a book for a customer until the reservation
+// expires. Reserving a book again replaces the earlier reservation and
+// starts its clock over.
+func (s *ReservationService) Reserve(ctx context.Context, reservation models.Reservation) (models.Reservation, error) {
+	select {
+	case <-ctx.Done():
+		return models.Reservation{}, ctx.Err()
+	default:
+	}
+
+	if _, err := s.customerstore.GetCustomer(reservation.UserID); err != nil {
+		return models.Reservation{}, invalidf("User with ID %d not found .", reservation.UserID)
+	}
+
+	reservation.ID = 0
+	reservation.ExpiresAt = time.Now().Add(s.ttl)
+	reservation.CreatedAt = time.Time{}
+	created, err := s.store.Reserve(reservation)
+	if err != nil {
+		return models.Reservation{}, asInvalid(err)
+	}
+	return created, nil
+}
+
+// GetReservation retrieves a reservation. A userID other than 0 only finds
+// the reservations of that user.
+func (s *ReservationService) GetReservation(ctx context.Context, id, userID int) (models.Reservation, error) {
+	select {
+	case <-ctx.Done():
+		return models.Reservation{}, ctx.Err()
+	default:
+	}
+
+	reservation, err := s.store.GetReservation(id)
+	if err != nil {
+		return models.Reservation{}, err
+	}
+	if userID > 0 && reservation.UserID != userID {
+		return models.Reservation{}, notFoundf("reservation with ID %d not found", id)
+	}
+	return reservation, nil
+}
+
+// ListReservations retrieves the active reservations of a user, or of
+// everyone when userID is 0
+func (s *ReservationService) ListReservations(ctx context.Context, userID int) ([]models.Reservation, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	return s.store.ListReservations(userID)
+}
+
+// Release gives the copies of a reservation back before it expires. A
+// userID other than 0 may only release its own reservations.
+func (s *ReservationService) Release(ctx context.Context, id, userID int) error {
+	if _, err := s.GetReservation(ctx, id, userID); err != nil {
+		return err
+	}
+	return s.store.DeleteReservation(id)
+}
+
+// ReleaseExpired deletes the reservations that ran out and returns how
+// many there were
+func (s *ReservationService) ReleaseExpired(ctx context.Context) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+	}
+
+	return s.store.DeleteExpired()
+}
+
+// heldByOthers counts the copies of a book that other users have reserved;
+// a user's own reservations do not keep them from buying the copies
+func (s *ReservationService) heldByOthers(bookID, userID int) (int, error) {
+	return s.store.HeldByOthers(bookID, userID)
+}
+
+// convert takes the copies a user just ordered, quantities by book ID, off
+// their reservations: the order now holds those copies, and a reservation
+// for more copies than were ordered keeps holding the rest
+func (s *ReservationService) convert(userID int, quantities map[int]int) error {
+	return s.store.ConsumeReservations(userID, quantities)
+}
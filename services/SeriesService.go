@@ -0,0 +1,128 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"context"
+	"strings"
+)
+
+type SeriesService struct {
+	store          repositories.SeriesStore
+	publisherStore repositories.PublisherStore
+	audit          *AuditService
+}
+
+func NewSeriesService(store repositories.SeriesStore, publisherStore repositories.PublisherStore, audit *AuditService) *SeriesService {
+	return &SeriesService{store: store, publisherStore: publisherStore, audit: audit}
+}
+
+// CreateSeries inserts a new series
+func (s *SeriesService) CreateSeries(ctx context.Context, series models.Series) (models.Series, error) {
+	select {
+	case <-ctx.Done():
+		return models.Series{}, ctx.Err()
+	default:
+	}
+
+	if err := s.validateSeries(&series); err != nil {
+		return models.Series{}, err
+	}
+	created, err := s.store.CreateSeries(series)
+	if err != nil {
+		return models.Series{}, err
+	}
+	s.audit.Record(ctx, models.AuditSeries, created.ID, models.AuditCreate, nil, created)
+	return s.store.GetSeries(created.ID)
+}
+
+// GetSeries retrieves a series by ID
+func (s *SeriesService) GetSeries(ctx context.Context, id int) (models.Series, error) {
+	select {
+	case <-ctx.Done():
+		return models.Series{}, ctx.Err()
+	default:
+	}
+	return s.store.GetSeries(id)
+}
+
+// UpdateSeries modifies an existing series
+func (s *SeriesService) UpdateSeries(ctx context.Context, id int, series models.Series) (models.Series, error) {
+	select {
+	case <-ctx.Done():
+		return models.Series{}, ctx.Err()
+	default:
+	}
+
+	if err := s.validateSeries(&series); err != nil {
+		return models.Series{}, err
+	}
+
+	existing, err := s.store.GetSeries(id)
+	if err != nil {
+		return models.Series{}, err
+	}
+	updated, err := s.store.UpdateSeries(id, series)
+	if err != nil {
+		return models.Series{}, err
+	}
+	s.audit.Record(ctx, models.AuditSeries, id, models.AuditUpdate, existing, updated)
+	return updated, nil
+}
+
+// DeleteSeries removes a series and detaches its books
+func (s *SeriesService) DeleteSeries(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetSeries(id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteSeries(id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditSeries, id, models.AuditDelete, existing, nil)
+	return nil
+}
+
+// ListSeries retrieves series, optionally filtered by name
+func (s *SeriesService) ListSeries(ctx context.Context, name string) ([]models.Series, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	return s.store.ListSeries(name)
+}
+
+// ListSeriesBooks retrieves the books of a series in reading order
+func (s *SeriesService) ListSeriesBooks(ctx context.Context, id int) ([]models.Book, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if _, err := s.store.GetSeries(id); err != nil {
+		return nil, err
+	}
+	return s.store.ListSeriesBooks(id)
+}
+
+func (s *SeriesService) validateSeries(series *models.Series) error {
+	series.Name = strings.TrimSpace(series.Name)
+	if series.Name == "" {
+		return invalidf("series name cannot be empty")
+	}
+	if series.PublisherID > 0 {
+		if _, err := s.publisherStore.GetPublisher(series.PublisherID); err != nil {
+			return asInvalid(err)
+		}
+	}
+	series.Publisher = nil
+	return nil
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"FinalProject/models"
+	"FinalProject/repositories"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// TaxModeFromEnv reads whether prices include tax from TAX_MODE
+func TaxModeFromEnv() string {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("TAX_MODE")))
+	switch mode {
+	case "":
+		return models.TaxExclusive
+	case models.TaxExclusive, models.TaxInclusive:
+		return mode
+	}
+	log.Printf("Invalid TAX_MODE %q, using %s", mode, models.TaxExclusive)
+	return models.TaxExclusive
+}
+
+// TaxService manages tax rates and works out the tax on orders
+type TaxService struct {
+	store repositories.TaxStore
+	mode  string
+	audit *AuditService
+}
+
+func NewTaxService(store repositories.TaxStore, mode string, audit *AuditService) *TaxService {
+	return &TaxService{store: store, mode: mode, audit: audit}
+}
+
+// CreateTaxRate inserts a new tax rate
+func (s *TaxService) CreateTaxRate(ctx context.Context, rate models.TaxRate) (models.TaxRate, error) {
+	select {
+	case <-ctx.Done():
+		return models.TaxRate{}, ctx.Err()
+	default:
+	}
+
+	if err := normalizeTaxRate(&rate); err != nil {
+		return models.TaxRate{}, err
+	}
+	rate.ID = 0
+	rate.CreatedAt = time.Time{}
+	created, err := s.store.CreateTaxRate(rate)
+	if err != nil {
+		return models.TaxRate{}, err
+	}
+	s.audit.Record(ctx, models.AuditTaxRate, created.ID, models.AuditCreate, nil, created)
+	return created, nil
+}
+
+// GetTaxRate retrieves a tax rate by ID
+func (s *TaxService) GetTaxRate(ctx context.Context, id int) (models.TaxRate, error) {
+	select {
+	case <-ctx.Done():
+		return models.TaxRate{}, ctx.Err()
+	default:
+	}
+	return s.store.GetTaxRate(id)
+}
+
+// UpdateTaxRate modifies an existing tax rate. Orders already placed keep
+// the tax they were charged.
+func (s *TaxService) UpdateTaxRate(ctx context.Context, id int, rate models.TaxRate) (models.TaxRate, error) {
+	select {
+	case <-ctx.Done():
+		return models.TaxRate{}, ctx.Err()
+	default:
+	}
+
+	if err := normalizeTaxRate(&rate); err != nil {
+		return models.TaxRate{}, err
+	}
+	existing, err := s.store.GetTaxRate(id)
+	if err != nil {
+		return models.TaxRate{}, err
+	}
+	updated, err := s.store.UpdateTaxRate(id, rate)
+	if err != nil {
+		return models.TaxRate{}, err
+	}
+	s.audit.Record(ctx, models.AuditTaxRate, id, models.AuditUpdate, existing, updated)
+	return updated, nil
+}
+
+// DeleteTaxRate removes a tax rate
+func (s *TaxService) DeleteTaxRate(ctx context.Context, id int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	existing, err := s.store.GetTaxRate(id)
+	if err != nil {
+		return err
+	}
+	if err := s.store.DeleteTaxRate(id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditTaxRate, id, models.AuditDelete, existing, nil)
+	return nil
+}
+
+// ListTaxRates retrieves every tax rate, or those of one country
+func (s *TaxService) ListTaxRates(ctx context.Context, country string) ([]models.TaxRate, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	return s.store.ListTaxRates(strings.TrimSpace(country))
+}
+
+// ratesFor fetches the tax rates that may apply to an address. An address
+// without a country is not taxed.
+func (s *TaxService) ratesFor(address models.Address) ([]models.TaxRate, error) {
+	country := strings.TrimSpace(address.Country)
+	if country == "" {
+		return nil, nil
+	}
+	return s.store.RatesFor(country, strings.TrimSpace(address.State))
+}
+
+// apply works out the tax on an order from the rates of its address and
+// sets its tax lines. Each item is taxed on what the customer pays for it:
+// its price less its promotion discount and its share of the coupon
+// discount. The tax is rounded once per rate. With exclusive pricing it is
+// added to the total; with inclusive pricing it is the part of the total
+// that is tax.
+func (s *TaxService) apply(order *models.Order, rates []models.TaxRate) {
+	order.TaxMode = s.mode
+	order.Tax = models.Money{Currency: order.Currency}
+	order.Taxes = nil
+	if len(order.Items) == 0 {
+		return
+	}
+
+	nets := make([]models.Money, len(order.Items))
+	weights := make([]int, len(order.Items))
+	for i, item := range order.Items {
+		nets[i] = item.UnitPrice.Times(item.Quantity).Sub(item.Discount)
+		weights[i] = int(max(nets[i].Minor, 0))
+	}
+	couponShares := order.CouponDiscount.Allocate(weights)
+
+	var taxes []*models.OrderTax
+	byRate := make(map[int]*models.OrderTax)
+	for i, item := range order.Items {
+		rate, ok := bestTaxRate(rates, taxCategory(item))
+		if !ok {
+			continue
+		}
+		tax, ok := byRate[rate.ID]
+		if !ok {
+			tax = &models.OrderTax{
+				TaxRateID: rate.ID,
+				Name:      rate.Name,
+				Country:   rate.Country,
+				State:     rate.State,
+				Category:  rate.Category,
+				Rate:      rate.Rate,
+				Taxable:   models.Money{Currency: order.Currency},
+			}
+			byRate[rate.ID] = tax
+			taxes = append(taxes, tax)
+		}
+		tax.Taxable = tax.Taxable.Add(nets[i].Sub(couponShares[i]))
+	}
+
+	for _, tax := range taxes {
+		if s.mode == models.TaxInclusive {
+			tax.Amount = tax.Taxable.PercentWithin(tax.Rate)
+		} else {
+			tax.Amount = tax.Taxable.Percent(tax.Rate)
+		}
+		order.Tax = order.Tax.Add(tax.Amount)
+		order.Taxes = append(order.Taxes, *tax)
+	}
+	if s.mode != models.TaxInclusive {
+		order.TotalPrice = order.TotalPrice.Add(order.Tax)
+	}
+}
+
+// taxCategory is what the copies of an item are taxed as
+func taxCategory(item models.OrderItem) string {
+	if item.EditionID > 0 && item.Edition != nil && item.Edition.Format == models.FormatEbook {
+		return models.TaxCategoryEbook
+	}
+	return models.TaxCategoryBook
+}
+
+// bestTaxRate picks the most specific of the rates of an address that
+// applies to category: a state's over its country's, then one for the
+// category over one for all
+func bestTaxRate(rates []models.TaxRate, category string) (models.TaxRate, bool) {
+	var best models.TaxRate
+	bestScore := -1
+	for _, rate := range rates {
+		if rate.Category != "" && rate.Category != category {
+			continue
+		}
+		score := 0
+		if rate.State != "" {
+			score += 2
+		}
+		if rate.Category != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = rate, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// normalizeTaxRate trims a tax rate and checks its category and rate
+func normalizeTaxRate(rate *models.TaxRate) error {
+	rate.Name = strings.TrimSpace(rate.Name)
+	rate.Country = strings.TrimSpace(rate.Country)
+	rate.State = strings.TrimSpace(rate.State)
+	rate.Category = strings.ToLower(strings.TrimSpace(rate.Category))
+	if rate.Name == "" || rate.Country == "" {
+		return invalidf("a tax rate needs a name and a country")
+	}
+	if rate.Category != "" {
+		known := false
+		for _, c := range models.TaxCategories {
+			if c == rate.Category {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return invalidf("tax category must be one of %s", strings.Join(models.TaxCategories, ", "))
+		}
+	}
+	if rate.Rate < 0 || rate.Rate > 100 {
+		return invalidf("tax rate must be between 0 and 100 percent")
+	}
+	return nil
+}
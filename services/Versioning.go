@@ -0,0 +1,20 @@
+package services
+
+import "FinalProject/repositories"
+
+// ErrVersionConflict is returned when a write names a version other than the
+// one stored, i.e. someone else changed the record in the meantime
+var ErrVersionConflict = repositories.ErrVersionConflict
+
+// matchVersion checks the version a client read against the current one and
+// returns the version to write against. An expected version of 0 stands for
+// "If-Match: *" and accepts whatever is current.
+func matchVersion(expected, current int) (int, error) {
+	if expected == 0 {
+		return current, nil
+	}
+	if expected != current {
+		return 0, ErrVersionConflict
+	}
+	return expected, nil
+}
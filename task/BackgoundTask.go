@@ -79,3 +79,156 @@ func StartDailyReportJob(rs *services.ReportService) {
 		}
 	}()
 }
+
+// StartLowStockJob checks stock against the reorder thresholds every day at
+// 06:00 UTC and sends an alert with a reorder suggestion for every book that
+// is running out
+func StartLowStockJob(rs *services.ReorderService) {
+	go func() {
+		for {
+			now := time.Now().UTC()
+			nextRun := time.Date(now.Year(), now.Month(), now.Day(), 6, 0, 0, 0, time.UTC)
+			if now.After(nextRun) {
+				nextRun = nextRun.Add(24 * time.Hour)
+			}
+
+			log.Printf("Next low-stock check scheduled at: %v", nextRun)
+			time.Sleep(time.Until(nextRun))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			alerts, err := rs.CheckLowStock(ctx)
+			cancel()
+			if err != nil {
+				controllers.LogError(err)
+				continue
+			}
+
+			log.Printf("✅ Low-stock check done: %d books at or below their reorder threshold", len(alerts))
+		}
+	}()
+}
+
+// StartPurgeJob permanently removes expired soft-deleted records every day at 03:00 UTC
+func StartPurgeJob(ps *services.PurgeService) {
+	go func() {
+		for {
+			now := time.Now().UTC()
+			nextRun := time.Date(now.Year(), now.Month(), now.Day(), 3, 0, 0, 0, time.UTC)
+			if now.After(nextRun) {
+				nextRun = nextRun.Add(24 * time.Hour)
+			}
+
+			log.Printf("Next purge of deleted records scheduled at: %v", nextRun)
+			time.Sleep(time.Until(nextRun))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			result, err := ps.PurgeDeleted(ctx)
+			cancel()
+			if err != nil {
+				controllers.LogError(err)
+				continue
+			}
+
+			log.Printf("✅ Purged deleted records: %d books, %d customers, %d authors", result.Books, result.Customers, result.Authors)
+		}
+	}()
+}
+
+// StartStockReconcileJob compares stock with the stock ledger every day at
+// 04:00 UTC and logs every figure that drifted from it
+func StartStockReconcileJob(is *services.InventoryService) {
+	go func() {
+		for {
+			now := time.Now().UTC()
+			nextRun := time.Date(now.Year(), now.Month(), now.Day(), 4, 0, 0, 0, time.UTC)
+			if now.After(nextRun) {
+				nextRun = nextRun.Add(24 * time.Hour)
+			}
+
+			log.Printf("Next stock reconciliation scheduled at: %v", nextRun)
+			time.Sleep(time.Until(nextRun))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			drift, err := is.StockDrift(ctx)
+			cancel()
+			if err != nil {
+				controllers.LogError(err)
+				continue
+			}
+
+			if len(drift) == 0 {
+				log.Println("✅ Stock matches the stock ledger")
+				continue
+			}
+			for _, d := range drift {
+				log.Printf("⚠ Stock drift: book %d, edition %d, location %d: ledger says %d, stock is %d",
+					d.BookID, d.EditionID, d.LocationID, d.Recorded, d.Actual)
+			}
+			log.Printf("⚠ Stock reconciliation found %d figures that drifted from the ledger", len(drift))
+		}
+	}()
+}
+
+// StartBackorderJob fills backorders every hour, on the hour. Restocks fill
+// them straight away; the job picks up books that were released and stock
+// that changed outside the API.
+func StartBackorderJob(ors *services.OrderService) {
+	go func() {
+		for {
+			nextRun := time.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+			time.Sleep(time.Until(nextRun))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			filled, err := ors.FillAllBackorders(ctx)
+			cancel()
+			if err != nil {
+				controllers.LogError(err)
+			}
+			if filled > 0 {
+				log.Printf("📦 Filled %d backordered order items", filled)
+			}
+		}
+	}()
+}
+
+// StartReservationSweeper releases expired reservations every minute.
+// Expired reservations stop holding stock straight away; the sweeper only
+// clears them out.
+func StartReservationSweeper(rs *services.ReservationService) {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			released, err := rs.ReleaseExpired(ctx)
+			cancel()
+			if err != nil {
+				controllers.LogError(err)
+				continue
+			}
+			if released > 0 {
+				log.Printf("🛒 Released %d expired reservations", released)
+			}
+		}
+	}()
+}
+
+// StartExportSweeper removes expired background exports and their files
+// every hour
+func StartExportSweeper(es *services.ExportService) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			removed, err := es.RemoveExpiredJobs(ctx)
+			cancel()
+			if err != nil {
+				controllers.LogError(err)
+			}
+			if removed > 0 {
+				log.Printf("📤 Removed %d expired export jobs", removed)
+			}
+		}
+	}()
+}
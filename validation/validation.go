@@ -0,0 +1,221 @@
+// Package validation checks request bodies against the rules declared in
+// their `validate` struct tags, e.g. `validate:"required,email"`.
+//
+// Rules are separated by commas and apply in order:
+//
+//	required         the value is not empty (blank strings count as empty)
+//	omitempty        skip the remaining rules when the value is empty
+//	email            a bare email address
+//	oneof=a b c      one of the space-separated values
+//	min=n, max=n     bounds on a number, or on the length of a string or list
+//	                 (a value with a Float64 method, like models.Money, is a
+//	                 number)
+//	gt, gte, lt, lte strict and inclusive bounds, as above
+//	dive             the rules after it apply to each element of a list
+//
+// Nested structs and pointers to structs are always checked, and so are list
+// elements after a dive.
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError is one rule that one field failed
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors lists every failed rule of a request
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + " " + fe.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Struct checks v, a struct or a pointer to one, and returns Errors when any
+// rule fails
+func Struct(v any) error {
+	var errs Errors
+	checkStruct(reflect.Indirect(reflect.ValueOf(v)), "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func checkStruct(v reflect.Value, prefix string, errs *Errors) {
+	if v.Kind() != reflect.Struct || v.Type() == timeType {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		var rules []string
+		if tag := f.Tag.Get("validate"); tag != "" && tag != "-" {
+			rules = strings.Split(tag, ",")
+		}
+		checkValue(v.Field(i), prefix+fieldName(f), rules, errs)
+	}
+}
+
+// fieldName is the name a field has in the request JSON
+func fieldName(f reflect.StructField) string {
+	if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return f.Name
+}
+
+func checkValue(v reflect.Value, field string, rules []string, errs *Errors) {
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "omitempty":
+			if isEmpty(v) {
+				return
+			}
+			continue
+		case "dive":
+			if v.Kind() == reflect.Pointer {
+				v = v.Elem()
+			}
+			if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+				for j := 0; j < v.Len(); j++ {
+					checkValue(v.Index(j), fmt.Sprintf("%s[%d]", field, j), rules[i+1:], errs)
+				}
+			}
+			return
+		}
+
+		if msg := check(v, name, param); msg != "" {
+			*errs = append(*errs, FieldError{Field: field, Rule: name, Message: msg})
+			// Later rules are meaningless once a value is missing
+			if name == "required" {
+				return
+			}
+		}
+	}
+
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	checkStruct(v, field+".", errs)
+}
+
+// check applies one rule and returns why v fails it, or ""
+func check(v reflect.Value, rule, param string) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if rule == "required" {
+				return "is required"
+			}
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch rule {
+	case "required":
+		if isEmpty(v) {
+			return "is required"
+		}
+	case "email":
+		addr, err := mail.ParseAddress(v.String())
+		if err != nil || addr.Address != v.String() {
+			return "must be a valid email address"
+		}
+	case "oneof":
+		options := strings.Fields(param)
+		value := fmt.Sprint(v.Interface())
+		for _, o := range options {
+			if o == value {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(options, ", ")
+	case "min", "gte":
+		return compare(v, param, func(n, limit float64) bool { return n >= limit }, "at least")
+	case "max", "lte":
+		return compare(v, param, func(n, limit float64) bool { return n <= limit }, "at most")
+	case "gt":
+		return compare(v, param, func(n, limit float64) bool { return n > limit }, "greater than")
+	case "lt":
+		return compare(v, param, func(n, limit float64) bool { return n < limit }, "less than")
+	default:
+		panic(fmt.Sprintf("validation: unknown rule %q", rule))
+	}
+	return ""
+}
+
+// number is a value that is not a Go number but compares as one
+type number interface {
+	Float64() float64
+}
+
+// compare checks a number against a bound, or the length of a string or list
+func compare(v reflect.Value, param string, ok func(n, limit float64) bool, relation string) string {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: invalid bound %q", param))
+	}
+
+	var n float64
+	unit := ""
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	case reflect.String:
+		n = float64(len([]rune(v.String())))
+		unit = " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		n = float64(v.Len())
+		unit = " items"
+	default:
+		num, isNumber := v.Interface().(number)
+		if !isNumber {
+			return ""
+		}
+		n = num.Float64()
+	}
+
+	if ok(n, limit) {
+		return ""
+	}
+	if unit == " items" {
+		return fmt.Sprintf("must have %s %s%s", relation, param, unit)
+	}
+	return fmt.Sprintf("must be %s %s%s", relation, param, unit)
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.IsZero()
+}